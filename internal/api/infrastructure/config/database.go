@@ -27,10 +27,18 @@ func DatabaseInit() {
 		panic(err)
 	}
 
-	err = database.AutoMigrate(&models.ValidationToken{}, &models.User{}, &models.Bike{}, &models.Rental{})
+	if err = migrateMoneyColumns(database); err != nil {
+		panic(err)
+	}
+
+	err = database.AutoMigrate(&models.ValidationToken{}, &models.CorporateAccount{}, &models.User{}, &models.BikeModel{}, &models.Station{}, &models.PricingPlan{}, &models.PricingPlanAssignment{}, &models.PricingRule{}, &models.Holiday{}, &models.TaxRate{}, &models.Promotion{}, &models.PromotionRedemption{}, &models.PassProduct{}, &models.Pass{}, &models.PassUsage{}, &models.LedgerAccount{}, &models.LedgerTransaction{}, &models.LedgerEntry{}, &models.PaymentMethod{}, &models.Payment{}, &models.PaymentEvent{}, &models.DocumentCounter{}, &models.Receipt{}, &models.Invoice{}, &models.Bike{}, &models.Rental{}, &models.RentalSegment{}, &models.RentalTaxLine{}, &models.Dispute{}, &models.DisputePhoto{}, &models.RentalAdjustment{}, &models.Penalty{}, &models.ScanNonce{}, &models.LockCommand{}, &models.Zone{}, &models.TripPoint{}, &models.Reservation{}, &models.BikeHold{}, &models.IdempotencyKey{})
 	if err != nil {
 		panic(err)
 	}
+
+	if err = runMigrationOnce(database, activateLegacyUsersMigration, activateLegacyUsers); err != nil {
+		panic(err)
+	}
 }
 
 // GetDatabaseInstance returns the database instance.
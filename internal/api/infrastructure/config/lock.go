@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"os"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/lock"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/mqtt"
+)
+
+var lockController lock.LockController
+
+// LockInit initializes the smart-lock driver selected by the "LOCK_DRIVER" environment variable.
+//
+// With "mqtt" commands are published to the broker at "MQTT_BROKER_URL". With "fake" an
+// in-memory driver acknowledges every command. When the variable is empty no driver is
+// configured and rentals start and end without commanding the locks.
+//
+// It does not take any parameters.
+// It does not return anything.
+// If the broker cannot be reached, it panics.
+func LockInit() {
+	switch os.Getenv("LOCK_DRIVER") {
+	case "mqtt":
+		clientID := os.Getenv("MQTT_CLIENT_ID")
+		if clientID == "" {
+			clientID = "ebike-rental-service"
+		}
+
+		client, err := mqtt.Connect(context.Background(), mqtt.Options{
+			BrokerURL: os.Getenv("MQTT_BROKER_URL"),
+			ClientID:  clientID,
+			Username:  os.Getenv("MQTT_USERNAME"),
+			Password:  os.Getenv("MQTT_PASSWORD"),
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		controller, err := lock.NewMQTTController(context.Background(), client, os.Getenv("MQTT_TOPIC_PREFIX"))
+		if err != nil {
+			panic(err)
+		}
+
+		lockController = controller
+	case "fake":
+		lockController = lock.NewFakeController()
+	default:
+		lockController = nil
+	}
+}
+
+// GetLockController returns the smart-lock driver, or nil when none is configured.
+//
+// It does not take any parameters.
+// Returns lock.LockController.
+func GetLockController() lock.LockController {
+	return lockController
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+	"gorm.io/gorm"
+)
+
+// moneyColumns lists the amounts that used to be stored as floating point numbers in major
+// units, by table. Each one is now stored in minor units with its currency, in the
+// "<column>_amount" and "<column>_currency" columns.
+var moneyColumns = map[string][]string{
+	"bikes":         {"price_per_hour"},
+	"bike_models":   {"default_price_per_hour"},
+	"rentals":       {"total_cost", "zone_fee"},
+	"zones":         {"fee"},
+	"pricing_plans": {"unlock_fee", "per_minute_rate", "daily_cap", "minimum_charge"},
+}
+
+const moneyMigrationBatchSize = 1000
+
+// migrateMoneyColumns converts the floating point amounts of a database created before amounts
+// carried a currency. Existing amounts are taken as amounts of the default currency and rounded
+// to minor units with the configured money rounding. Tables that were already converted are
+// left alone, so it is safe to run on every start.
+func migrateMoneyColumns(db *gorm.DB) error {
+	for table, columns := range moneyColumns {
+		for _, column := range columns {
+			if !db.Migrator().HasTable(table) || !db.Migrator().HasColumn(table, column) {
+				continue
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return migrateMoneyColumn(tx, table, column)
+			})
+			if err != nil {
+				return fmt.Errorf("migrating %s.%s: %w", table, column, err)
+			}
+		}
+	}
+
+	if db.Migrator().HasTable("rentals") && db.Migrator().HasColumn("rentals", "price_breakdown") {
+		if err := db.Transaction(migratePriceBreakdowns); err != nil {
+			return fmt.Errorf("migrating rentals.price_breakdown: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateMoneyColumn moves a floating point column into its amount and currency columns and
+// drops it.
+func migrateMoneyColumn(tx *gorm.DB, table string, column string) error {
+	currency, mode := utils.DefaultCurrency(), utils.MoneyRounding()
+
+	statements := []string{
+		fmt.Sprintf(`ALTER TABLE %q ADD COLUMN IF NOT EXISTS %q bigint NOT NULL DEFAULT 0`, table, column+"_amount"),
+		fmt.Sprintf(`ALTER TABLE %q ADD COLUMN IF NOT EXISTS %q varchar(3) NOT NULL DEFAULT ''`, table, column+"_currency"),
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	lastID := ""
+	for {
+		var rows []struct {
+			ID    string
+			Value *float64
+		}
+
+		query := fmt.Sprintf(`SELECT id::text AS id, %q AS value FROM %q WHERE id::text > ? ORDER BY id::text LIMIT ?`, column, table)
+		if err := tx.Raw(query, lastID, moneyMigrationBatchSize).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			amount := money.Money{Currency: currency}
+			if row.Value != nil {
+				amount = majorUnitsToMoney(*row.Value, currency, mode)
+			}
+
+			update := fmt.Sprintf(`UPDATE %q SET %q = ?, %q = ? WHERE id::text = ?`, table, column+"_amount", column+"_currency")
+			if err := tx.Exec(update, amount.Amount, amount.Currency, row.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < moneyMigrationBatchSize {
+			break
+		}
+
+		lastID = rows[len(rows)-1].ID
+	}
+
+	return tx.Exec(fmt.Sprintf(`ALTER TABLE %q DROP COLUMN %q`, table, column)).Error
+}
+
+// legacyBreakdown is a price breakdown stored before amounts carried a currency.
+type legacyBreakdown struct {
+	Plan          string   `json:"plan"`
+	BilledMinutes float64  `json:"billed_minutes"`
+	Rules         []string `json:"rules"`
+	Lines         []struct {
+		Code        string  `json:"code"`
+		Description string  `json:"description"`
+		Amount      float64 `json:"amount"`
+	} `json:"lines"`
+	Total float64 `json:"total"`
+}
+
+// migratePriceBreakdowns rewrites the price breakdowns whose amounts are plain numbers.
+func migratePriceBreakdowns(tx *gorm.DB) error {
+	var rows []struct {
+		ID             string
+		PriceBreakdown string
+	}
+
+	err := tx.Raw(`SELECT id::text AS id, price_breakdown::text AS price_breakdown FROM rentals WHERE jsonb_typeof(price_breakdown->'total') = 'number'`).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	currency, mode := utils.DefaultCurrency(), utils.MoneyRounding()
+
+	for _, row := range rows {
+		var legacy legacyBreakdown
+		if err := json.Unmarshal([]byte(row.PriceBreakdown), &legacy); err != nil {
+			return fmt.Errorf("rental %s: %w", row.ID, err)
+		}
+
+		breakdown := pricing.Breakdown{
+			Plan:          legacy.Plan,
+			BilledMinutes: legacy.BilledMinutes,
+			Rules:         legacy.Rules,
+			Total:         money.New(0, currency),
+		}
+
+		for _, line := range legacy.Lines {
+			breakdown.Add(line.Code, line.Description, majorUnitsToMoney(line.Amount, currency, mode))
+		}
+
+		encoded, err := json.Marshal(breakdown)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`UPDATE rentals SET price_breakdown = ? WHERE id::text = ?`, string(encoded), row.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// majorUnitsToMoney converts a floating point amount in major units. The amount is read from
+// its shortest decimal form, so 0.1 is exactly one tenth.
+func majorUnitsToMoney(value float64, currency string, mode money.RoundingMode) money.Money {
+	amount, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(money.MinorUnits(currency))), nil)))
+
+	return money.FromMinor(amount, currency, mode)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
+)
+
+var paymentGateway payment.PaymentGateway
+
+// PaymentInit initializes the payment gateway selected by the "PAYMENT_DRIVER" environment
+// variable.
+//
+// With "fake" an in-memory gateway answers according to the test card tokens, signs its
+// webhooks with "PAYMENT_WEBHOOK_SECRET" and posts the asynchronous results to the webhook
+// endpoint of the API under "PUBLIC_API_URL". When the variable is empty no gateway is
+// configured and rentals are only charged to the rider wallets.
+//
+// It does not take any parameters.
+// It does not return anything.
+func PaymentInit() {
+	switch os.Getenv("PAYMENT_DRIVER") {
+	case "fake":
+		gateway := payment.NewFakeGateway(os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+
+		webhookURL := strings.TrimRight(os.Getenv("PUBLIC_API_URL"), "/") + "/v1/payments/webhook"
+		gateway.OnWebhook(func(payload []byte, signature string) {
+			request, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+			if err != nil {
+				slog.Error("failed to deliver payment webhook", "error", err)
+				return
+			}
+
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set(payment.SignatureHeader, signature)
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				slog.Error("failed to deliver payment webhook", "error", err)
+				return
+			}
+			response.Body.Close()
+		}, 2*time.Second)
+
+		paymentGateway = gateway
+	default:
+		paymentGateway = nil
+	}
+}
+
+// GetPaymentGateway returns the payment gateway, or nil when none is configured.
+//
+// It does not take any parameters.
+// Returns payment.PaymentGateway.
+func GetPaymentGateway() payment.PaymentGateway {
+	return paymentGateway
+}
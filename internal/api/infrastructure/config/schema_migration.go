@@ -0,0 +1,41 @@
+package config
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// schemaMigration records a one-shot data migration applied to the database.
+type schemaMigration struct {
+	ID        string    `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName returns the table the applied migrations are recorded in.
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// runMigrationOnce applies a one-shot data migration unless the "schema_migrations" table records
+// it already. The migration and its record are written in the same transaction, so it is applied
+// exactly once, even when several instances start at the same time.
+func runMigrationOnce(db *gorm.DB, id string, migrate func(tx *gorm.DB) error) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&schemaMigration{ID: id, AppliedAt: time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return migrate(tx)
+	})
+}
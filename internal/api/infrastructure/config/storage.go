@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/storage"
+)
+
+var blobStore storage.BlobStore
+
+// StorageInit initializes the blob store selected by the "STORAGE_DRIVER" environment variable.
+//
+// With "local" (the default) objects are written to "STORAGE_LOCAL_DIR" and served by the API
+// itself under "PUBLIC_API_URL". With "s3" objects are written to the S3-compatible bucket
+// described by the "S3_*" variables.
+//
+// It does not take any parameters.
+// It does not return anything.
+// If the store cannot be created, it panics.
+func StorageInit() {
+	var err error
+
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		blobStore, err = storage.NewS3Store(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			ForcePathStyle:  os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		})
+	default:
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+
+		secret := os.Getenv("STORAGE_SIGNING_SECRET")
+		if secret == "" {
+			secret = os.Getenv("JWT_SECRET")
+		}
+
+		blobStore, err = storage.NewLocalStore(dir, strings.TrimSuffix(os.Getenv("PUBLIC_API_URL"), "/")+"/v1/images", secret)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetBlobStore returns the blob store instance.
+//
+// It does not take any parameters.
+// Returns storage.BlobStore.
+func GetBlobStore() storage.BlobStore {
+	return blobStore
+}
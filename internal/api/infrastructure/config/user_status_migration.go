@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"gorm.io/gorm"
+)
+
+// activateLegacyUsersMigration identifies activateLegacyUsers in the "schema_migrations" table.
+const activateLegacyUsersMigration = "activate_verified_users"
+
+// activateLegacyUsers activates the verified accounts created before validating an account made
+// it active, so their riders are not refused rentals as inactive. It runs once per database, through
+// runMigrationOnce: an account deactivated afterwards stays inactive.
+func activateLegacyUsers(tx *gorm.DB) error {
+	return tx.Model(&models.User{}).
+		Where("verified = ? AND status = ?", true, models.UserStatusInactive).
+		Update("status", models.UserStatusActive).Error
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// BikeCatalogHandler handles HTTP requests related to the bike model catalog.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - bikeCatalogService: a pointer to a services.BikeCatalogService object providing the catalog operations.
+func BikeCatalogHandler(router *gin.Engine, bikeCatalogService *services.BikeCatalogService) {
+	v1 := router.Group("/v1")
+	{
+		catalogRouter := v1.Group("/bike-models")
+		catalogRouter.Use(middlewares.AuthMiddleware())
+		{
+			catalogRouter.GET("/", bikeCatalogService.GetAllBikeModels)
+			catalogRouter.GET("/:id", bikeCatalogService.GetBikeModelByID)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/bike-models")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", bikeCatalogService.CreateBikeModel)
+			adminRouter.PUT("/:id", bikeCatalogService.UpdateBikeModel)
+			adminRouter.PUT("/:id/price", bikeCatalogService.RepriceBikeModel)
+			adminRouter.DELETE("/:id", bikeCatalogService.DeleteBikeModel)
+		}
+	}
+}
@@ -29,6 +29,9 @@ func BikeHandler(router *gin.Engine, bikeService *services.BikeService) {
 		adminRouter.Use(middlewares.AdminOnly())
 		{
 			adminRouter.POST("/", bikeService.CreateBike)
+			adminRouter.POST("/import", bikeService.ImportBikes)
+			adminRouter.GET("/export", bikeService.ExportBikes)
+			adminRouter.GET("/:id/qr", bikeService.GetBikeQRCode)
 			adminRouter.PUT("/:id", bikeService.UpdateBike)
 			adminRouter.DELETE("/:id", bikeService.DeleteBike)
 		}
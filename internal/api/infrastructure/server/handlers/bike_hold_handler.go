@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// BikeHoldHandler handles HTTP requests related to bike holds.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - bikeHoldService: a pointer to a services.BikeHoldService object providing the hold operations.
+func BikeHoldHandler(router *gin.Engine, bikeHoldService *services.BikeHoldService) {
+	v1 := router.Group("/v1")
+	{
+		holdRouter := v1.Group("/holds")
+		holdRouter.Use(middlewares.AuthMiddleware())
+		{
+			holdRouter.POST("/", middlewares.IdempotencyMiddleware(), bikeHoldService.CreateHold)
+			holdRouter.GET("/current", bikeHoldService.GetCurrentHold)
+			holdRouter.DELETE("/:id", bikeHoldService.ReleaseHold)
+		}
+	}
+}
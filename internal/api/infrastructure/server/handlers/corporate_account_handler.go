@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// CorporateAccountHandler handles HTTP requests related to corporate accounts.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - corporateAccountService: a pointer to a services.CorporateAccountService object providing the corporate account operations.
+func CorporateAccountHandler(router *gin.Engine, corporateAccountService *services.CorporateAccountService) {
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/corporate-accounts")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", corporateAccountService.CreateCorporateAccount)
+			adminRouter.GET("/", corporateAccountService.GetAllCorporateAccounts)
+			adminRouter.GET("/:id", corporateAccountService.GetCorporateAccountByID)
+			adminRouter.PUT("/:id", corporateAccountService.UpdateCorporateAccount)
+			adminRouter.DELETE("/:id", corporateAccountService.DeleteCorporateAccount)
+			adminRouter.GET("/:id/members", corporateAccountService.GetCorporateAccountMembers)
+			adminRouter.POST("/:id/members/:userId", corporateAccountService.AddCorporateAccountMember)
+			adminRouter.DELETE("/:id/members/:userId", corporateAccountService.RemoveCorporateAccountMember)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// DisputeHandler handles HTTP requests related to rental disputes.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - disputeService: a pointer to a services.DisputeService object providing the dispute operations.
+func DisputeHandler(router *gin.Engine, disputeService *services.DisputeService) {
+	v1 := router.Group("/v1")
+	{
+		disputeRouter := v1.Group("/disputes")
+		disputeRouter.Use(middlewares.AuthMiddleware())
+		{
+			disputeRouter.POST("/", disputeService.CreateDispute)
+			disputeRouter.GET("/", disputeService.GetMyDisputes)
+			disputeRouter.GET("/:id", disputeService.GetDisputeByID)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		disputeRouter := admin.Group("/disputes")
+		disputeRouter.Use(middlewares.AuthMiddleware())
+		disputeRouter.Use(middlewares.AdminOnly())
+		{
+			disputeRouter.GET("/", disputeService.GetAllDisputes)
+			disputeRouter.GET("/:id", disputeService.GetDisputeByID)
+			disputeRouter.POST("/:id/refund", middlewares.IdempotencyMiddleware(), disputeService.RefundDispute)
+			disputeRouter.POST("/:id/end-time", middlewares.IdempotencyMiddleware(), disputeService.AdjustDisputeEndTime)
+			disputeRouter.POST("/:id/reject", disputeService.RejectDispute)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// ImageHandler registers the image upload and download routes with the provided gin.Engine.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - imageService: a pointer to a services.ImageService object providing the image-related operations.
+func ImageHandler(router *gin.Engine, imageService *services.ImageService) {
+	v1 := router.Group("/v1")
+	{
+		// Signed URLs of the local store carry their own authorization.
+		v1.GET("/images/*key", imageService.ServeImage)
+
+		bikesRouter := v1.Group("/bikes")
+		bikesRouter.Use(middlewares.AuthMiddleware())
+		{
+			bikesRouter.GET("/:id/image", imageService.GetBikeImage)
+		}
+
+		userRouter := v1.Group("/users")
+		userRouter.Use(middlewares.AuthMiddleware())
+		{
+			userRouter.GET("/:id/image", imageService.GetUserImage)
+			userRouter.POST("/:id/image", imageService.UploadUserImage)
+		}
+
+		disputeRouter := v1.Group("/disputes")
+		disputeRouter.Use(middlewares.AuthMiddleware())
+		{
+			disputeRouter.GET("/:id/photos/:photoId", imageService.GetDisputePhoto)
+			disputeRouter.POST("/:id/photos", imageService.UploadDisputePhoto)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/bikes")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/:id/image", imageService.UploadBikeImage)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// PassHandler handles HTTP requests related to passes and pass products.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - passService: a pointer to a services.PassService object providing the pass operations.
+func PassHandler(router *gin.Engine, passService *services.PassService) {
+	v1 := router.Group("/v1")
+	{
+		passRouter := v1.Group("/passes")
+		passRouter.Use(middlewares.AuthMiddleware())
+		{
+			passRouter.GET("/products", passService.GetPassProductsOnSale)
+			passRouter.POST("/", middlewares.IdempotencyMiddleware(), passService.BuyPass)
+			passRouter.GET("/", passService.GetMyPasses)
+			passRouter.GET("/:id", passService.GetPassByID)
+			passRouter.POST("/:id/cancel", passService.CancelPass)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/pass-products")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", passService.CreatePassProduct)
+			adminRouter.GET("/", passService.GetAllPassProducts)
+			adminRouter.GET("/:id", passService.GetPassProductByID)
+			adminRouter.PUT("/:id", passService.UpdatePassProduct)
+			adminRouter.DELETE("/:id", passService.DeletePassProduct)
+		}
+	}
+}
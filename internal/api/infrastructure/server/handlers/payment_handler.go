@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// PaymentHandler handles HTTP requests related to payment methods, payments and the webhooks of
+// the payment provider.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - paymentService: a pointer to a services.PaymentService object providing the payment operations.
+func PaymentHandler(router *gin.Engine, paymentService *services.PaymentService) {
+	v1 := router.Group("/v1")
+	{
+		v1.POST("/payments/webhook", paymentService.HandleWebhook)
+
+		paymentRouter := v1.Group("/payments")
+		paymentRouter.Use(middlewares.AuthMiddleware())
+		{
+			paymentRouter.GET("/", paymentService.GetMyPayments)
+			paymentRouter.POST("/methods", middlewares.IdempotencyMiddleware(), paymentService.AddPaymentMethod)
+			paymentRouter.GET("/methods", paymentService.GetMyPaymentMethods)
+			paymentRouter.POST("/methods/:id/default", paymentService.SetDefaultPaymentMethod)
+			paymentRouter.DELETE("/methods/:id", paymentService.DeletePaymentMethod)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/payments")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.GET("/", paymentService.GetAllPayments)
+			adminRouter.GET("/:id", paymentService.GetPaymentByID)
+			adminRouter.POST("/:id/refund", middlewares.IdempotencyMiddleware(), paymentService.RefundPayment)
+			adminRouter.POST("/:id/void", middlewares.IdempotencyMiddleware(), paymentService.VoidPayment)
+		}
+	}
+}
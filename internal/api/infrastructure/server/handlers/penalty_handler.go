@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// PenaltyHandler handles HTTP requests related to rental penalties.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - penaltyService: a pointer to a services.PenaltyService object providing the penalty operations.
+func PenaltyHandler(router *gin.Engine, penaltyService *services.PenaltyService) {
+	v1 := router.Group("/v1")
+	{
+		penaltyRouter := v1.Group("/penalties")
+		penaltyRouter.Use(middlewares.AuthMiddleware())
+		{
+			penaltyRouter.GET("/", penaltyService.GetMyPenalties)
+			penaltyRouter.POST("/:id/pay", middlewares.IdempotencyMiddleware(), penaltyService.PayPenalty)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		penaltyRouter := admin.Group("/penalties")
+		penaltyRouter.Use(middlewares.AuthMiddleware())
+		penaltyRouter.Use(middlewares.AdminOnly())
+		{
+			penaltyRouter.GET("/", penaltyService.GetAllPenalties)
+			penaltyRouter.POST("/:id/waive", penaltyService.WaivePenalty)
+		}
+	}
+}
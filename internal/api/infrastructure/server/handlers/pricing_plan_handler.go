@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// PricingPlanHandler handles HTTP requests related to pricing plans, pricing rules and the
+// holiday calendar.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - pricingPlanService: a pointer to a services.PricingPlanService object providing the pricing plan operations.
+func PricingPlanHandler(router *gin.Engine, pricingPlanService *services.PricingPlanService) {
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/pricing-plans")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", pricingPlanService.CreatePricingPlan)
+			adminRouter.GET("/", pricingPlanService.GetAllPricingPlans)
+			adminRouter.GET("/:id", pricingPlanService.GetPricingPlanByID)
+			adminRouter.PUT("/:id", pricingPlanService.UpdatePricingPlan)
+			adminRouter.DELETE("/:id", pricingPlanService.DeletePricingPlan)
+			adminRouter.POST("/:id/assignments", pricingPlanService.AssignPricingPlan)
+			adminRouter.DELETE("/assignments/:assignmentId", pricingPlanService.DeletePricingPlanAssignment)
+		}
+
+		ruleRouter := admin.Group("/pricing-rules")
+		ruleRouter.Use(middlewares.AuthMiddleware())
+		ruleRouter.Use(middlewares.AdminOnly())
+		{
+			ruleRouter.POST("/", pricingPlanService.CreatePricingRule)
+			ruleRouter.GET("/", pricingPlanService.GetAllPricingRules)
+			ruleRouter.GET("/:id", pricingPlanService.GetPricingRuleByID)
+			ruleRouter.PUT("/:id", pricingPlanService.UpdatePricingRule)
+			ruleRouter.DELETE("/:id", pricingPlanService.DeletePricingRule)
+		}
+
+		holidayRouter := admin.Group("/holidays")
+		holidayRouter.Use(middlewares.AuthMiddleware())
+		holidayRouter.Use(middlewares.AdminOnly())
+		{
+			holidayRouter.POST("/", pricingPlanService.CreateHoliday)
+			holidayRouter.GET("/", pricingPlanService.GetHolidays)
+			holidayRouter.DELETE("/:id", pricingPlanService.DeleteHoliday)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// PromotionHandler handles HTTP requests related to promo codes.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - promotionService: a pointer to a services.PromotionService object providing the promotion operations.
+func PromotionHandler(router *gin.Engine, promotionService *services.PromotionService) {
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/promotions")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", promotionService.CreatePromotion)
+			adminRouter.GET("/", promotionService.GetAllPromotions)
+			adminRouter.GET("/:id", promotionService.GetPromotionByID)
+			adminRouter.PUT("/:id", promotionService.UpdatePromotion)
+			adminRouter.DELETE("/:id", promotionService.DeletePromotion)
+			adminRouter.GET("/:id/redemptions", promotionService.GetPromotionRedemptions)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// ReceiptHandler handles HTTP requests related to rental receipts and corporate invoices.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - receiptService: a pointer to a services.ReceiptService object providing the receipt and invoice operations.
+func ReceiptHandler(router *gin.Engine, receiptService *services.ReceiptService) {
+	v1 := router.Group("/v1")
+	{
+		receiptRouter := v1.Group("/rentals")
+		receiptRouter.Use(middlewares.AuthMiddleware())
+		{
+			// The rentals group already routes "/:userId", and gin needs a wildcard to keep its
+			// name along a path, so the rental ID is read from "userId".
+			receiptRouter.GET("/:userId/receipt", receiptService.GetRentalReceipt)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		invoiceRouter := admin.Group("/invoices")
+		invoiceRouter.Use(middlewares.AuthMiddleware())
+		invoiceRouter.Use(middlewares.AdminOnly())
+		{
+			invoiceRouter.GET("/", receiptService.GetInvoices)
+			invoiceRouter.GET("/:id", receiptService.GetInvoiceByID)
+			invoiceRouter.POST("/run", middlewares.IdempotencyMiddleware(), receiptService.RunInvoices)
+		}
+	}
+}
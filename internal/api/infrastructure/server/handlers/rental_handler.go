@@ -12,8 +12,15 @@ func RentalHandler(router *gin.Engine, rentalService *services.RentalService) {
 		rentalRouter := v1.Group("/rentals")
 		rentalRouter.Use(middlewares.AuthMiddleware())
 		{
-			rentalRouter.POST("/rent/:bikeId", rentalService.CreateRental)
-			rentalRouter.POST("/return/:rentalId", rentalService.ReturnBike)
+			rentalRouter.POST("/rent/:bikeId", middlewares.IdempotencyMiddleware(), rentalService.CreateRental)
+			rentalRouter.POST("/scan", middlewares.IdempotencyMiddleware(), rentalService.ScanRental)
+			rentalRouter.POST("/return/:rentalId", middlewares.IdempotencyMiddleware(), rentalService.ReturnBike)
+			rentalRouter.POST("/pause/:rentalId", middlewares.IdempotencyMiddleware(), rentalService.PauseRental)
+			rentalRouter.POST("/resume/:rentalId", middlewares.IdempotencyMiddleware(), rentalService.ResumeRental)
+			rentalRouter.POST("/points/:rentalId", rentalService.AddTripPoints)
+			rentalRouter.GET("/route/:rentalId", rentalService.GetRentalRoute)
+			rentalRouter.GET("/quote/:bikeId", rentalService.QuoteRental)
+			rentalRouter.GET("/eligibility", rentalService.GetRentalEligibility)
 			rentalRouter.GET("/:userId", rentalService.GetRentalByUserID)
 		}
 	}
@@ -25,6 +32,7 @@ func RentalHandler(router *gin.Engine, rentalService *services.RentalService) {
 		adminRouter.Use(middlewares.AdminOnly())
 		{
 			adminRouter.GET("/", rentalService.GetAllRentals)
+			adminRouter.GET("/overdue", rentalService.GetOverdueRentals)
 		}
 	}
 }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// ReservationHandler handles HTTP requests related to reservations.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - reservationService: a pointer to a services.ReservationService object providing the reservation operations.
+func ReservationHandler(router *gin.Engine, reservationService *services.ReservationService) {
+	v1 := router.Group("/v1")
+	{
+		reservationRouter := v1.Group("/reservations")
+		reservationRouter.Use(middlewares.AuthMiddleware())
+		{
+			reservationRouter.POST("/", middlewares.IdempotencyMiddleware(), reservationService.CreateReservation)
+			reservationRouter.GET("/", reservationService.GetMyReservations)
+			reservationRouter.GET("/:id", reservationService.GetReservationByID)
+			reservationRouter.POST("/:id/cancel", reservationService.CancelReservation)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/reservations")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.GET("/", reservationService.GetAllReservations)
+		}
+	}
+}
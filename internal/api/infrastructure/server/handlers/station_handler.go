@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// StationHandler handles HTTP requests related to stations.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - stationService: a pointer to a services.StationService object providing the station operations.
+func StationHandler(router *gin.Engine, stationService *services.StationService) {
+	v1 := router.Group("/v1")
+	{
+		stationRouter := v1.Group("/stations")
+		stationRouter.Use(middlewares.AuthMiddleware())
+		{
+			stationRouter.GET("/", stationService.GetAllStations)
+			stationRouter.GET("/:id", stationService.GetStationByID)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/stations")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", stationService.CreateStation)
+			adminRouter.PUT("/:id", stationService.UpdateStation)
+			adminRouter.DELETE("/:id", stationService.DeleteStation)
+		}
+	}
+}
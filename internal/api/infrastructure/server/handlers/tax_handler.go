@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// TaxHandler handles HTTP requests related to tax rates and revenue reports.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - taxService: a pointer to a services.TaxService object providing the tax operations.
+func TaxHandler(router *gin.Engine, taxService *services.TaxService) {
+	admin := router.Group("/v1/admin")
+	{
+		taxRouter := admin.Group("/tax-rates")
+		taxRouter.Use(middlewares.AuthMiddleware())
+		taxRouter.Use(middlewares.AdminOnly())
+		{
+			taxRouter.POST("/", taxService.CreateTaxRate)
+			taxRouter.GET("/", taxService.GetTaxRates)
+			taxRouter.GET("/:id", taxService.GetTaxRateByID)
+			taxRouter.DELETE("/:id", taxService.DeleteTaxRate)
+		}
+
+		reportRouter := admin.Group("/reports")
+		reportRouter.Use(middlewares.AuthMiddleware())
+		reportRouter.Use(middlewares.AdminOnly())
+		{
+			reportRouter.GET("/revenue", taxService.GetRevenueReport)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// WalletHandler handles HTTP requests related to rider wallets and the ledger.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - walletService: a pointer to a services.WalletService object providing the wallet operations.
+func WalletHandler(router *gin.Engine, walletService *services.WalletService) {
+	v1 := router.Group("/v1")
+	{
+		walletRouter := v1.Group("/wallet")
+		walletRouter.Use(middlewares.AuthMiddleware())
+		{
+			walletRouter.GET("/", walletService.GetMyWallet)
+			walletRouter.POST("/top-up", middlewares.IdempotencyMiddleware(), walletService.TopUpWallet)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		walletRouter := admin.Group("/wallets")
+		walletRouter.Use(middlewares.AuthMiddleware())
+		walletRouter.Use(middlewares.AdminOnly())
+		{
+			walletRouter.GET("/:userId", walletService.GetWalletByUserID)
+			walletRouter.POST("/:userId/adjustments", middlewares.IdempotencyMiddleware(), walletService.AdjustWallet)
+		}
+
+		ledgerRouter := admin.Group("/ledger")
+		ledgerRouter.Use(middlewares.AuthMiddleware())
+		ledgerRouter.Use(middlewares.AdminOnly())
+		{
+			ledgerRouter.GET("/transactions", walletService.GetLedgerTransactions)
+			ledgerRouter.POST("/transactions/:id/reverse", walletService.ReverseLedgerTransaction)
+			ledgerRouter.POST("/refunds", middlewares.IdempotencyMiddleware(), walletService.RefundRental)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/server/middlewares"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/services"
+)
+
+// ZoneHandler handles HTTP requests related to geofenced zones.
+//
+// Parameters:
+// - router: a pointer to a gin.Engine object representing the HTTP router.
+// - zoneService: a pointer to a services.ZoneService object providing the zone operations.
+func ZoneHandler(router *gin.Engine, zoneService *services.ZoneService) {
+	v1 := router.Group("/v1")
+	{
+		zoneRouter := v1.Group("/zones")
+		zoneRouter.Use(middlewares.AuthMiddleware())
+		{
+			zoneRouter.GET("/", zoneService.GetAllZones)
+			zoneRouter.GET("/check", zoneService.CheckPosition)
+			zoneRouter.GET("/:id", zoneService.GetZoneByID)
+		}
+	}
+
+	admin := router.Group("/v1/admin")
+	{
+		adminRouter := admin.Group("/zones")
+		adminRouter.Use(middlewares.AuthMiddleware())
+		adminRouter.Use(middlewares.AdminOnly())
+		{
+			adminRouter.POST("/", zoneService.CreateZone)
+			adminRouter.POST("/import", zoneService.ImportZones)
+			adminRouter.PUT("/:id", zoneService.UpdateZone)
+			adminRouter.DELETE("/:id", zoneService.DeleteZone)
+		}
+	}
+}
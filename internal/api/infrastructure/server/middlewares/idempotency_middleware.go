@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/infrastructure/config"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+)
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyMaxBodySize is the largest request body read to fingerprint a request.
+const idempotencyMaxBodySize = 1 << 20
+
+// idempotencyResponseWriter copies the response body while it is written to the client.
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes a route safe to retry. It must run after AuthMiddleware.
+//
+// When a request carries an "Idempotency-Key" header, the key is stored per user with a
+// fingerprint of the request (method, path and body) for "IDEMPOTENCY_KEY_TTL" (24 hours by
+// default). A retry with the same key and request gets the original response back, marked with
+// an "Idempotent-Replayed" header, without running the handler again. Reusing a key for a
+// different request is rejected, as is a retry sent while the original is still running.
+// Server errors release the key, so the request can be retried. Bodies larger than
+// idempotencyMaxBodySize are rejected with 413.
+//
+// Requests without the header are passed through unchanged.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "idempotency key must be at most 255 characters long"})
+			c.Abort()
+			return
+		}
+
+		loggedUser, err := utils.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, idempotencyMaxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("body must be at most %d bytes", idempotencyMaxBodySize)})
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + "\n" + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+
+		record := &models.IdempotencyKey{
+			UserID:      loggedUser.ID,
+			Key:         key,
+			Fingerprint: hex.EncodeToString(hash.Sum(nil)),
+			Status:      models.IDEMPOTENCY_KEY_STATUS_PROCESSING,
+			ExpiresAt:   time.Now().Add(idempotencyKeyTTL()),
+		}
+
+		repo := repositories.NewIdempotencyRepository(config.GetDatabaseInstance())
+
+		claimed, err := repo.CreateIdempotencyKey(record)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to store idempotency key"})
+			c.Abort()
+			return
+		}
+
+		if !claimed {
+			replayIdempotentResponse(c, repo, record)
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				if err := repo.DeleteIdempotencyKey(record.UserID.String(), record.Key); err != nil {
+					slog.Error("failed to release idempotency key", "key", record.Key, "error", err)
+				}
+			}
+		}()
+
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record.StatusCode = writer.Status()
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.ResponseBody = writer.body.Bytes()
+
+		if err := repo.CompleteIdempotencyKey(record); err != nil {
+			slog.Error("failed to store idempotent response", "key", record.Key, "error", err)
+			return
+		}
+
+		completed = true
+	}
+}
+
+// replayIdempotentResponse answers a request whose idempotency key was already used.
+func replayIdempotentResponse(c *gin.Context, repo repositories.IdempotencyRepository, record *models.IdempotencyKey) {
+	defer c.Abort()
+
+	existing, err := repo.GetIdempotencyKey(record.UserID.String(), record.Key)
+	if err != nil {
+		if strings.Contains(err.Error(), "idempotency key not found") {
+			// The original request failed and released the key in the meantime.
+			c.JSON(http.StatusConflict, gin.H{"message": "a request with this idempotency key is still being processed"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get idempotency key"})
+		return
+	}
+
+	if existing.Fingerprint != record.Fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "idempotency key was already used for a different request"})
+		return
+	}
+
+	if existing.Status != models.IDEMPOTENCY_KEY_STATUS_COMPLETED {
+		c.JSON(http.StatusConflict, gin.H{"message": "a request with this idempotency key is still being processed"})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+
+	if len(existing.ResponseBody) == 0 {
+		c.Status(existing.StatusCode)
+		return
+	}
+
+	c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+}
+
+// idempotencyKeyTTL returns how long idempotency keys are kept, read from the
+// "IDEMPOTENCY_KEY_TTL" environment variable.
+func idempotencyKeyTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_KEY_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return 24 * time.Hour
+}
@@ -45,6 +45,15 @@ func StartServer() error {
 	}
 	defer dbGorm.Close()
 
+	// Storage
+	config.StorageInit()
+
+	// Smart locks
+	config.LockInit()
+
+	// Payments
+	config.PaymentInit()
+
 	// Init router
 	router := gin.Default()
 
@@ -58,13 +67,53 @@ func StartServer() error {
 	authService := services.NewAuthService(repositories.NewAuthRepository(config.GetDatabaseInstance()))
 	userService := services.NewUserService(repositories.NewUserRepository(config.GetDatabaseInstance()))
 	bikeService := services.NewBikeService(repositories.NewBikeRepository(config.GetDatabaseInstance()))
-	rentalService := services.NewRentalService(repositories.NewRentalRepository(config.GetDatabaseInstance()))
+	bikeCatalogService := services.NewBikeCatalogService(repositories.NewBikeCatalogRepository(config.GetDatabaseInstance()))
+	receiptService := services.NewReceiptService(repositories.NewReceiptRepository(config.GetDatabaseInstance()))
+	rentalService := services.NewRentalService(repositories.NewRentalRepository(config.GetDatabaseInstance()), config.GetLockController(), config.GetPaymentGateway(), receiptService)
+	imageService := services.NewImageService(repositories.NewImageRepository(config.GetDatabaseInstance()), config.GetBlobStore())
+	zoneService := services.NewZoneService(repositories.NewZoneRepository(config.GetDatabaseInstance()))
+	stationService := services.NewStationService(repositories.NewStationRepository(config.GetDatabaseInstance()))
+	reservationService := services.NewReservationService(repositories.NewReservationRepository(config.GetDatabaseInstance()))
+	bikeHoldService := services.NewBikeHoldService(repositories.NewBikeHoldRepository(config.GetDatabaseInstance()))
+	pricingPlanService := services.NewPricingPlanService(repositories.NewPricingPlanRepository(config.GetDatabaseInstance()))
+	promotionService := services.NewPromotionService(repositories.NewPromotionRepository(config.GetDatabaseInstance()))
+	passService := services.NewPassService(repositories.NewPassRepository(config.GetDatabaseInstance()))
+	walletService := services.NewWalletService(repositories.NewWalletRepository(config.GetDatabaseInstance()))
+	paymentService := services.NewPaymentService(repositories.NewPaymentRepository(config.GetDatabaseInstance()), config.GetPaymentGateway())
+	corporateAccountService := services.NewCorporateAccountService(repositories.NewCorporateAccountRepository(config.GetDatabaseInstance()))
+	taxService := services.NewTaxService(repositories.NewTaxRepository(config.GetDatabaseInstance()))
+	disputeService := services.NewDisputeService(repositories.NewDisputeRepository(config.GetDatabaseInstance()), rentalService)
+	penaltyService := services.NewPenaltyService(repositories.NewPenaltyRepository(config.GetDatabaseInstance()))
+
+	// Startup tasks
+	go bikeService.BackfillBikeCodes()
+	go reservationService.SweepNoShows()
+	go bikeHoldService.SweepExpiredHolds()
+	go passService.RenewPasses()
+	go rentalService.RenewRentalHolds()
+	go rentalService.WatchOverdueRentals()
 
 	// Routes
 	handlers.AuthHandler(router, authService)
 	handlers.UserHandler(router, userService)
 	handlers.BikeHandler(router, bikeService)
+	handlers.BikeCatalogHandler(router, bikeCatalogService)
 	handlers.RentalHandler(router, rentalService)
+	handlers.ImageHandler(router, imageService)
+	handlers.ZoneHandler(router, zoneService)
+	handlers.StationHandler(router, stationService)
+	handlers.ReservationHandler(router, reservationService)
+	handlers.BikeHoldHandler(router, bikeHoldService)
+	handlers.PricingPlanHandler(router, pricingPlanService)
+	handlers.PromotionHandler(router, promotionService)
+	handlers.PassHandler(router, passService)
+	handlers.WalletHandler(router, walletService)
+	handlers.PaymentHandler(router, paymentService)
+	handlers.ReceiptHandler(router, receiptService)
+	handlers.CorporateAccountHandler(router, corporateAccountService)
+	handlers.TaxHandler(router, taxService)
+	handlers.DisputeHandler(router, disputeService)
+	handlers.PenaltyHandler(router, penaltyService)
 
 	// Others routes
 	router.GET("/health", func(c *gin.Context) {
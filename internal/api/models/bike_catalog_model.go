@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+// BikeModel is a catalog entry shared by every physical bike of the same make and model.
+type BikeModel struct {
+	ID                  uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Manufacturer        string         `json:"manufacturer" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Name                string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Description         string         `json:"description" gorm:"size:500;" validate:"max=500"`
+	MotorPowerWatts     int            `json:"motor_power_watts" gorm:"not null;default:0" validate:"min=0"`
+	BatteryCapacityWh   int            `json:"battery_capacity_wh" gorm:"not null;default:0" validate:"min=0"`
+	FrameSize           string         `json:"frame_size" gorm:"size:20;" validate:"max=20"`
+	DefaultPricePerHour money.Money    `json:"default_price_per_hour" gorm:"embedded;embeddedPrefix:default_price_per_hour_" validate:"required,money=positive"`
+	Photos              []string       `json:"photos" gorm:"type:jsonb;serializer:json" validate:"max=10,dive,url"`
+	CreatedAt           time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt           gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BikeHoldStatusEnum represents the status of a hold.
+type BikeHoldStatusEnum string
+
+const (
+	BIKE_HOLD_STATUS_ACTIVE    BikeHoldStatusEnum = "active"
+	BIKE_HOLD_STATUS_CONVERTED BikeHoldStatusEnum = "converted"
+	BIKE_HOLD_STATUS_RELEASED  BikeHoldStatusEnum = "released"
+	BIKE_HOLD_STATUS_EXPIRED   BikeHoldStatusEnum = "expired"
+)
+
+// BikeHold keeps a bike for a rider for a few minutes while they walk to it. While the hold is
+// active the bike is "held" and only the rider holding it can rent it.
+type BikeHold struct {
+	ID        uuid.UUID          `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	UserID    uuid.UUID          `json:"user_id" gorm:"type:uuid;not null;index;index:idx_bike_holds_active_user,unique,where:status = 'active'"`
+	BikeID    uuid.UUID          `json:"bike_id" gorm:"type:uuid;not null;index:idx_bike_holds_active_bike,unique,where:status = 'active'"`
+	Status    BikeHoldStatusEnum `json:"status" gorm:"not null;default:'active';index"`
+	ExpiresAt time.Time          `json:"expires_at" gorm:"not null;index"`
+	EndedAt   *time.Time         `json:"ended_at"`
+	CreatedAt time.Time          `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
 	"gorm.io/gorm"
 )
 
@@ -14,18 +15,43 @@ const (
 	BIKE_STATUS_AVAILABLE    BikeStatusEnum = "available"
 	BIKE_STATUS_NOTAVAILABLE BikeStatusEnum = "notavailable"
 	BIKE_STATUS_BOOKED       BikeStatusEnum = "booked"
+	BIKE_STATUS_HELD         BikeStatusEnum = "held"
 	BIKE_STATUS_MAINTENANCE  BikeStatusEnum = "maintenance"
 )
 
 type Bike struct {
 	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
-	Name         string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
-	Description  string         `json:"description" gorm:"not null;size:500;" validate:"required,min=1,max=500"`
-	PricePerHour float64        `json:"price_per_hour" gorm:"not null;" validate:"required"`
+	Code         string         `json:"code" gorm:"size:12;index:idx_bikes_code,unique,where:code <> ''"`
+	ModelID      *uuid.UUID     `json:"model_id" gorm:"type:uuid;index"`
+	Model        *BikeModel     `json:"model,omitempty" gorm:"foreignKey:ModelID" validate:"-"`
+	StationID    *uuid.UUID     `json:"station_id" gorm:"type:uuid;index"`
+	SerialNumber string         `json:"serial_number" gorm:"size:100;index:idx_bikes_serial_number,unique,where:serial_number <> ''" validate:"max=100"`
+	FrameNumber  string         `json:"frame_number" gorm:"size:100;" validate:"max=100"`
+	PurchaseDate *time.Time     `json:"purchase_date"`
+	Name         string         `json:"name" gorm:"not null;size:100;" validate:"required_without=ModelID,max=100"`
+	Description  string         `json:"description" gorm:"not null;size:500;" validate:"required_without=ModelID,max=500"`
+	PricePerHour money.Money    `json:"price_per_hour" gorm:"embedded;embeddedPrefix:price_per_hour_" validate:"required_without=ModelID,money"`
 	Location     string         `json:"location" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
-	Status       BikeStatusEnum `json:"status" gorm:"not null" validate:"required,oneof='available' 'notavailable' 'booked' 'maintenance'"`
-	Image        string         `json:"image" gorm:"not null;size:500;" validate:"required,min=1,max=500"`
+	Latitude     *float64       `json:"latitude" validate:"omitempty,latitude"`
+	Longitude    *float64       `json:"longitude" validate:"omitempty,longitude"`
+	Status       BikeStatusEnum `json:"status" gorm:"not null" validate:"required,oneof='available' 'notavailable' 'booked' 'held' 'maintenance'"`
+	Image        string         `json:"image" gorm:"size:500;" validate:"max=500"`
+	ImageKey     string         `json:"-" gorm:"size:500;"`
+	ThumbnailKey string         `json:"-" gorm:"size:500;"`
 	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt    time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
+
+// EffectivePricePerHour returns the hourly price of the bike.
+//
+// Bikes that belong to a catalog model are priced by the model, so repricing the model
+// reprices every unit at once. Bikes without a model keep their own price. The Model
+// association must be loaded for the model price to be used.
+func (b *Bike) EffectivePricePerHour() money.Money {
+	if b.Model != nil {
+		return b.Model.DefaultPricePerHour
+	}
+
+	return b.PricePerHour
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CorporateAccount is a company that pays for the rentals of its riders. The rentals of the
+// members of the account are billed to it, and the receipts of a month are grouped into a
+// monthly invoice sent to BillingEmail.
+type CorporateAccount struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Name         string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	TaxID        string         `json:"tax_id" gorm:"size:50;" validate:"max=50"`
+	BillingEmail string         `json:"billing_email" gorm:"not null;size:100;" validate:"required,email"`
+	Address      []string       `json:"address" gorm:"type:jsonb;serializer:json" validate:"max=5,dive,max=100"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+)
+
+// DisputeStatusEnum represents the status of a rental dispute.
+type DisputeStatusEnum string
+
+const (
+	DISPUTE_STATUS_OPEN     DisputeStatusEnum = "open"
+	DISPUTE_STATUS_RESOLVED DisputeStatusEnum = "resolved"
+	DISPUTE_STATUS_REJECTED DisputeStatusEnum = "rejected"
+)
+
+// Dispute is a completed rental contested by its rider. A rental has at most one open dispute.
+// Support staff resolve it with a refund or an adjustment of the end time of the rental, or
+// reject it, and explain the outcome in Resolution.
+type Dispute struct {
+	ID           uuid.UUID          `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	RentalID     uuid.UUID          `json:"rental_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_disputes_open_rental,where:status = 'open'"`
+	UserID       uuid.UUID          `json:"user_id" gorm:"type:uuid;not null;index"`
+	Reason       string             `json:"reason" gorm:"not null;size:1000;" validate:"required,min=1,max=1000"`
+	Status       DisputeStatusEnum  `json:"status" gorm:"not null;size:10;default:'open';index"`
+	Resolution   string             `json:"resolution" gorm:"size:1000;"`
+	ResolvedByID *uuid.UUID         `json:"resolved_by_id" gorm:"type:uuid"`
+	ResolvedAt   *time.Time         `json:"resolved_at"`
+	Photos       []DisputePhoto     `json:"photos,omitempty" gorm:"foreignKey:DisputeID"`
+	Adjustments  []RentalAdjustment `json:"adjustments,omitempty" gorm:"foreignKey:DisputeID"`
+	CreatedAt    time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// DisputePhoto is a picture sent by the rider with a dispute. Image is the stable URL of the
+// photo, which redirects to a freshly signed URL.
+type DisputePhoto struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	DisputeID    uuid.UUID `json:"dispute_id" gorm:"type:uuid;not null;index"`
+	Image        string    `json:"image" gorm:"not null;size:500;"`
+	ImageKey     string    `json:"-" gorm:"not null;size:500;"`
+	ThumbnailKey string    `json:"-" gorm:"not null;size:500;"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RentalAdjustmentTypeEnum represents what an adjustment changed on a rental.
+type RentalAdjustmentTypeEnum string
+
+const (
+	// RENTAL_ADJUSTMENT_REFUND refunds some or all of the rental charge.
+	RENTAL_ADJUSTMENT_REFUND RentalAdjustmentTypeEnum = "refund"
+	// RENTAL_ADJUSTMENT_END_TIME moves the end of the rental earlier and prices it again.
+	RENTAL_ADJUSTMENT_END_TIME RentalAdjustmentTypeEnum = "end_time"
+)
+
+// RentalAdjustment audits a change made to a completed rental after it was charged. It is linked
+// to the original charge of the rental and to the refund it posted, when there was something to
+// refund, and keeps the end time, total and price breakdown of the rental before and after.
+type RentalAdjustment struct {
+	ID                  uuid.UUID                `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	RentalID            uuid.UUID                `json:"rental_id" gorm:"type:uuid;not null;index"`
+	DisputeID           *uuid.UUID               `json:"dispute_id" gorm:"type:uuid;index"`
+	Type                RentalAdjustmentTypeEnum `json:"type" gorm:"not null;size:10;"`
+	Reason              string                   `json:"reason" gorm:"not null;size:1000;"`
+	ChargeTransactionID *uuid.UUID               `json:"charge_transaction_id" gorm:"type:uuid;index"`
+	RefundTransactionID *uuid.UUID               `json:"refund_transaction_id" gorm:"type:uuid"`
+	Refund              money.Money              `json:"refund" gorm:"embedded;embeddedPrefix:refund_"`
+	PreviousEndTime     time.Time                `json:"previous_end_time" gorm:"not null"`
+	EndTime             time.Time                `json:"end_time" gorm:"not null"`
+	PreviousTotal       money.Money              `json:"previous_total" gorm:"embedded;embeddedPrefix:previous_total_"`
+	Total               money.Money              `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	PreviousBreakdown   *pricing.Breakdown       `json:"previous_breakdown" gorm:"type:jsonb;serializer:json"`
+	Breakdown           *pricing.Breakdown       `json:"breakdown" gorm:"type:jsonb;serializer:json"`
+	CreatedByID         uuid.UUID                `json:"created_by_id" gorm:"type:uuid;not null"`
+	CreatedAt           time.Time                `json:"created_at" gorm:"autoCreateTime"`
+}
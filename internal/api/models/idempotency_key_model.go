@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyStatusEnum represents the processing status of an idempotent request.
+type IdempotencyKeyStatusEnum string
+
+const (
+	IDEMPOTENCY_KEY_STATUS_PROCESSING IdempotencyKeyStatusEnum = "processing"
+	IDEMPOTENCY_KEY_STATUS_COMPLETED  IdempotencyKeyStatusEnum = "completed"
+)
+
+// IdempotencyKey stores the response of a request sent with an "Idempotency-Key" header, so
+// retries of the same request get the same response instead of being executed again.
+type IdempotencyKey struct {
+	UserID       uuid.UUID                `json:"user_id" gorm:"type:uuid;primaryKey"`
+	Key          string                   `json:"key" gorm:"primaryKey;size:255"`
+	Fingerprint  string                   `json:"fingerprint" gorm:"not null;size:64"`
+	Status       IdempotencyKeyStatusEnum `json:"status" gorm:"not null"`
+	StatusCode   int                      `json:"status_code" gorm:"not null;default:0"`
+	ContentType  string                   `json:"content_type" gorm:"size:100"`
+	ResponseBody []byte                   `json:"-"`
+	ExpiresAt    time.Time                `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time                `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// LedgerAccountTypeEnum represents the kind of a ledger account.
+type LedgerAccountTypeEnum string
+
+const (
+	// LEDGER_ACCOUNT_WALLET holds the prepaid balance of a rider.
+	LEDGER_ACCOUNT_WALLET LedgerAccountTypeEnum = "wallet"
+	// LEDGER_ACCOUNT_FUNDING is where the money riders pay in comes from, by top-up or card.
+	LEDGER_ACCOUNT_FUNDING LedgerAccountTypeEnum = "funding"
+	// LEDGER_ACCOUNT_REVENUE receives the price of the rides.
+	LEDGER_ACCOUNT_REVENUE LedgerAccountTypeEnum = "revenue"
+	// LEDGER_ACCOUNT_ADJUSTMENTS is the counterpart of the manual adjustments of wallets.
+	LEDGER_ACCOUNT_ADJUSTMENTS LedgerAccountTypeEnum = "adjustments"
+)
+
+// LedgerTransactionTypeEnum represents the business event a ledger transaction records.
+type LedgerTransactionTypeEnum string
+
+const (
+	LEDGER_TRANSACTION_TOP_UP        LedgerTransactionTypeEnum = "top_up"
+	LEDGER_TRANSACTION_RENTAL_CHARGE LedgerTransactionTypeEnum = "rental_charge"
+	LEDGER_TRANSACTION_REFUND        LedgerTransactionTypeEnum = "refund"
+	LEDGER_TRANSACTION_ADJUSTMENT    LedgerTransactionTypeEnum = "adjustment"
+	LEDGER_TRANSACTION_REVERSAL      LedgerTransactionTypeEnum = "reversal"
+	LEDGER_TRANSACTION_CARD_PAYMENT  LedgerTransactionTypeEnum = "card_payment"
+	LEDGER_TRANSACTION_CARD_REFUND   LedgerTransactionTypeEnum = "card_refund"
+	LEDGER_TRANSACTION_PENALTY       LedgerTransactionTypeEnum = "penalty"
+)
+
+// LedgerEntryDirectionEnum tells whether an entry debits or credits its account.
+type LedgerEntryDirectionEnum string
+
+const (
+	LEDGER_ENTRY_DEBIT  LedgerEntryDirectionEnum = "debit"
+	LEDGER_ENTRY_CREDIT LedgerEntryDirectionEnum = "credit"
+)
+
+// LedgerAccount is an account of the double-entry ledger. Rider wallets belong to their rider;
+// the other accounts belong to the platform. An account holds a single currency, and Key
+// identifies it by type, owner and currency.
+type LedgerAccount struct {
+	ID        uuid.UUID             `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Key       string                `json:"-" gorm:"not null;size:100;uniqueIndex"`
+	Type      LedgerAccountTypeEnum `json:"type" gorm:"not null;size:20;"`
+	UserID    *uuid.UUID            `json:"user_id" gorm:"type:uuid;index"`
+	Currency  string                `json:"currency" gorm:"not null;size:3;"`
+	CreatedAt time.Time             `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// LedgerTransaction groups the entries of a business event. Its debits and credits are equal in
+// every currency. Transactions are never changed: a mistake is fixed by a reversal, which
+// mirrors the entries of the transaction it reverses.
+type LedgerTransaction struct {
+	ID             uuid.UUID                 `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Type           LedgerTransactionTypeEnum `json:"type" gorm:"not null;size:20;index"`
+	Description    string                    `json:"description" gorm:"not null;size:255;"`
+	Reason         string                    `json:"reason" gorm:"size:500;"`
+	RentalID       *uuid.UUID                `json:"rental_id" gorm:"type:uuid;index"`
+	PaymentID      *uuid.UUID                `json:"payment_id" gorm:"type:uuid;index"`
+	ReversalOfID   *uuid.UUID                `json:"reversal_of_id" gorm:"type:uuid;uniqueIndex"`
+	IdempotencyKey *string                   `json:"-" gorm:"size:100;uniqueIndex"`
+	CreatedByID    *uuid.UUID                `json:"created_by_id" gorm:"type:uuid"`
+	Entries        []LedgerEntry             `json:"entries,omitempty" gorm:"foreignKey:TransactionID"`
+	CreatedAt      time.Time                 `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// LedgerEntry debits or credits an account with a positive amount.
+type LedgerEntry struct {
+	ID            uuid.UUID                `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	TransactionID uuid.UUID                `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	AccountID     uuid.UUID                `json:"account_id" gorm:"type:uuid;not null;index"`
+	Direction     LedgerEntryDirectionEnum `json:"direction" gorm:"not null;size:6;"`
+	Amount        money.Money              `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Transaction   *LedgerTransaction       `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+	CreatedAt     time.Time                `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// WalletSnapshot is the state of the wallet of a rider at a point in time. Balances are derived
+// from the ledger entries, one per currency, and Entries are the latest entries of the wallet,
+// read in the same snapshot.
+type WalletSnapshot struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	AsOf     time.Time     `json:"as_of"`
+	Balances []money.Money `json:"balances"`
+	Entries  []LedgerEntry `json:"entries"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LockCommandStatusEnum represents the delivery status of a lock command.
+type LockCommandStatusEnum string
+
+const (
+	LOCK_COMMAND_STATUS_PENDING      LockCommandStatusEnum = "pending"
+	LOCK_COMMAND_STATUS_ACKNOWLEDGED LockCommandStatusEnum = "acknowledged"
+	LOCK_COMMAND_STATUS_REJECTED     LockCommandStatusEnum = "rejected"
+	LOCK_COMMAND_STATUS_TIMEOUT      LockCommandStatusEnum = "timeout"
+	LOCK_COMMAND_STATUS_FAILED       LockCommandStatusEnum = "failed"
+)
+
+// LockCommand records every command sent to the lock of a bike and its acknowledgement.
+type LockCommand struct {
+	ID             uuid.UUID             `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	BikeID         uuid.UUID             `json:"bike_id" gorm:"type:uuid;not null;index"`
+	RentalID       *uuid.UUID            `json:"rental_id" gorm:"type:uuid;index"`
+	Action         string                `json:"action" gorm:"not null;size:20"`
+	Status         LockCommandStatusEnum `json:"status" gorm:"not null;size:20"`
+	Error          string                `json:"error" gorm:"size:500"`
+	SentAt         time.Time             `json:"sent_at" gorm:"not null"`
+	AcknowledgedAt *time.Time            `json:"acknowledged_at"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+}
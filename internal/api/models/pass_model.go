@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+// PassTypeEnum represents the kind of pass a product sells.
+type PassTypeEnum string
+
+const (
+	PASS_TYPE_DAY_PASS    PassTypeEnum = "day_pass"
+	PASS_TYPE_MONTHLY     PassTypeEnum = "monthly"
+	PASS_TYPE_RIDE_BUNDLE PassTypeEnum = "ride_bundle"
+)
+
+// PassStatusEnum represents the status of a pass.
+type PassStatusEnum string
+
+const (
+	PASS_STATUS_ACTIVE  PassStatusEnum = "active"
+	PASS_STATUS_EXPIRED PassStatusEnum = "expired"
+)
+
+// PassProduct is a pass riders can buy. A pass covers IncludedRides rides during ValidityDays
+// days, or every ride when IncludedRides is zero. The first MinutesPerRide minutes of a covered
+// ride are free, up to IncludedMinutes minutes over the whole pass when it is set, and its
+// unlock fee is waived with WaiveUnlockFee. Pass holders get MemberDiscountPercent off the rest
+// of the time charge of their rides.
+type PassProduct struct {
+	ID                    uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Name                  string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Description           string         `json:"description" gorm:"size:500;" validate:"max=500"`
+	Type                  PassTypeEnum   `json:"type" gorm:"not null;size:20;" validate:"required,oneof='day_pass' 'monthly' 'ride_bundle'"`
+	Price                 money.Money    `json:"price" gorm:"embedded;embeddedPrefix:price_" validate:"money=positive"`
+	ValidityDays          int            `json:"validity_days" gorm:"not null" validate:"required,min=1,max=366"`
+	IncludedRides         int            `json:"included_rides" gorm:"not null;default:0" validate:"required_if=Type ride_bundle,min=0"`
+	MinutesPerRide        int            `json:"minutes_per_ride" gorm:"not null;default:0" validate:"min=0"`
+	IncludedMinutes       int            `json:"included_minutes" gorm:"not null;default:0" validate:"min=0"`
+	WaiveUnlockFee        bool           `json:"waive_unlock_fee" gorm:"not null"`
+	MemberDiscountPercent int            `json:"member_discount_percent" gorm:"not null;default:0" validate:"min=0,max=100"`
+	Active                bool           `json:"active" gorm:"not null"`
+	CreatedAt             time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt             time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt             gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Pass is a pass bought by a rider, valid from StartsAt until ExpiresAt. A pass that auto-renews
+// is bought again when it expires, or when a ride bundle runs out of rides. Cancelling a pass
+// turns auto-renewal off; it stays usable until it expires.
+type Pass struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	UserID      uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	ProductID   uuid.UUID      `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product     *PassProduct   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Status      PassStatusEnum `json:"status" gorm:"not null;size:20;default:'active';index"`
+	Price       money.Money    `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	StartsAt    time.Time      `json:"starts_at" gorm:"not null"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null;index"`
+	AutoRenew   bool           `json:"auto_renew" gorm:"not null;default:false"`
+	RidesUsed   int            `json:"rides_used" gorm:"not null;default:0"`
+	MinutesUsed int            `json:"minutes_used" gorm:"not null;default:0"`
+	RenewedByID *uuid.UUID     `json:"renewed_by_id" gorm:"type:uuid"`
+	CancelledAt *time.Time     `json:"cancelled_at"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// PassUsage records what a pass covered on a rental.
+type PassUsage struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	PassID    uuid.UUID `json:"pass_id" gorm:"type:uuid;not null;index"`
+	RentalID  uuid.UUID `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Rides     int       `json:"rides" gorm:"not null;default:0"`
+	Minutes   int       `json:"minutes" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// HasRidesLeft reports whether the pass can still cover rides.
+func (p *Pass) HasRidesLeft() bool {
+	return p.Product != nil && (p.Product.IncludedRides == 0 || p.RidesUsed < p.Product.IncludedRides)
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+// PaymentStatusEnum represents the status of a payment.
+type PaymentStatusEnum string
+
+const (
+	PAYMENT_STATUS_PENDING    PaymentStatusEnum = "pending"
+	PAYMENT_STATUS_AUTHORIZED PaymentStatusEnum = "authorized"
+	PAYMENT_STATUS_CAPTURED   PaymentStatusEnum = "captured"
+	PAYMENT_STATUS_VOIDED     PaymentStatusEnum = "voided"
+	PAYMENT_STATUS_REFUNDED   PaymentStatusEnum = "refunded"
+	PAYMENT_STATUS_FAILED     PaymentStatusEnum = "failed"
+)
+
+// PaymentMethod is a card on file of a rider. Token is the reference of the card at the payment
+// provider; the card number is never stored. The default payment method pays for the rentals.
+type PaymentMethod struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	Token     string         `json:"-" gorm:"not null;size:255;"`
+	Brand     string         `json:"brand" gorm:"not null;size:20;"`
+	Last4     string         `json:"last4" gorm:"not null;size:4;"`
+	ExpMonth  int            `json:"exp_month" gorm:"not null"`
+	ExpYear   int            `json:"exp_year" gorm:"not null"`
+	IsDefault bool           `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Payment is money collected from a payment method through the payment provider. Amount is the
+// amount authorized; CapturedAmount and RefundedAmount follow what the provider reports.
+// GatewayReference is the ID of the payment at the provider. A pre-authorization hold placed at
+// the start of a rental expires at ExpiresAt and must be renewed by then.
+type Payment struct {
+	ID               uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	UserID           uuid.UUID         `json:"user_id" gorm:"type:uuid;not null;index"`
+	RentalID         *uuid.UUID        `json:"rental_id" gorm:"type:uuid;index"`
+	PaymentMethodID  uuid.UUID         `json:"payment_method_id" gorm:"type:uuid;not null"`
+	GatewayReference *string           `json:"gateway_reference" gorm:"size:255;uniqueIndex"`
+	Status           PaymentStatusEnum `json:"status" gorm:"not null;size:20;index"`
+	Amount           money.Money       `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CapturedAmount   money.Money       `json:"captured_amount" gorm:"embedded;embeddedPrefix:captured_amount_"`
+	RefundedAmount   money.Money       `json:"refunded_amount" gorm:"embedded;embeddedPrefix:refunded_amount_"`
+	FailureCode      string            `json:"failure_code,omitempty" gorm:"size:100;"`
+	FailureMessage   string            `json:"failure_message,omitempty" gorm:"size:255;"`
+	ExpiresAt        *time.Time        `json:"expires_at"`
+	CreatedAt        time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// PaymentEvent is a webhook event received from the payment provider. Events are stored once,
+// so a webhook delivered twice is applied once.
+type PaymentEvent struct {
+	ID         string            `json:"id" gorm:"primaryKey;size:255;not null"`
+	PaymentID  *uuid.UUID        `json:"payment_id" gorm:"type:uuid;index"`
+	Status     PaymentStatusEnum `json:"status" gorm:"not null;size:20;"`
+	Payload    string            `json:"payload" gorm:"type:jsonb;not null"`
+	ReceivedAt time.Time         `json:"received_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// PenaltyTypeEnum represents why a rider is charged a penalty.
+type PenaltyTypeEnum string
+
+const (
+	// PENALTY_TYPE_OVERTIME is charged for a rental returned after its maximum duration.
+	PENALTY_TYPE_OVERTIME PenaltyTypeEnum = "overtime"
+	// PENALTY_TYPE_ABANDONMENT is charged for a suspected abandoned rental ended by the operations team.
+	PENALTY_TYPE_ABANDONMENT PenaltyTypeEnum = "abandonment"
+)
+
+// PenaltyStatusEnum represents the status of a penalty.
+type PenaltyStatusEnum string
+
+const (
+	PENALTY_STATUS_UNPAID PenaltyStatusEnum = "unpaid"
+	PENALTY_STATUS_PAID   PenaltyStatusEnum = "paid"
+	PENALTY_STATUS_WAIVED PenaltyStatusEnum = "waived"
+)
+
+// Penalty is a fee a rider owes for a rental, on top of its price. A penalty is paid from the
+// wallet of the rider, through TransactionID, or waived by an admin. Riders with unpaid penalties
+// cannot start new rentals. A rental has at most one penalty of each type.
+type Penalty struct {
+	ID            uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	UserID        uuid.UUID         `json:"user_id" gorm:"type:uuid;not null;index"`
+	RentalID      uuid.UUID         `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex:idx_penalties_rental_type"`
+	Type          PenaltyTypeEnum   `json:"type" gorm:"not null;size:20;uniqueIndex:idx_penalties_rental_type"`
+	Description   string            `json:"description" gorm:"not null;size:255;"`
+	Amount        money.Money       `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Status        PenaltyStatusEnum `json:"status" gorm:"not null;size:10;default:'unpaid';index"`
+	TransactionID *uuid.UUID        `json:"transaction_id" gorm:"type:uuid"`
+	WaivedByID    *uuid.UUID        `json:"waived_by_id" gorm:"type:uuid"`
+	WaiveReason   string            `json:"waive_reason" gorm:"size:500;"`
+	SettledAt     *time.Time        `json:"settled_at"`
+	CreatedAt     time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+// PricingPlanScopeEnum represents what a pricing plan is assigned to.
+type PricingPlanScopeEnum string
+
+const (
+	PRICING_PLAN_SCOPE_BIKE_MODEL PricingPlanScopeEnum = "bike_model"
+	PRICING_PLAN_SCOPE_STATION    PricingPlanScopeEnum = "station"
+	PRICING_PLAN_SCOPE_CITY       PricingPlanScopeEnum = "city"
+	PRICING_PLAN_SCOPE_DEFAULT    PricingPlanScopeEnum = "default"
+)
+
+// PricingPlan describes how rides are charged. Every amount of a plan is in the same currency.
+// Rides longer than MaxDurationMinutes are charged the OvertimeFee for every started hour past
+// it; a zero maximum or fee falls back to the defaults of the service. Paused rides are charged
+// the PausedPerMinuteRate, or the PerMinuteRate when it is zero, for at most MaxPauseMinutes in
+// total; a zero maximum falls back to the default of the service.
+type PricingPlan struct {
+	ID                      uuid.UUID               `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Name                    string                  `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Description             string                  `json:"description" gorm:"size:500;" validate:"max=500"`
+	UnlockFee               money.Money             `json:"unlock_fee" gorm:"embedded;embeddedPrefix:unlock_fee_" validate:"money"`
+	PerMinuteRate           money.Money             `json:"per_minute_rate" gorm:"embedded;embeddedPrefix:per_minute_rate_" validate:"money"`
+	BillingIncrementSeconds int                     `json:"billing_increment_seconds" gorm:"not null" validate:"min=0"`
+	Rounding                string                  `json:"rounding" gorm:"not null;size:10;default:'up'" validate:"required,oneof='up' 'down' 'nearest'"`
+	FreeMinutes             int                     `json:"free_minutes" gorm:"not null;default:0" validate:"min=0"`
+	DailyCap                money.Money             `json:"daily_cap" gorm:"embedded;embeddedPrefix:daily_cap_" validate:"money"`
+	MinimumCharge           money.Money             `json:"minimum_charge" gorm:"embedded;embeddedPrefix:minimum_charge_" validate:"money"`
+	MaxDurationMinutes      int                     `json:"max_duration_minutes" gorm:"not null;default:0" validate:"min=0"`
+	OvertimeFee             money.Money             `json:"overtime_fee" gorm:"embedded;embeddedPrefix:overtime_fee_" validate:"money"`
+	PausedPerMinuteRate     money.Money             `json:"paused_per_minute_rate" gorm:"embedded;embeddedPrefix:paused_per_minute_rate_" validate:"money"`
+	MaxPauseMinutes         int                     `json:"max_pause_minutes" gorm:"not null;default:0" validate:"min=0"`
+	Assignments             []PricingPlanAssignment `json:"assignments,omitempty" gorm:"foreignKey:PricingPlanID" validate:"-"`
+	CreatedAt               time.Time               `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt               time.Time               `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt               gorm.DeletedAt          `json:"deleted_at" gorm:"index"`
+}
+
+// PricingPlanAssignment applies a pricing plan to a bike model, a station, a city, or to every
+// bike by default. A scope has at most one plan. ScopeKey holds the model or station ID, or the
+// lowercase city name.
+type PricingPlanAssignment struct {
+	ID            uuid.UUID            `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	PricingPlanID uuid.UUID            `json:"pricing_plan_id" gorm:"type:uuid;not null;index"`
+	Scope         PricingPlanScopeEnum `json:"scope" gorm:"not null;size:20;uniqueIndex:idx_pricing_plan_assignments_scope" validate:"required,oneof='bike_model' 'station' 'city' 'default'"`
+	ScopeKey      string               `json:"scope_key" gorm:"not null;size:100;uniqueIndex:idx_pricing_plan_assignments_scope"`
+	CreatedAt     time.Time            `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Currency returns the currency of the plan amounts, or an empty string when every amount is
+// zero.
+func (p *PricingPlan) Currency() string {
+	for _, amount := range []money.Money{p.PerMinuteRate, p.UnlockFee, p.DailyCap, p.MinimumCharge, p.OvertimeFee, p.PausedPerMinuteRate} {
+		if amount.Currency != "" {
+			return amount.Currency
+		}
+	}
+
+	return ""
+}
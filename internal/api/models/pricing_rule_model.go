@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PricingRuleTypeEnum represents the condition that triggers a pricing rule.
+type PricingRuleTypeEnum string
+
+const (
+	PRICING_RULE_TYPE_SCHEDULE        PricingRuleTypeEnum = "schedule"
+	PRICING_RULE_TYPE_HOLIDAY         PricingRuleTypeEnum = "holiday"
+	PRICING_RULE_TYPE_SURGE           PricingRuleTypeEnum = "surge"
+	PRICING_RULE_TYPE_DEPLETED_RETURN PricingRuleTypeEnum = "depleted_return"
+)
+
+// PricingRule multiplies the ride time charge of the rentals that meet its condition. Weekdays
+// go from 0 (Sunday) to 6 (Saturday) and the hours cover [StartHour, EndHour) in the operator
+// time zone.
+type PricingRule struct {
+	ID                uuid.UUID           `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Name              string              `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Type              PricingRuleTypeEnum `json:"type" gorm:"not null;size:20;" validate:"required,oneof='schedule' 'holiday' 'surge' 'depleted_return'"`
+	Multiplier        float64             `json:"multiplier" gorm:"not null" validate:"gt=0,max=10"`
+	Weekdays          []int               `json:"weekdays" gorm:"type:jsonb;serializer:json" validate:"max=7,dive,min=0,max=6"`
+	StartHour         int                 `json:"start_hour" gorm:"not null;default:0" validate:"min=0,max=23"`
+	EndHour           int                 `json:"end_hour" gorm:"not null;default:0" validate:"min=0,max=24"`
+	MaxAvailableBikes int                 `json:"max_available_bikes" gorm:"not null;default:0" validate:"min=0"`
+	Active            bool                `json:"active" gorm:"not null"`
+	CreatedAt         time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt         gorm.DeletedAt      `json:"deleted_at" gorm:"index"`
+}
+
+// Holiday is a day of the holiday calendar used by the holiday pricing rules. A holiday without
+// a country applies everywhere.
+type Holiday struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Date      string    `json:"date" gorm:"not null;size:10;uniqueIndex:idx_holidays_date_country" validate:"required,datetime=2006-01-02"`
+	Country   string    `json:"country" gorm:"not null;size:2;default:'';uniqueIndex:idx_holidays_date_country" validate:"omitempty,len=2"`
+	Name      string    `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+// PromotionTypeEnum represents the kind of discount a promotion gives.
+type PromotionTypeEnum string
+
+const (
+	PROMOTION_TYPE_PERCENTAGE   PromotionTypeEnum = "percentage"
+	PROMOTION_TYPE_FIXED_AMOUNT PromotionTypeEnum = "fixed_amount"
+	PROMOTION_TYPE_FREE_MINUTES PromotionTypeEnum = "free_minutes"
+)
+
+// PromotionRedemptionStatusEnum represents the status of a promotion redemption.
+type PromotionRedemptionStatusEnum string
+
+const (
+	PROMOTION_REDEMPTION_STATUS_PENDING  PromotionRedemptionStatusEnum = "pending"
+	PROMOTION_REDEMPTION_STATUS_APPLIED  PromotionRedemptionStatusEnum = "applied"
+	PROMOTION_REDEMPTION_STATUS_RELEASED PromotionRedemptionStatusEnum = "released"
+)
+
+// Promotion is a promo code riders apply when starting a rental. A limit of zero means no limit,
+// and a promotion without eligible bike models applies to every bike.
+type Promotion struct {
+	ID                    uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Code                  string            `json:"code" gorm:"not null;size:32;uniqueIndex" validate:"required,min=3,max=32,alphanum"`
+	Description           string            `json:"description" gorm:"size:500;" validate:"max=500"`
+	Type                  PromotionTypeEnum `json:"type" gorm:"not null;size:20;" validate:"required,oneof='percentage' 'fixed_amount' 'free_minutes'"`
+	PercentOff            int               `json:"percent_off" gorm:"not null;default:0" validate:"required_if=Type percentage,min=0,max=100"`
+	AmountOff             money.Money       `json:"amount_off" gorm:"embedded;embeddedPrefix:amount_off_" validate:"money"`
+	FreeMinutes           int               `json:"free_minutes" gorm:"not null;default:0" validate:"required_if=Type free_minutes,min=0"`
+	StartsAt              *time.Time        `json:"starts_at"`
+	EndsAt                *time.Time        `json:"ends_at"`
+	MaxRedemptions        int               `json:"max_redemptions" gorm:"not null;default:0" validate:"min=0"`
+	MaxRedemptionsPerUser int               `json:"max_redemptions_per_user" gorm:"not null" validate:"min=0"`
+	FirstRideOnly         bool              `json:"first_ride_only" gorm:"not null;default:false"`
+	EligibleBikeModelIDs  []uuid.UUID       `json:"eligible_bike_model_ids" gorm:"type:jsonb;serializer:json" validate:"max=100"`
+	Active                bool              `json:"active" gorm:"not null"`
+	CreatedAt             time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt             time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt             gorm.DeletedAt    `json:"deleted_at" gorm:"index"`
+}
+
+// PromotionRedemption records the use of a promotion on a rental. It is pending while the rental
+// is active, applied with the discount given when the bike is returned, and released when the
+// rental is cancelled, which gives the use back to the rider.
+type PromotionRedemption struct {
+	ID          uuid.UUID                     `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	PromotionID uuid.UUID                     `json:"promotion_id" gorm:"type:uuid;not null;index"`
+	UserID      uuid.UUID                     `json:"user_id" gorm:"type:uuid;not null;index"`
+	RentalID    uuid.UUID                     `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Status      PromotionRedemptionStatusEnum `json:"status" gorm:"not null;size:20;default:'pending'"`
+	Discount    money.Money                   `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	CreatedAt   time.Time                     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time                     `json:"updated_at" gorm:"autoUpdateTime"`
+}
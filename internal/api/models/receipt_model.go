@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/document"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// Receipt is the numbered proof of payment of a completed rental. Document is the receipt as it
+// was issued, which is what is rendered and sent to the rider. A receipt of a rental billed to a
+// corporate account is grouped into the monthly invoice of the account.
+type Receipt struct {
+	ID                 uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Number             string            `json:"number" gorm:"not null;size:20;uniqueIndex"`
+	RentalID           uuid.UUID         `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex"`
+	UserID             uuid.UUID         `json:"user_id" gorm:"type:uuid;not null;index"`
+	CorporateAccountID *uuid.UUID        `json:"corporate_account_id" gorm:"type:uuid;index"`
+	InvoiceID          *uuid.UUID        `json:"invoice_id" gorm:"type:uuid;index"`
+	Subtotal           money.Money       `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	TaxTotal           money.Money       `json:"tax_total" gorm:"embedded;embeddedPrefix:tax_total_"`
+	Total              money.Money       `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	Document           document.Document `json:"document" gorm:"type:jsonb;serializer:json;not null"`
+	IssuedAt           time.Time         `json:"issued_at" gorm:"not null;index"`
+	EmailedAt          *time.Time        `json:"emailed_at"`
+	CreatedAt          time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Invoice bills a corporate account for the receipts of its riders issued in a month, in one
+// currency. PeriodStart and PeriodEnd bound the month, the end excluded.
+type Invoice struct {
+	ID                 uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Number             string            `json:"number" gorm:"not null;size:20;uniqueIndex"`
+	CorporateAccountID uuid.UUID         `json:"corporate_account_id" gorm:"type:uuid;not null;uniqueIndex:idx_invoice_period"`
+	PeriodStart        time.Time         `json:"period_start" gorm:"not null;uniqueIndex:idx_invoice_period"`
+	PeriodEnd          time.Time         `json:"period_end" gorm:"not null"`
+	Currency           string            `json:"currency" gorm:"not null;size:3;uniqueIndex:idx_invoice_period"`
+	ReceiptCount       int               `json:"receipt_count" gorm:"not null"`
+	Subtotal           money.Money       `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	TaxTotal           money.Money       `json:"tax_total" gorm:"embedded;embeddedPrefix:tax_total_"`
+	Total              money.Money       `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	Document           document.Document `json:"document" gorm:"type:jsonb;serializer:json;not null"`
+	IssuedAt           time.Time         `json:"issued_at" gorm:"not null"`
+	EmailedAt          *time.Time        `json:"emailed_at"`
+	CreatedAt          time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// DocumentCounter numbers a kind of billing document. Numbers are taken inside the transaction
+// that stores the document, so they have no gaps.
+type DocumentCounter struct {
+	Name  string `gorm:"primaryKey;size:20;not null"`
+	Value int64  `gorm:"not null;default:0"`
+}
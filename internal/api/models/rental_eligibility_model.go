@@ -0,0 +1,22 @@
+package models
+
+// RentalRejectionCodeEnum is a machine-readable reason a rider cannot start a rental, so apps
+// can guide the rider to fix it.
+type RentalRejectionCodeEnum string
+
+const (
+	RENTAL_REJECTION_ACCOUNT_NOT_VERIFIED    RentalRejectionCodeEnum = "account_not_verified"
+	RENTAL_REJECTION_ACCOUNT_INACTIVE        RentalRejectionCodeEnum = "account_inactive"
+	RENTAL_REJECTION_UNPAID_PENALTIES        RentalRejectionCodeEnum = "unpaid_penalties"
+	RENTAL_REJECTION_UNPAID_BALANCE          RentalRejectionCodeEnum = "unpaid_balance"
+	RENTAL_REJECTION_TOO_MANY_ACTIVE_RENTALS RentalRejectionCodeEnum = "too_many_active_rentals"
+	RENTAL_REJECTION_BIRTH_DATE_REQUIRED     RentalRejectionCodeEnum = "birth_date_required"
+	RENTAL_REJECTION_UNDERAGE                RentalRejectionCodeEnum = "underage"
+	RENTAL_REJECTION_TERMS_NOT_ACCEPTED      RentalRejectionCodeEnum = "terms_not_accepted"
+)
+
+// RentalRejection is a rule of the rental eligibility a rider does not meet.
+type RentalRejection struct {
+	Code    RentalRejectionCodeEnum `json:"code"`
+	Message string                  `json:"message"`
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
 	"gorm.io/gorm"
 )
 
@@ -11,19 +13,108 @@ import (
 type RentalStatusEnum string
 
 const (
-	RENTAL_STATUS_ACTIVE    RentalStatusEnum = "active"
+	RENTAL_STATUS_ACTIVE RentalStatusEnum = "active"
+	// RENTAL_STATUS_PAUSED is a rental whose bike the rider locked in place without ending it.
+	RENTAL_STATUS_PAUSED    RentalStatusEnum = "paused"
 	RENTAL_STATUS_COMPLETED RentalStatusEnum = "completed"
+	RENTAL_STATUS_CANCELLED RentalStatusEnum = "cancelled"
+)
+
+// RentalInProgressStatuses are the statuses of the rentals that have not ended yet.
+var RentalInProgressStatuses = []RentalStatusEnum{RENTAL_STATUS_ACTIVE, RENTAL_STATUS_PAUSED}
+
+// InProgress reports whether a rental with the status has not ended yet.
+func (s RentalStatusEnum) InProgress() bool {
+	return s == RENTAL_STATUS_ACTIVE || s == RENTAL_STATUS_PAUSED
+}
+
+// RentalSegmentTypeEnum tells whether the bike was ridden or paused during a segment of a rental.
+type RentalSegmentTypeEnum string
+
+const (
+	RENTAL_SEGMENT_RIDING RentalSegmentTypeEnum = "riding"
+	RENTAL_SEGMENT_PAUSED RentalSegmentTypeEnum = "paused"
+)
+
+// RentalOvertimeStageEnum represents how far an active rental ran past its maximum duration.
+type RentalOvertimeStageEnum string
+
+const (
+	// RENTAL_OVERTIME_NONE is a rental well within its maximum duration.
+	RENTAL_OVERTIME_NONE RentalOvertimeStageEnum = ""
+	// RENTAL_OVERTIME_WARNED is a rental about to reach its maximum duration; the rider was warned.
+	RENTAL_OVERTIME_WARNED RentalOvertimeStageEnum = "warned"
+	// RENTAL_OVERTIME_OVERDUE is a rental past its maximum duration, charged an overtime fee.
+	RENTAL_OVERTIME_OVERDUE RentalOvertimeStageEnum = "overdue"
+	// RENTAL_OVERTIME_SUSPECTED_ABANDONED is a rental overdue for so long that the bike is
+	// probably abandoned, for the operations team to review.
+	RENTAL_OVERTIME_SUSPECTED_ABANDONED RentalOvertimeStageEnum = "suspected_abandoned"
 )
 
 type Rental struct {
-	ID        uuid.UUID        `json:"id" gorm:"type:uuid;primaryKey;not null;index" validate:"required,uuid4"`
-	UserID    uuid.UUID        `json:"user_id" gorm:"type:uuid;not null"`
-	BikeID    uuid.UUID        `json:"bike_id" gorm:"type:uuid;not null"`
-	StartTime time.Time        `json:"start_time" gorm:"not null"`
-	EndTime   time.Time        `json:"end_time" gorm:"not null"`
-	Status    RentalStatusEnum `json:"status" gorm:"not null;default:'active'" validate:"required,oneof='active' 'completed'"`
-	TotalCost float64          `json:"total_cost" gorm:"not null"`
-	CreatedAt time.Time        `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt gorm.DeletedAt   `json:"deleted_at" gorm:"index"`
+	ID                         uuid.UUID               `json:"id" gorm:"type:uuid;primaryKey;not null;index" validate:"required,uuid4"`
+	UserID                     uuid.UUID               `json:"user_id" gorm:"type:uuid;not null"`
+	BikeID                     uuid.UUID               `json:"bike_id" gorm:"type:uuid;not null"`
+	PricingPlanID              *uuid.UUID              `json:"pricing_plan_id" gorm:"type:uuid"`
+	PromotionID                *uuid.UUID              `json:"promotion_id" gorm:"type:uuid"`
+	PassID                     *uuid.UUID              `json:"pass_id" gorm:"type:uuid"`
+	HoldPaymentID              *uuid.UUID              `json:"hold_payment_id" gorm:"type:uuid"`
+	StartTime                  time.Time               `json:"start_time" gorm:"not null"`
+	EndTime                    time.Time               `json:"end_time" gorm:"not null"`
+	Status                     RentalStatusEnum        `json:"status" gorm:"not null;default:'active'" validate:"required,oneof='active' 'paused' 'completed' 'cancelled'"`
+	TotalCost                  money.Money             `json:"total_cost" gorm:"embedded;embeddedPrefix:total_cost_"`
+	ZoneFee                    money.Money             `json:"zone_fee" gorm:"embedded;embeddedPrefix:zone_fee_"`
+	TaxTotal                   money.Money             `json:"tax_total" gorm:"embedded;embeddedPrefix:tax_total_"`
+	TaxLines                   []RentalTaxLine         `json:"tax_lines,omitempty" gorm:"foreignKey:RentalID"`
+	ReturnLatitude             *float64                `json:"return_latitude"`
+	ReturnLongitude            *float64                `json:"return_longitude"`
+	DistanceMeters             float64                 `json:"distance_meters" gorm:"not null;default:0"`
+	AverageSpeedKmh            float64                 `json:"average_speed_kmh" gorm:"not null;default:0"`
+	MaxSpeedKmh                float64                 `json:"max_speed_kmh" gorm:"not null;default:0"`
+	StartStationID             *uuid.UUID              `json:"start_station_id" gorm:"type:uuid"`
+	StartStationAvailableBikes *int                    `json:"start_station_available_bikes"`
+	ReturnStationID            *uuid.UUID              `json:"return_station_id" gorm:"type:uuid"`
+	PriceBreakdown             *pricing.Breakdown      `json:"price_breakdown" gorm:"type:jsonb;serializer:json"`
+	MaxDurationMinutes         int                     `json:"max_duration_minutes" gorm:"not null;default:0"`
+	OvertimeStage              RentalOvertimeStageEnum `json:"overtime_stage" gorm:"not null;size:20;default:'';index"`
+	OvertimeStageAt            *time.Time              `json:"overtime_stage_at"`
+	MaxPauseMinutes            int                     `json:"max_pause_minutes" gorm:"not null;default:0"`
+	Segments                   []RentalSegment         `json:"segments,omitempty" gorm:"foreignKey:RentalID"`
+	Penalties                  []Penalty               `json:"penalties,omitempty" gorm:"foreignKey:RentalID"`
+	CreatedAt                  time.Time               `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt                  time.Time               `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt                  gorm.DeletedAt          `json:"deleted_at" gorm:"index"`
+}
+
+// RentalSegment is a stretch of a rental during which the bike was either ridden or paused. A
+// rental starts with a riding segment, and every pause and resume closes the open segment and
+// opens the next one. EndTime is nil for the open segment of a rental in progress.
+type RentalSegment struct {
+	ID        uuid.UUID             `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	RentalID  uuid.UUID             `json:"rental_id" gorm:"type:uuid;not null;index"`
+	Type      RentalSegmentTypeEnum `json:"type" gorm:"not null;size:10"`
+	StartTime time.Time             `json:"start_time" gorm:"not null"`
+	EndTime   *time.Time            `json:"end_time"`
+	CreatedAt time.Time             `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// PausedDuration returns how long a rental was paused up to a given time, from its segments. An
+// open segment lasts until that time, and the segments after it are left out.
+func PausedDuration(segments []RentalSegment, until time.Time) time.Duration {
+	var paused time.Duration
+
+	for _, segment := range segments {
+		if segment.Type != RENTAL_SEGMENT_PAUSED || !segment.StartTime.Before(until) {
+			continue
+		}
+
+		end := until
+		if segment.EndTime != nil && segment.EndTime.Before(until) {
+			end = *segment.EndTime
+		}
+
+		paused += end.Sub(segment.StartTime)
+	}
+
+	return paused
 }
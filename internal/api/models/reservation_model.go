@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReservationStatusEnum represents the status of a reservation.
+type ReservationStatusEnum string
+
+const (
+	RESERVATION_STATUS_PENDING   ReservationStatusEnum = "pending"
+	RESERVATION_STATUS_FULFILLED ReservationStatusEnum = "fulfilled"
+	RESERVATION_STATUS_CANCELLED ReservationStatusEnum = "cancelled"
+	RESERVATION_STATUS_NO_SHOW   ReservationStatusEnum = "no_show"
+)
+
+// Reservation books a bike for a future time window. Reservations made at a station are
+// assigned one of its bikes when they are created.
+type Reservation struct {
+	ID          uuid.UUID             `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	UserID      uuid.UUID             `json:"user_id" gorm:"type:uuid;not null;index"`
+	BikeID      uuid.UUID             `json:"bike_id" gorm:"type:uuid;not null;index:idx_reservations_bike_window"`
+	StationID   *uuid.UUID            `json:"station_id" gorm:"type:uuid"`
+	StartTime   time.Time             `json:"start_time" gorm:"not null;index:idx_reservations_bike_window"`
+	EndTime     time.Time             `json:"end_time" gorm:"not null"`
+	Status      ReservationStatusEnum `json:"status" gorm:"not null;default:'pending';index" validate:"required,oneof='pending' 'fulfilled' 'cancelled' 'no_show'"`
+	RentalID    *uuid.UUID            `json:"rental_id" gorm:"type:uuid"`
+	CancelledAt *time.Time            `json:"cancelled_at"`
+	CreatedAt   time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt        `json:"deleted_at" gorm:"index"`
+}
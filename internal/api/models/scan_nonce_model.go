@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScanNonce records a QR payload that has already been used to start a rental, so the same
+// payload cannot be replayed. It is recorded with the rental it started, and released when that
+// rental is cancelled before the bike is unlocked.
+type ScanNonce struct {
+	Nonce     string     `json:"nonce" gorm:"primaryKey;size:32"`
+	BikeID    uuid.UUID  `json:"bike_id" gorm:"type:uuid;not null"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	RentalID  *uuid.UUID `json:"rental_id" gorm:"type:uuid;index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
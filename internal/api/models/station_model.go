@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Station is a docking point where bikes are parked between rentals.
+type Station struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Name      string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Address   string         `json:"address" gorm:"size:200;" validate:"max=200"`
+	City      string         `json:"city" gorm:"not null;size:100;index" validate:"required,min=1,max=100"`
+	Country   string         `json:"country" gorm:"not null;size:2;" validate:"required,len=2"`
+	Latitude  float64        `json:"latitude" gorm:"not null" validate:"latitude"`
+	Longitude float64        `json:"longitude" gorm:"not null" validate:"longitude"`
+	Capacity  int            `json:"capacity" gorm:"not null;default:0" validate:"min=0"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
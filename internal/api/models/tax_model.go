@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/tax"
+)
+
+// TaxRate is a tax charged on the rides of a country, or of a city when City is set, such as a
+// VAT. City holds the lowercase city name, and the rates of a city are charged on top of the
+// rates of its country. A rate is in force from EffectiveFrom until a rate with the same name
+// and jurisdiction takes effect after it; a rate of zero ends the tax. An inclusive rate is part
+// of the ride price, an exclusive rate is added on top of it.
+type TaxRate struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	Name          string    `json:"name" gorm:"not null;size:50;uniqueIndex:idx_tax_rates_version" validate:"required,min=1,max=50"`
+	Country       string    `json:"country" gorm:"not null;size:2;uniqueIndex:idx_tax_rates_version" validate:"required,len=2"`
+	City          string    `json:"city" gorm:"not null;size:100;default:'';uniqueIndex:idx_tax_rates_version" validate:"max=100"`
+	Percent       float64   `json:"percent" gorm:"not null" validate:"min=0,max=100"`
+	Inclusive     bool      `json:"inclusive" gorm:"not null;default:false"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"not null;uniqueIndex:idx_tax_rates_version"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RentalTaxLine is a tax charged on a rental, with the rate in force when the rental was
+// charged. Base is the rental price without taxes.
+type RentalTaxLine struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	RentalID  uuid.UUID   `json:"rental_id" gorm:"type:uuid;not null;index"`
+	TaxRateID uuid.UUID   `json:"tax_rate_id" gorm:"type:uuid;not null;index"`
+	Name      string      `json:"name" gorm:"not null;size:50;"`
+	Country   string      `json:"country" gorm:"not null;size:2;"`
+	City      string      `json:"city" gorm:"not null;size:100;default:''"`
+	Percent   float64     `json:"percent" gorm:"not null"`
+	Inclusive bool        `json:"inclusive" gorm:"not null;default:false"`
+	Base      money.Money `json:"base" gorm:"embedded;embeddedPrefix:base_"`
+	Amount    money.Money `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt time.Time   `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Description returns how the tax line is shown on receipts, such as "VAT 21%".
+func (l RentalTaxLine) Description() string {
+	return l.Name + " " + tax.FormatPercent(l.Percent)
+}
+
+// RevenueReport sums the rentals completed over a period, one row per currency, and the taxes
+// charged on them, one row per rate.
+type RevenueReport struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Revenue []RevenueRow    `json:"revenue"`
+	Taxes   []RevenueTaxRow `json:"taxes"`
+}
+
+// RevenueRow is the revenue of the rentals of a currency. Gross is what the riders were
+// charged, taxes included, and Net is the gross less the taxes. Refunds are not deducted.
+type RevenueRow struct {
+	Currency string      `json:"currency"`
+	Rentals  int64       `json:"rentals"`
+	Gross    money.Money `json:"gross"`
+	Taxes    money.Money `json:"taxes"`
+	Net      money.Money `json:"net"`
+}
+
+// RevenueTaxRow is the tax charged at a rate, in a currency.
+type RevenueTaxRow struct {
+	Name      string      `json:"name"`
+	Country   string      `json:"country"`
+	City      string      `json:"city"`
+	Percent   float64     `json:"percent"`
+	Inclusive bool        `json:"inclusive"`
+	Rentals   int64       `json:"rentals"`
+	Base      money.Money `json:"base"`
+	Amount    money.Money `json:"amount"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TripPoint is a GPS fix reported by a bike or by the rider's app during a rental. The points
+// of a rental, ordered by RecordedAt, form its route.
+type TripPoint struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;not null"`
+	RentalID   uuid.UUID `json:"rental_id" gorm:"type:uuid;not null;uniqueIndex:idx_trip_points_rental_recorded_at"`
+	Latitude   float64   `json:"latitude" gorm:"not null" validate:"latitude"`
+	Longitude  float64   `json:"longitude" gorm:"not null" validate:"longitude"`
+	RecordedAt time.Time `json:"recorded_at" gorm:"not null;uniqueIndex:idx_trip_points_rental_recorded_at" validate:"required"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
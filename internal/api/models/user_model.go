@@ -23,15 +23,21 @@ const (
 )
 
 type User struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
-	Email     string         `json:"email" gorm:"unique;not null;size:100;" validate:"required,email"`
-	Password  string         `json:"password" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
-	Name      string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
-	Phone     string         `json:"phone" gorm:"not null;size:100;"`
-	Status    UserStatusEnum `json:"status" gorm:"not null;default:'active'" validate:"required,oneof='active' 'inactive'"`
-	Role      UserRoleEnum   `json:"role" gorm:"not null;default:'user'" validate:"required,oneof='admin' 'user'"`
-	Image     string         `json:"image" gorm:"size:500;"`
-	Verified  bool           `json:"verified" gorm:"not null;default:false"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                 uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	Email              string         `json:"email" gorm:"unique;not null;size:100;" validate:"required,email"`
+	Password           string         `json:"password" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Name               string         `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Phone              string         `json:"phone" gorm:"not null;size:100;"`
+	Status             UserStatusEnum `json:"status" gorm:"not null;default:'active'" validate:"required,oneof='active' 'inactive'"`
+	Role               UserRoleEnum   `json:"role" gorm:"not null;default:'user'" validate:"required,oneof='admin' 'user'"`
+	Image              string         `json:"image" gorm:"size:500;"`
+	ImageKey           string         `json:"-" gorm:"size:500;"`
+	ThumbnailKey       string         `json:"-" gorm:"size:500;"`
+	Verified           bool           `json:"verified" gorm:"not null;default:false"`
+	BirthDate          string         `json:"birth_date" gorm:"size:10;" validate:"omitempty,datetime=2006-01-02"`
+	TermsVersion       string         `json:"terms_version" gorm:"size:50;" validate:"max=50"`
+	TermsAcceptedAt    *time.Time     `json:"terms_accepted_at"`
+	CorporateAccountID *uuid.UUID     `json:"corporate_account_id" gorm:"type:uuid;index"`
+	CreatedAt          time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 }
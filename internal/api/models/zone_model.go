@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+// ZoneTypeEnum represents the kind of area a zone delimits.
+type ZoneTypeEnum string
+
+const (
+	ZONE_TYPE_OPERATING         ZoneTypeEnum = "operating"
+	ZONE_TYPE_NO_PARKING        ZoneTypeEnum = "no_parking"
+	ZONE_TYPE_SLOW              ZoneTypeEnum = "slow"
+	ZONE_TYPE_PREFERRED_PARKING ZoneTypeEnum = "preferred_parking"
+)
+
+// Zone is an area of the map drawn by an admin. Bikes may only be returned inside an operating
+// zone and outside no-parking zones; a no-parking zone with a fee charges it instead of
+// refusing the return.
+type Zone struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey;not null;index"`
+	Name          string          `json:"name" gorm:"not null;size:100;" validate:"required,min=1,max=100"`
+	Type          ZoneTypeEnum    `json:"type" gorm:"not null;index" validate:"required,oneof='operating' 'no_parking' 'slow' 'preferred_parking'"`
+	Geometry      json.RawMessage `json:"geometry" gorm:"type:jsonb;not null;serializer:json" validate:"required"`
+	Fee           money.Money     `json:"fee" gorm:"embedded;embeddedPrefix:fee_" validate:"money"`
+	SpeedLimitKmh *int            `json:"speed_limit_kmh" validate:"omitempty,gt=0"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+}
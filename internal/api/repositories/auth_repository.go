@@ -138,7 +138,7 @@ func (r *authRepositoryImp) DeleteValidationToken(token string) error {
 	return r.db.Where("token = ?", token).Delete(&models.ValidationToken{}).Error
 }
 
-// ValidateUser updates the "validated" field of a user in the database.
+// ValidateUser marks a user as verified and activates their account.
 //
 // Parameters:
 // - id: a string representing the ID of the user to validate.
@@ -146,5 +146,5 @@ func (r *authRepositoryImp) DeleteValidationToken(token string) error {
 // Returns:
 // - error: an error if there was a problem updating the user, or nil if the user was updated successfully.
 func (r *authRepositoryImp) ValidateUser(id string) error {
-	return r.db.Where("id = ?", id).Model(&models.User{}).Update("verified", true).Error
+	return r.db.Where("id = ?", id).Model(&models.User{}).Updates(map[string]interface{}{"verified": true, "status": models.UserStatusActive}).Error
 }
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+type BikeCatalogRepository interface {
+	CreateBikeModel(model *models.BikeModel) error
+	GetAllBikeModels(pagination pkg.Pagination) (*[]models.BikeModel, *pkg.Pagination, error)
+	GetBikeModelByID(id string) (*models.BikeModel, error)
+	UpdateBikeModel(model *models.BikeModel) error
+	UpdateBikeModelPrice(id string, pricePerHour money.Money) (int64, error)
+	DeleteBikeModel(id string) error
+}
+
+type bikeCatalogRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewBikeCatalogRepository creates a new bike catalog repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - BikeCatalogRepository: an implementation of the BikeCatalogRepository interface.
+func NewBikeCatalogRepository(db *gorm.DB) BikeCatalogRepository {
+	return &bikeCatalogRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateBikeModel creates a new catalog model in the database.
+//
+// Parameters:
+// - model: a pointer to a models.BikeModel object representing the model to be created.
+//
+// Returns:
+// - error: an error if there was a problem creating the model, or nil if the model was created successfully.
+func (r *bikeCatalogRepositoryImp) CreateBikeModel(model *models.BikeModel) error {
+	return r.db.Create(model).Error
+}
+
+// GetAllBikeModels retrieves all catalog models from the database.
+//
+// Parameters:
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.BikeModel: a pointer to a slice of models.BikeModel.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *bikeCatalogRepositoryImp) GetAllBikeModels(pagination pkg.Pagination) (*[]models.BikeModel, *pkg.Pagination, error) {
+	var bikeModels []models.BikeModel
+
+	err := r.db.Scopes(pkg.Paginate(&models.BikeModel{}, &pagination, r.db)).Order("manufacturer, name").Find(&bikeModels).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &bikeModels, &pagination, nil
+}
+
+// GetBikeModelByID retrieves a catalog model from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the model to retrieve.
+//
+// Returns:
+// - *models.BikeModel: a pointer to the model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the model, or nil if successful.
+func (r *bikeCatalogRepositoryImp) GetBikeModelByID(id string) (*models.BikeModel, error) {
+	var model models.BikeModel
+
+	if err := r.db.First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike model not found")
+		}
+
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+// UpdateBikeModel updates a catalog model in the database.
+//
+// Parameters:
+// - model: a pointer to a models.BikeModel object representing the model to be updated.
+//
+// Returns:
+// - error: an error if there was a problem updating the model, or nil if the model was updated successfully.
+func (r *bikeCatalogRepositoryImp) UpdateBikeModel(model *models.BikeModel) error {
+	result := r.db.Model(&models.BikeModel{}).Omit("ID", "CreatedAt").Where("id = ?", model.ID).Updates(model)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("bike model not found")
+	}
+
+	return nil
+}
+
+// UpdateBikeModelPrice sets the default hourly price of a catalog model. Every bike of the
+// model is priced by it, so the change applies to the whole model at once.
+//
+// Parameters:
+// - id: the ID of the model to reprice.
+// - pricePerHour: the new hourly price.
+//
+// Returns:
+// - int64: the number of bikes priced by the model.
+// - error: an error if the model is not found or could not be updated.
+func (r *bikeCatalogRepositoryImp) UpdateBikeModelPrice(id string, pricePerHour money.Money) (int64, error) {
+	var bikes int64
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BikeModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"default_price_per_hour_amount":   pricePerHour.Amount,
+			"default_price_per_hour_currency": pricePerHour.Currency,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("bike model not found")
+		}
+
+		return tx.Model(&models.Bike{}).Where("model_id = ?", id).Count(&bikes).Error
+	})
+
+	return bikes, err
+}
+
+// DeleteBikeModel deletes a catalog model from the database based on its ID.
+//
+// Parameters:
+// - id: the ID of the model to be deleted.
+//
+// Returns:
+// - error: an error if the model is not found, is still used by bikes, or could not be deleted.
+func (r *bikeCatalogRepositoryImp) DeleteBikeModel(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var bikes int64
+		if err := tx.Model(&models.Bike{}).Where("model_id = ?", id).Count(&bikes).Error; err != nil {
+			return err
+		}
+
+		if bikes > 0 {
+			return fmt.Errorf("bike model is in use by %d bikes", bikes)
+		}
+
+		result := tx.Where("id = ?", id).Delete(&models.BikeModel{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("bike model not found")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BikeHoldRepository interface {
+	GetBikeByID(id string) (*models.Bike, error)
+	CreateHold(hold *models.BikeHold, cooldown time.Duration, reservationBuffer time.Duration) error
+	GetActiveHoldByUserID(userID string) (*models.BikeHold, error)
+	GetHoldByID(id string) (*models.BikeHold, error)
+	ReleaseHold(id string) error
+	ExpireHolds() (int64, error)
+}
+
+type bikeHoldRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewBikeHoldRepository creates a new bike hold repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - BikeHoldRepository: an implementation of the BikeHoldRepository interface.
+func NewBikeHoldRepository(db *gorm.DB) BikeHoldRepository {
+	return &bikeHoldRepositoryImp{
+		db: db,
+	}
+}
+
+// GetBikeByID retrieves a bike from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the bike to retrieve.
+//
+// Returns:
+// - *models.Bike: a pointer to the bike if found, or nil if not found.
+// - error: an error if there was a problem retrieving the bike, or nil if successful.
+func (r *bikeHoldRepositoryImp) GetBikeByID(id string) (*models.Bike, error) {
+	var bike models.Bike
+
+	if err := r.db.First(&bike, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike not found")
+		}
+
+		return nil, err
+	}
+
+	return &bike, nil
+}
+
+// CreateHold holds an available bike for a rider and marks the bike as held, in a single
+// transaction with the bike row locked.
+//
+// Parameters:
+// - hold: a pointer to a models.BikeHold object representing the hold to create.
+// - cooldown: how long after a released or expired hold the rider must wait.
+// - reservationBuffer: how long before a reservation of another rider the bike cannot be held.
+//
+// Returns:
+// - error: an error if the bike is not found or not available, the rider already holds a bike
+// or is cooling down, or the hold could not be created.
+func (r *bikeHoldRepositoryImp) CreateHold(hold *models.BikeHold, cooldown time.Duration, reservationBuffer time.Duration) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var bike models.Bike
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bike, "id = ?", hold.BikeID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("bike not found")
+			}
+
+			return err
+		}
+
+		if bike.Status != models.BIKE_STATUS_AVAILABLE {
+			return fmt.Errorf("bike is not available to hold")
+		}
+
+		var count int64
+		err := tx.Model(&models.BikeHold{}).
+			Where("user_id = ? AND status IN ? AND ended_at > ?", hold.UserID,
+				[]models.BikeHoldStatusEnum{models.BIKE_HOLD_STATUS_RELEASED, models.BIKE_HOLD_STATUS_EXPIRED},
+				time.Now().Add(-cooldown)).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("you must wait before holding another bike")
+		}
+
+		err = tx.Model(&models.Reservation{}).
+			Where("bike_id = ? AND user_id <> ? AND status = ? AND start_time < ?", hold.BikeID, hold.UserID,
+				models.RESERVATION_STATUS_PENDING, time.Now().Add(reservationBuffer)).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("bike is reserved by another rider")
+		}
+
+		if err := tx.Create(hold).Error; err != nil {
+			if isDuplicateKeyError(err) {
+				return fmt.Errorf("you already have an active hold")
+			}
+
+			return err
+		}
+
+		return tx.Model(&models.Bike{}).Where("id = ?", hold.BikeID).Update("status", models.BIKE_STATUS_HELD).Error
+	})
+}
+
+// GetActiveHoldByUserID retrieves the active hold of a rider.
+//
+// Parameters:
+// - userID: the ID of the rider.
+//
+// Returns:
+// - *models.BikeHold: a pointer to the hold.
+// - error: an error if the rider has no active hold or it could not be retrieved.
+func (r *bikeHoldRepositoryImp) GetActiveHoldByUserID(userID string) (*models.BikeHold, error) {
+	var hold models.BikeHold
+
+	err := r.db.First(&hold, "user_id = ? AND status = ?", userID, models.BIKE_HOLD_STATUS_ACTIVE).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("hold not found")
+		}
+
+		return nil, err
+	}
+
+	return &hold, nil
+}
+
+// GetHoldByID retrieves a hold from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the hold to retrieve.
+//
+// Returns:
+// - *models.BikeHold: a pointer to the hold if found, or nil if not found.
+// - error: an error if there was a problem retrieving the hold, or nil if successful.
+func (r *bikeHoldRepositoryImp) GetHoldByID(id string) (*models.BikeHold, error) {
+	var hold models.BikeHold
+
+	if err := r.db.First(&hold, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("hold not found")
+		}
+
+		return nil, err
+	}
+
+	return &hold, nil
+}
+
+// ReleaseHold ends an active hold before it expires and makes its bike available again.
+//
+// Parameters:
+// - id: the ID of the hold to release.
+//
+// Returns:
+// - error: an error if the hold is no longer active or could not be released.
+func (r *bikeHoldRepositoryImp) ReleaseHold(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var holds []models.BikeHold
+
+		result := tx.Model(&holds).
+			Clauses(clause.Returning{}).
+			Where("id = ? AND status = ?", id, models.BIKE_HOLD_STATUS_ACTIVE).
+			Updates(map[string]interface{}{"status": models.BIKE_HOLD_STATUS_RELEASED, "ended_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if len(holds) == 0 {
+			return fmt.Errorf("hold is not active")
+		}
+
+		return releaseHeldBikes(tx, holds)
+	})
+}
+
+// ExpireHolds ends the active holds whose time is up and makes their bikes available again.
+//
+// Returns:
+// - int64: the number of holds expired.
+// - error: an error if the holds could not be expired.
+func (r *bikeHoldRepositoryImp) ExpireHolds() (int64, error) {
+	var holds []models.BikeHold
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Model(&holds).
+			Clauses(clause.Returning{}).
+			Where("status = ? AND expires_at <= ?", models.BIKE_HOLD_STATUS_ACTIVE, now).
+			Updates(map[string]interface{}{"status": models.BIKE_HOLD_STATUS_EXPIRED, "ended_at": now}).Error
+		if err != nil {
+			return err
+		}
+
+		return releaseHeldBikes(tx, holds)
+	})
+
+	return int64(len(holds)), err
+}
+
+// releaseHeldBikes makes the bikes of ended holds available, unless they were rented in the
+// meantime.
+func releaseHeldBikes(tx *gorm.DB, holds []models.BikeHold) error {
+	if len(holds) == 0 {
+		return nil
+	}
+
+	bikeIDs := make([]uuid.UUID, len(holds))
+	for i, hold := range holds {
+		bikeIDs[i] = hold.BikeID
+	}
+
+	return tx.Model(&models.Bike{}).
+		Where("id IN ? AND status = ?", bikeIDs, models.BIKE_STATUS_HELD).
+		Update("status", models.BIKE_STATUS_AVAILABLE).Error
+}
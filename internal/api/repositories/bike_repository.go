@@ -3,6 +3,7 @@ package repositories
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
 	"github.com/vinniciusgomes/ebike-rental-service/pkg"
@@ -11,10 +12,17 @@ import (
 
 type BikeRepository interface {
 	CreateBike(bike *models.Bike) error
+	CreateBikes(bikes []models.Bike) error
 	GetAllBikes(pagination pkg.Pagination) (*[]models.Bike, *pkg.Pagination, error)
 	GetBikeByID(id string) (*models.Bike, error)
 	UpdateBike(bike *models.Bike) error
 	DeleteBike(id string) error
+	StreamBikes(batchSize int, fn func(bikes []models.Bike) error) error
+	GetBikeModelByID(id string) (*models.BikeModel, error)
+	GetStationByID(id string) (*models.Station, error)
+	GetExistingSerialNumbers(serialNumbers []string) ([]string, error)
+	GetBikesWithoutCode() (*[]models.Bike, error)
+	UpdateBikeCode(id string, code string) error
 }
 
 type bikeRepositoryImp struct {
@@ -33,15 +41,36 @@ func NewBikeRepository(db *gorm.DB) BikeRepository {
 // - bike: a pointer to a models.Bike object representing the bike to be created.
 //
 // Returns:
-// - error: an error if there was a problem creating the bike, or nil if the bike was created successfully.
+// - error: an error if there was a problem creating the bike, or nil if the bike was created successfully. It is ErrDuplicateSerialNumber when the serial number
+// is used by another bike.
 func (r *bikeRepositoryImp) CreateBike(bike *models.Bike) error {
 	if err := r.db.Create(bike).Error; err != nil {
-		return err
+		return bikeCreateError(err)
 	}
 
 	return nil
 }
 
+// CreateBikes creates several bikes in a single transaction.
+//
+// Parameters:
+// - bikes: a slice of models.Bike objects representing the bikes to be created.
+//
+// Returns:
+// - error: a *BikeRowError if any of the bikes could not be created. In that case none of them
+// is persisted.
+func (r *bikeRepositoryImp) CreateBikes(bikes []models.Bike) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range bikes {
+			if err := tx.Create(&bikes[i]).Error; err != nil {
+				return &BikeRowError{Row: i + 1, Err: bikeCreateError(err)}
+			}
+		}
+
+		return nil
+	})
+}
+
 // GetAllBikes retrieves all bikes from the database.
 //
 // Parameters:
@@ -53,7 +82,7 @@ func (r *bikeRepositoryImp) CreateBike(bike *models.Bike) error {
 func (r *bikeRepositoryImp) GetAllBikes(pagination pkg.Pagination) (*[]models.Bike, *pkg.Pagination, error) {
 	var bikes []models.Bike
 
-	err := r.db.Scopes(pkg.Paginate(&models.Bike{}, &pagination, r.db)).Find(&bikes).Error
+	err := r.db.Scopes(pkg.Paginate(&models.Bike{}, &pagination, r.db)).Preload("Model").Find(&bikes).Error
 	if err != nil {
 		return nil, nil, err
 	}
@@ -72,7 +101,7 @@ func (r *bikeRepositoryImp) GetAllBikes(pagination pkg.Pagination) (*[]models.Bi
 func (r *bikeRepositoryImp) GetBikeByID(id string) (*models.Bike, error) {
 	var bike models.Bike
 
-	if err := r.db.First(&bike, "id = ?", id).Error; err != nil {
+	if err := r.db.Preload("Model").First(&bike, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("bike not found")
 		}
@@ -106,12 +135,13 @@ func (r *bikeRepositoryImp) DeleteBike(id string) error {
 // - bike: a pointer to a models.Bike object representing the bike to be updated.
 //
 // Returns:
-// - error: an error if there was a problem updating the bike, or nil if the bike was updated successfully.
+// - error: an error if there was a problem updating the bike, or nil if the bike was updated successfully. It is ErrDuplicateSerialNumber when the serial number
+// is used by another bike.
 func (r *bikeRepositoryImp) UpdateBike(bike *models.Bike) error {
 	result := r.db.Model(&models.Bike{}).Omit("ID", "CreatedAt").Where("id = ?", bike.ID).Updates(bike)
 
 	if result.Error != nil {
-		return result.Error
+		return bikeCreateError(result.Error)
 	}
 
 	if result.RowsAffected == 0 {
@@ -120,3 +150,147 @@ func (r *bikeRepositoryImp) UpdateBike(bike *models.Bike) error {
 
 	return nil
 }
+
+// StreamBikes walks through every bike in the database in batches, ordered by ID.
+//
+// Parameters:
+// - batchSize: the number of bikes loaded per batch.
+// - fn: a callback invoked with each batch. Returning an error stops the iteration.
+//
+// Returns:
+// - error: an error if there was a problem retrieving the bikes or if fn returned an error.
+func (r *bikeRepositoryImp) StreamBikes(batchSize int, fn func(bikes []models.Bike) error) error {
+	var bikes []models.Bike
+
+	return r.db.FindInBatches(&bikes, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(bikes)
+	}).Error
+}
+
+// GetBikeModelByID retrieves a catalog model from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the model to retrieve.
+//
+// Returns:
+// - *models.BikeModel: a pointer to the model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the model, or nil if successful.
+func (r *bikeRepositoryImp) GetBikeModelByID(id string) (*models.BikeModel, error) {
+	var model models.BikeModel
+
+	if err := r.db.First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike model not found")
+		}
+
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+// GetStationByID retrieves a station from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the station to retrieve.
+//
+// Returns:
+// - *models.Station: a pointer to the station if found, or nil if not found.
+// - error: an error if there was a problem retrieving the station, or nil if successful.
+func (r *bikeRepositoryImp) GetStationByID(id string) (*models.Station, error) {
+	var station models.Station
+
+	if err := r.db.First(&station, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("station not found")
+		}
+
+		return nil, err
+	}
+
+	return &station, nil
+}
+
+// GetExistingSerialNumbers returns which of the given serial numbers are already used by a bike,
+// deleted bikes included, since the unique index covers them too.
+//
+// Parameters:
+// - serialNumbers: the serial numbers to look up.
+//
+// Returns:
+// - []string: the serial numbers already in use.
+// - error: an error if there was a problem retrieving the bikes.
+func (r *bikeRepositoryImp) GetExistingSerialNumbers(serialNumbers []string) ([]string, error) {
+	existing := []string{}
+	if len(serialNumbers) == 0 {
+		return existing, nil
+	}
+
+	err := r.db.Unscoped().Model(&models.Bike{}).Where("serial_number IN ?", serialNumbers).Pluck("serial_number", &existing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// GetBikesWithoutCode retrieves the bikes that were created before bike codes existed.
+//
+// Returns:
+// - *[]models.Bike: a pointer to a slice of models.Bike without a code.
+// - error: an error if there was a problem retrieving the bikes.
+func (r *bikeRepositoryImp) GetBikesWithoutCode() (*[]models.Bike, error) {
+	var bikes []models.Bike
+
+	if err := r.db.Where("code = '' OR code IS NULL").Find(&bikes).Error; err != nil {
+		return nil, err
+	}
+
+	return &bikes, nil
+}
+
+// UpdateBikeCode sets the short code of a bike.
+//
+// Parameters:
+// - id: the ID of the bike to update.
+// - code: the new code.
+//
+// Returns:
+// - error: an error if the code is already taken or the bike could not be updated.
+func (r *bikeRepositoryImp) UpdateBikeCode(id string, code string) error {
+	err := r.db.Model(&models.Bike{}).Where("id = ?", id).Update("code", code).Error
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("bike code already in use")
+	}
+
+	return err
+}
+
+// ErrDuplicateSerialNumber is returned when a bike is saved with the serial number of another
+// bike.
+var ErrDuplicateSerialNumber = errors.New("serial number is already used by another bike")
+
+// BikeRowError is returned when a bike of a batch could not be created. Row is the position of
+// the bike in the batch, starting at 1.
+type BikeRowError struct {
+	Row int
+	Err error
+}
+
+func (e *BikeRowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+}
+
+func (e *BikeRowError) Unwrap() error {
+	return e.Err
+}
+
+// bikeCreateError replaces the unique violation of the serial number of a bike with
+// ErrDuplicateSerialNumber.
+func bikeCreateError(err error) error {
+	if isDuplicateKeyError(err) && strings.Contains(err.Error(), "idx_bikes_serial_number") {
+		return ErrDuplicateSerialNumber
+	}
+
+	return err
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+)
+
+type CorporateAccountRepository interface {
+	CreateCorporateAccount(account *models.CorporateAccount) error
+	GetAllCorporateAccounts(pagination pkg.Pagination) (*[]models.CorporateAccount, *pkg.Pagination, error)
+	GetCorporateAccountByID(id string) (*models.CorporateAccount, error)
+	UpdateCorporateAccount(account *models.CorporateAccount) error
+	DeleteCorporateAccount(id string) error
+	GetCorporateAccountMembers(id string, pagination pkg.Pagination) (*[]models.User, *pkg.Pagination, error)
+	AddCorporateAccountMember(id string, userID string) error
+	RemoveCorporateAccountMember(id string, userID string) error
+}
+
+type corporateAccountRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewCorporateAccountRepository creates a new corporate account repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - CorporateAccountRepository: an implementation of the CorporateAccountRepository interface.
+func NewCorporateAccountRepository(db *gorm.DB) CorporateAccountRepository {
+	return &corporateAccountRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateCorporateAccount creates a new corporate account in the database.
+//
+// Parameters:
+// - account: a pointer to a models.CorporateAccount object representing the account to be created.
+//
+// Returns:
+// - error: an error if the account could not be created.
+func (r *corporateAccountRepositoryImp) CreateCorporateAccount(account *models.CorporateAccount) error {
+	return r.db.Create(account).Error
+}
+
+// GetAllCorporateAccounts retrieves the corporate accounts, by name.
+//
+// Parameters:
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.CorporateAccount: a pointer to a slice of models.CorporateAccount.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *corporateAccountRepositoryImp) GetAllCorporateAccounts(pagination pkg.Pagination) (*[]models.CorporateAccount, *pkg.Pagination, error) {
+	var accounts []models.CorporateAccount
+
+	err := r.db.Scopes(pkg.Paginate(&models.CorporateAccount{}, &pagination, r.db)).Order("name").Find(&accounts).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &accounts, &pagination, nil
+}
+
+// GetCorporateAccountByID retrieves a corporate account by its ID.
+//
+// Parameters:
+// - id: the ID of the corporate account to retrieve.
+//
+// Returns:
+// - *models.CorporateAccount: a pointer to the corporate account if found.
+// - error: an error if the corporate account is not found or could not be retrieved.
+func (r *corporateAccountRepositoryImp) GetCorporateAccountByID(id string) (*models.CorporateAccount, error) {
+	var account models.CorporateAccount
+
+	if err := r.db.First(&account, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("corporate account not found")
+		}
+
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// UpdateCorporateAccount replaces the details of a corporate account. Documents already issued
+// keep the details they were issued with.
+//
+// Parameters:
+// - account: a pointer to a models.CorporateAccount object representing the account to be updated.
+//
+// Returns:
+// - error: an error if the corporate account is not found or could not be updated.
+func (r *corporateAccountRepositoryImp) UpdateCorporateAccount(account *models.CorporateAccount) error {
+	result := r.db.Model(&models.CorporateAccount{}).
+		Where("id = ?", account.ID).
+		Select("Name", "TaxID", "BillingEmail", "Address").
+		Updates(account)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("corporate account not found")
+	}
+
+	return nil
+}
+
+// DeleteCorporateAccount deletes a corporate account and removes its members from it, as a single
+// transaction. The receipts already billed to the account are still invoiced to it.
+//
+// Parameters:
+// - id: the ID of the corporate account to be deleted.
+//
+// Returns:
+// - error: an error if the corporate account is not found or could not be deleted.
+func (r *corporateAccountRepositoryImp) DeleteCorporateAccount(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.CorporateAccount{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("corporate account not found")
+		}
+
+		return tx.Model(&models.User{}).Where("corporate_account_id = ?", id).Update("corporate_account_id", nil).Error
+	})
+}
+
+// GetCorporateAccountMembers retrieves the users whose rentals are billed to a corporate account,
+// by name.
+//
+// Parameters:
+// - id: the ID of the corporate account.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.User: a pointer to a slice of models.User.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *corporateAccountRepositoryImp) GetCorporateAccountMembers(id string, pagination pkg.Pagination) (*[]models.User, *pkg.Pagination, error) {
+	var users []models.User
+
+	query := r.db.Where("corporate_account_id = ?", id).Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.User{}, &pagination, query)).Order("name").Find(&users).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &users, &pagination, nil
+}
+
+// AddCorporateAccountMember bills the rentals of a user to a corporate account. A user belongs
+// to one account at most, so the user leaves any other account.
+//
+// Parameters:
+// - id: the ID of the corporate account.
+// - userID: the ID of the user.
+//
+// Returns:
+// - error: an error if the user is not found or could not be updated.
+func (r *corporateAccountRepositoryImp) AddCorporateAccountMember(id string, userID string) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", userID).Update("corporate_account_id", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// RemoveCorporateAccountMember bills the rentals of a member of a corporate account to the user
+// again.
+//
+// Parameters:
+// - id: the ID of the corporate account.
+// - userID: the ID of the user.
+//
+// Returns:
+// - error: an error if the user is not a member of the account or could not be updated.
+func (r *corporateAccountRepositoryImp) RemoveCorporateAccountMember(id string, userID string) error {
+	result := r.db.Model(&models.User{}).Where("id = ? AND corporate_account_id = ?", userID, id).Update("corporate_account_id", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user is not a member of the corporate account")
+	}
+
+	return nil
+}
@@ -0,0 +1,392 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DisputeRepository interface {
+	CreateDispute(dispute *models.Dispute) error
+	GetDisputes(userID string, status string, pagination pkg.Pagination) (*[]models.Dispute, *pkg.Pagination, error)
+	GetDisputeByID(id string) (*models.Dispute, error)
+	GetRentalByID(id string) (*models.Rental, error)
+	RefundDispute(id string, amount *money.Money, resolution string, resolvedByID uuid.UUID) (*models.Dispute, error)
+	AdjustDisputedRental(id string, adjustment *models.RentalAdjustment, rental *models.Rental, usage *models.PassUsage, discount *money.Money) (*models.Dispute, error)
+	RejectDispute(id string, resolution string, resolvedByID uuid.UUID) (*models.Dispute, error)
+}
+
+type disputeRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewDisputeRepository creates a new dispute repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - DisputeRepository: an implementation of the DisputeRepository interface.
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &disputeRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateDispute opens a dispute about a rental.
+//
+// Parameters:
+// - dispute: a pointer to a models.Dispute object representing the dispute to be opened.
+//
+// Returns:
+// - error: an error if the rental already has an open dispute, or the dispute could not be created.
+func (r *disputeRepositoryImp) CreateDispute(dispute *models.Dispute) error {
+	err := r.db.Create(dispute).Error
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("rental already has an open dispute")
+	}
+
+	return err
+}
+
+// GetDisputes retrieves disputes, the most recent first, optionally only those of a rider or
+// with a status.
+//
+// Parameters:
+// - userID: the ID of the rider, or an empty string.
+// - status: the status of the disputes, or an empty string.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Dispute: a pointer to a slice of models.Dispute.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *disputeRepositoryImp) GetDisputes(userID string, status string, pagination pkg.Pagination) (*[]models.Dispute, *pkg.Pagination, error) {
+	var disputes []models.Dispute
+
+	query := r.db
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query = query.Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.Dispute{}, &pagination, query)).
+		Preload("Photos").
+		Order("created_at DESC, id").
+		Find(&disputes).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &disputes, &pagination, nil
+}
+
+// GetDisputeByID retrieves a dispute by its ID, with its photos and the adjustments that
+// resolved it.
+//
+// Parameters:
+// - id: the ID of the dispute to retrieve.
+//
+// Returns:
+// - *models.Dispute: a pointer to the dispute if found.
+// - error: an error if the dispute is not found or could not be retrieved.
+func (r *disputeRepositoryImp) GetDisputeByID(id string) (*models.Dispute, error) {
+	return getDispute(r.db, id)
+}
+
+// GetRentalByID retrieves a rental from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the rental to retrieve.
+// Returns:
+// - *models.Rental: a pointer to the rental model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the rental, or nil if successful.
+func (r *disputeRepositoryImp) GetRentalByID(id string) (*models.Rental, error) {
+	var rental models.Rental
+
+	if err := r.db.Preload("Segments", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).Where("id = ?", id).First(&rental).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rental not found")
+		}
+
+		return nil, err
+	}
+
+	return &rental, nil
+}
+
+// RefundDispute resolves an open dispute by crediting back to the wallet of the rider some or
+// all of what the disputed rental was charged, and records the refund as an adjustment of the
+// rental, as a single transaction.
+//
+// Parameters:
+// - id: the ID of the dispute.
+// - amount: the amount to refund, or nil to refund everything not refunded yet.
+// - resolution: why the rental is refunded.
+// - resolvedByID: the ID of the admin resolving the dispute.
+//
+// Returns:
+// - *models.Dispute: a pointer to the resolved dispute.
+// - error: an error if the dispute is not found or not open, the amount exceeds what is left to
+// refund, or the refund could not be posted.
+func (r *disputeRepositoryImp) RefundDispute(id string, amount *money.Money, resolution string, resolvedByID uuid.UUID) (*models.Dispute, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		dispute, err := lockOpenDispute(tx, id)
+		if err != nil {
+			return err
+		}
+
+		rental, err := lockRental(tx, dispute.RentalID)
+		if err != nil {
+			return err
+		}
+
+		refund, err := refundRental(tx, *rental, amount, resolution, resolvedByID)
+		if err != nil {
+			return err
+		}
+
+		adjustment := &models.RentalAdjustment{
+			ID:                  uuid.Must(uuid.NewRandom()),
+			Type:                models.RENTAL_ADJUSTMENT_REFUND,
+			Reason:              resolution,
+			RefundTransactionID: &refund.ID,
+			Refund:              refund.Entries[0].Amount,
+			PreviousEndTime:     rental.EndTime,
+			EndTime:             rental.EndTime,
+			PreviousTotal:       rental.TotalCost,
+			Total:               rental.TotalCost,
+			CreatedByID:         resolvedByID,
+		}
+
+		return resolveDispute(tx, dispute, rental, adjustment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return getDispute(r.db, id)
+}
+
+// AdjustDisputedRental resolves an open dispute by storing the rental priced again for an
+// earlier end time, as a single transaction. The tax lines of the rental are replaced, the pass
+// and promo code of the rental are updated with what they cover now, and what the rider was
+// charged above the new total is credited back to their wallet.
+//
+// Parameters:
+// - id: the ID of the dispute.
+// - adjustment: a pointer to a models.RentalAdjustment object with the end time, total and price
+// breakdown of the rental before and after the adjustment, the resolution and the admin.
+// - rental: a pointer to a models.Rental object representing the rental priced again.
+// - usage: what the pass of the rental covers now, or nil when no pass covered it.
+// - discount: what the promo code of the rental takes off now, or nil without a promo code.
+//
+// Returns:
+// - *models.Dispute: a pointer to the resolved dispute.
+// - error: an error if the dispute is not found or not open, the rental changed since it was
+// priced again, or the adjustment could not be stored.
+func (r *disputeRepositoryImp) AdjustDisputedRental(id string, adjustment *models.RentalAdjustment, rental *models.Rental, usage *models.PassUsage, discount *money.Money) (*models.Dispute, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		dispute, err := lockOpenDispute(tx, id)
+		if err != nil {
+			return err
+		}
+
+		current, err := lockRental(tx, dispute.RentalID)
+		if err != nil {
+			return err
+		}
+
+		if current.ID != rental.ID || !current.EndTime.Equal(adjustment.PreviousEndTime) {
+			return fmt.Errorf("rental was changed since it was priced again")
+		}
+
+		err = tx.Model(&models.Rental{}).Where("id = ?", rental.ID).
+			Select("end_time", "total_cost_amount", "total_cost_currency", "tax_total_amount", "tax_total_currency", "price_breakdown").
+			Updates(rental).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("rental_id = ?", rental.ID).Delete(&models.RentalTaxLine{}).Error; err != nil {
+			return err
+		}
+
+		if len(rental.TaxLines) > 0 {
+			if err := tx.Create(&rental.TaxLines).Error; err != nil {
+				return err
+			}
+		}
+
+		if usage != nil {
+			var previous models.PassUsage
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&previous, "id = ?", usage.ID).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&previous).Update("minutes", usage.Minutes).Error; err != nil {
+				return err
+			}
+
+			err := tx.Model(&models.Pass{}).Where("id = ?", usage.PassID).
+				Update("minutes_used", gorm.Expr("GREATEST(minutes_used + ?, 0)", usage.Minutes-previous.Minutes)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if discount != nil {
+			err := tx.Model(&models.PromotionRedemption{}).Where("rental_id = ?", rental.ID).
+				Updates(map[string]interface{}{"discount_amount": discount.Amount, "discount_currency": discount.Currency}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		adjustment.ID = uuid.Must(uuid.NewRandom())
+		adjustment.Type = models.RENTAL_ADJUSTMENT_END_TIME
+		adjustment.Refund = money.New(0, rental.TotalCost.Currency)
+
+		charged, err := rentalNetCharge(tx, *rental)
+		if err != nil {
+			return err
+		}
+
+		if refund := charged.Sub(rental.TotalCost); !refund.IsZero() && !refund.IsNegative() {
+			transaction, err := refundRental(tx, *rental, &refund, adjustment.Reason, adjustment.CreatedByID)
+			if err != nil {
+				return err
+			}
+
+			adjustment.RefundTransactionID = &transaction.ID
+			adjustment.Refund = refund
+		}
+
+		return resolveDispute(tx, dispute, rental, adjustment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return getDispute(r.db, id)
+}
+
+// RejectDispute closes an open dispute without changing the rental.
+//
+// Parameters:
+// - id: the ID of the dispute.
+// - resolution: why the dispute is rejected.
+// - resolvedByID: the ID of the admin rejecting the dispute.
+//
+// Returns:
+// - *models.Dispute: a pointer to the rejected dispute.
+// - error: an error if the dispute is not found or not open, or could not be updated.
+func (r *disputeRepositoryImp) RejectDispute(id string, resolution string, resolvedByID uuid.UUID) (*models.Dispute, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("id = ? AND status = ?", id, models.DISPUTE_STATUS_OPEN).
+		Updates(map[string]interface{}{
+			"status":         models.DISPUTE_STATUS_REJECTED,
+			"resolution":     resolution,
+			"resolved_by_id": resolvedByID,
+			"resolved_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := getDispute(r.db, id); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("dispute is not open")
+	}
+
+	return getDispute(r.db, id)
+}
+
+// getDispute retrieves a dispute with its photos and adjustments.
+func getDispute(tx *gorm.DB, id string) (*models.Dispute, error) {
+	var dispute models.Dispute
+
+	err := tx.Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Adjustments").
+		First(&dispute, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("dispute not found")
+		}
+
+		return nil, err
+	}
+
+	return &dispute, nil
+}
+
+// lockOpenDispute locks the row of an open dispute.
+func lockOpenDispute(tx *gorm.DB, id string) (*models.Dispute, error) {
+	var dispute models.Dispute
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&dispute, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("dispute not found")
+		}
+
+		return nil, err
+	}
+
+	if dispute.Status != models.DISPUTE_STATUS_OPEN {
+		return nil, fmt.Errorf("dispute is not open")
+	}
+
+	return &dispute, nil
+}
+
+// lockRental locks the row of a rental.
+func lockRental(tx *gorm.DB, id uuid.UUID) (*models.Rental, error) {
+	var rental models.Rental
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rental not found")
+		}
+
+		return nil, err
+	}
+
+	return &rental, nil
+}
+
+// resolveDispute records an adjustment of a disputed rental, linked to the charge of the rental,
+// and marks the dispute resolved by it.
+func resolveDispute(tx *gorm.DB, dispute *models.Dispute, rental *models.Rental, adjustment *models.RentalAdjustment) error {
+	chargeID, err := rentalChargeID(tx, rental.ID)
+	if err != nil {
+		return err
+	}
+
+	adjustment.RentalID = rental.ID
+	adjustment.DisputeID = &dispute.ID
+	adjustment.ChargeTransactionID = chargeID
+
+	if err := tx.Create(adjustment).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(dispute).Updates(map[string]interface{}{
+		"status":         models.DISPUTE_STATUS_RESOLVED,
+		"resolution":     adjustment.Reason,
+		"resolved_by_id": adjustment.CreatedByID,
+		"resolved_at":    time.Now(),
+	}).Error
+}
@@ -0,0 +1,9 @@
+package repositories
+
+import "strings"
+
+// isDuplicateKeyError reports whether err is a unique constraint violation raised by
+// PostgreSQL (SQLSTATE 23505).
+func isDuplicateKeyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "SQLSTATE 23505")
+}
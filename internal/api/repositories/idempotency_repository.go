@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	CreateIdempotencyKey(key *models.IdempotencyKey) (bool, error)
+	GetIdempotencyKey(userID string, key string) (*models.IdempotencyKey, error)
+	CompleteIdempotencyKey(key *models.IdempotencyKey) error
+	DeleteIdempotencyKey(userID string, key string) error
+}
+
+type idempotencyRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - IdempotencyRepository: an implementation of the IdempotencyRepository interface.
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateIdempotencyKey claims an idempotency key for a request. Expired keys are purged first,
+// so an expired key can be claimed again.
+//
+// Parameters:
+// - key: a pointer to a models.IdempotencyKey object representing the key to claim.
+//
+// Returns:
+// - bool: true if the key was claimed, false if the user already used it.
+// - error: an error if there was a problem storing the key.
+func (r *idempotencyRepositoryImp) CreateIdempotencyKey(key *models.IdempotencyKey) (bool, error) {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return false, err
+	}
+
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// GetIdempotencyKey retrieves an idempotency key of a user.
+//
+// Parameters:
+// - userID: the ID of the user.
+// - key: the idempotency key.
+//
+// Returns:
+// - *models.IdempotencyKey: a pointer to the key if found, or nil if not found.
+// - error: an error if there was a problem retrieving the key, or nil if successful.
+func (r *idempotencyRepositoryImp) GetIdempotencyKey(userID string, key string) (*models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+
+	if err := r.db.First(&idempotencyKey, "user_id = ? AND key = ?", userID, key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("idempotency key not found")
+		}
+
+		return nil, err
+	}
+
+	return &idempotencyKey, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed a key.
+//
+// Parameters:
+// - key: a pointer to a models.IdempotencyKey object holding the response.
+//
+// Returns:
+// - error: an error if there was a problem updating the key.
+func (r *idempotencyRepositoryImp) CompleteIdempotencyKey(key *models.IdempotencyKey) error {
+	return r.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", key.UserID, key.Key).
+		Updates(map[string]interface{}{
+			"status":        models.IDEMPOTENCY_KEY_STATUS_COMPLETED,
+			"status_code":   key.StatusCode,
+			"content_type":  key.ContentType,
+			"response_body": key.ResponseBody,
+		}).Error
+}
+
+// DeleteIdempotencyKey releases a key whose request failed, so it can be retried.
+//
+// Parameters:
+// - userID: the ID of the user.
+// - key: the idempotency key.
+//
+// Returns:
+// - error: an error if there was a problem deleting the key.
+func (r *idempotencyRepositoryImp) DeleteIdempotencyKey(userID string, key string) error {
+	return r.db.Where("user_id = ? AND key = ?", userID, key).Delete(&models.IdempotencyKey{}).Error
+}
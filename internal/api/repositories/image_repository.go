@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"gorm.io/gorm"
+)
+
+type ImageRepository interface {
+	GetBikeByID(id string) (*models.Bike, error)
+	UpdateBikeImage(id string, image string, imageKey string, thumbnailKey string) error
+	GetUserByID(id string) (*models.User, error)
+	UpdateUserImage(id string, image string, imageKey string, thumbnailKey string) error
+	GetDisputeByID(id string) (*models.Dispute, error)
+	CreateDisputePhoto(photo *models.DisputePhoto, maxPhotos int) error
+}
+
+type imageRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewImageRepository creates a new image repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - ImageRepository: an implementation of the ImageRepository interface.
+func NewImageRepository(db *gorm.DB) ImageRepository {
+	return &imageRepositoryImp{
+		db: db,
+	}
+}
+
+// GetBikeByID retrieves a bike from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the bike to retrieve.
+//
+// Returns:
+// - *models.Bike: a pointer to the bike model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the bike, or nil if successful.
+func (r *imageRepositoryImp) GetBikeByID(id string) (*models.Bike, error) {
+	var bike models.Bike
+
+	if err := r.db.Where("id = ?", id).First(&bike).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike not found")
+		}
+		return nil, err
+	}
+
+	return &bike, nil
+}
+
+// UpdateBikeImage updates the image URL and the storage keys of a bike.
+//
+// Parameters:
+// - id: the ID of the bike to update.
+// - image: the public URL of the image.
+// - imageKey: the storage key of the original image.
+// - thumbnailKey: the storage key of the thumbnail.
+//
+// Returns:
+// - error: an error if there was a problem updating the bike.
+func (r *imageRepositoryImp) UpdateBikeImage(id string, image string, imageKey string, thumbnailKey string) error {
+	result := r.db.Model(&models.Bike{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"image":         image,
+		"image_key":     imageKey,
+		"thumbnail_key": thumbnailKey,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("bike not found")
+	}
+
+	return nil
+}
+
+// GetUserByID retrieves a user from the database based on their ID.
+//
+// Parameters:
+// - id: the ID of the user to retrieve.
+//
+// Returns:
+// - *models.User: a pointer to the user model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the user, or nil if successful.
+func (r *imageRepositoryImp) GetUserByID(id string) (*models.User, error) {
+	var user models.User
+
+	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdateUserImage updates the image URL and the storage keys of a user.
+//
+// Parameters:
+// - id: the ID of the user to update.
+// - image: the public URL of the image.
+// - imageKey: the storage key of the original image.
+// - thumbnailKey: the storage key of the thumbnail.
+//
+// Returns:
+// - error: an error if there was a problem updating the user.
+func (r *imageRepositoryImp) UpdateUserImage(id string, image string, imageKey string, thumbnailKey string) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"image":         image,
+		"image_key":     imageKey,
+		"thumbnail_key": thumbnailKey,
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GetDisputeByID retrieves a dispute by its ID, with its photos.
+//
+// Parameters:
+// - id: the ID of the dispute to retrieve.
+//
+// Returns:
+// - *models.Dispute: a pointer to the dispute if found.
+// - error: an error if the dispute is not found or could not be retrieved.
+func (r *imageRepositoryImp) GetDisputeByID(id string) (*models.Dispute, error) {
+	return getDispute(r.db, id)
+}
+
+// CreateDisputePhoto adds a photo to an open dispute. The dispute row is locked, so concurrent
+// uploads cannot add more than maxPhotos photos.
+//
+// Parameters:
+// - photo: a pointer to a models.DisputePhoto object representing the photo to be added.
+// - maxPhotos: the number of photos a dispute can have.
+//
+// Returns:
+// - error: an error if the dispute is not found or not open, already has maxPhotos photos, or
+// the photo could not be added.
+func (r *imageRepositoryImp) CreateDisputePhoto(photo *models.DisputePhoto, maxPhotos int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockOpenDispute(tx, photo.DisputeID.String()); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.DisputePhoto{}).Where("dispute_id = ?", photo.DisputeID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count >= int64(maxPhotos) {
+			return fmt.Errorf("dispute already has %d photos", maxPhotos)
+		}
+
+		return tx.Create(photo).Error
+	})
+}
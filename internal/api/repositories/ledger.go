@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ledgerAccount returns the ledger account of a type, owner and currency, creating it the first
+// time it is used. Platform accounts have no owner.
+func ledgerAccount(tx *gorm.DB, accountType models.LedgerAccountTypeEnum, userID *uuid.UUID, currency string) (*models.LedgerAccount, error) {
+	owner := "platform"
+	if userID != nil {
+		owner = userID.String()
+	}
+
+	account := &models.LedgerAccount{
+		ID:       uuid.Must(uuid.NewRandom()),
+		Key:      fmt.Sprintf("%s:%s:%s", accountType, owner, currency),
+		Type:     accountType,
+		UserID:   userID,
+		Currency: currency,
+	}
+
+	err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "key"}}, DoNothing: true}).Create(account).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.First(account, "key = ?", account.Key).Error; err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ledgerEntry builds an entry on the ledger account of a type, owner and the currency of the
+// amount.
+func ledgerEntry(tx *gorm.DB, accountType models.LedgerAccountTypeEnum, userID *uuid.UUID, direction models.LedgerEntryDirectionEnum, amount money.Money) (models.LedgerEntry, error) {
+	account, err := ledgerAccount(tx, accountType, userID, amount.Currency)
+	if err != nil {
+		return models.LedgerEntry{}, err
+	}
+
+	return models.LedgerEntry{AccountID: account.ID, Direction: direction, Amount: amount}, nil
+}
+
+// transferEntries builds the two entries that move an amount from one ledger account to another:
+// the source account is debited and the destination account credited.
+func transferEntries(tx *gorm.DB, from models.LedgerAccountTypeEnum, fromUserID *uuid.UUID, to models.LedgerAccountTypeEnum, toUserID *uuid.UUID, amount money.Money) ([]models.LedgerEntry, error) {
+	debit, err := ledgerEntry(tx, from, fromUserID, models.LEDGER_ENTRY_DEBIT, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, err := ledgerEntry(tx, to, toUserID, models.LEDGER_ENTRY_CREDIT, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return []models.LedgerEntry{debit, credit}, nil
+}
+
+// postLedgerTransaction records a transaction and its entries. Every entry must have a positive
+// amount, and the debits and credits of the transaction must be equal in every currency.
+func postLedgerTransaction(tx *gorm.DB, transaction *models.LedgerTransaction, entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("ledger transaction has no entries")
+	}
+
+	balance := map[string]int64{}
+	for _, entry := range entries {
+		if entry.Amount.IsZero() || entry.Amount.IsNegative() {
+			return fmt.Errorf("ledger entries must have positive amounts")
+		}
+
+		if entry.Direction == models.LEDGER_ENTRY_DEBIT {
+			balance[entry.Amount.Currency] += entry.Amount.Amount
+		} else {
+			balance[entry.Amount.Currency] -= entry.Amount.Amount
+		}
+	}
+
+	for _, amount := range balance {
+		if amount != 0 {
+			return fmt.Errorf("ledger transaction is not balanced")
+		}
+	}
+
+	if err := tx.Omit("Entries").Create(transaction).Error; err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entries[i].ID = uuid.Must(uuid.NewRandom())
+		entries[i].TransactionID = transaction.ID
+	}
+
+	if err := tx.Omit("Transaction").Create(&entries).Error; err != nil {
+		return err
+	}
+
+	transaction.Entries = entries
+
+	return nil
+}
@@ -0,0 +1,318 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PassRepository interface {
+	CreatePassProduct(product *models.PassProduct) error
+	GetAllPassProducts(activeOnly bool) (*[]models.PassProduct, error)
+	GetPassProductByID(id string) (*models.PassProduct, error)
+	UpdatePassProduct(product *models.PassProduct) error
+	DeletePassProduct(id string) error
+	CreatePass(pass *models.Pass) error
+	GetPassesByUserID(userID string, pagination pkg.Pagination) (*[]models.Pass, *pkg.Pagination, error)
+	GetPassByID(id string) (*models.Pass, error)
+	CancelPass(id string) error
+	RenewPasses(now time.Time) (int64, error)
+}
+
+type passRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewPassRepository creates a new pass repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - PassRepository: an implementation of the PassRepository interface.
+func NewPassRepository(db *gorm.DB) PassRepository {
+	return &passRepositoryImp{
+		db: db,
+	}
+}
+
+// CreatePassProduct creates a new pass product in the database.
+//
+// Parameters:
+// - product: a pointer to a models.PassProduct object representing the product to be created.
+//
+// Returns:
+// - error: an error if there was a problem creating the product, or nil if the product was created successfully.
+func (r *passRepositoryImp) CreatePassProduct(product *models.PassProduct) error {
+	return r.db.Create(product).Error
+}
+
+// GetAllPassProducts retrieves the pass products, ordered by price.
+//
+// Parameters:
+// - activeOnly: whether to retrieve only the products on sale.
+//
+// Returns:
+// - *[]models.PassProduct: a pointer to a slice of models.PassProduct.
+// - error: an error if any.
+func (r *passRepositoryImp) GetAllPassProducts(activeOnly bool) (*[]models.PassProduct, error) {
+	var products []models.PassProduct
+
+	query := r.db.Order("price_amount, name")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	return &products, nil
+}
+
+// GetPassProductByID retrieves a pass product by its ID.
+//
+// Parameters:
+// - id: the ID of the product to retrieve.
+//
+// Returns:
+// - *models.PassProduct: a pointer to the product if found, or nil if not found.
+// - error: an error if there was a problem retrieving the product, or nil if successful.
+func (r *passRepositoryImp) GetPassProductByID(id string) (*models.PassProduct, error) {
+	var product models.PassProduct
+
+	if err := r.db.First(&product, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pass product not found")
+		}
+
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// UpdatePassProduct replaces the settings of a pass product. Passes already bought cover rides
+// with the new settings, and are renewed at the new price.
+//
+// Parameters:
+// - product: a pointer to a models.PassProduct object representing the product to be updated.
+//
+// Returns:
+// - error: an error if the product is not found or could not be updated.
+func (r *passRepositoryImp) UpdatePassProduct(product *models.PassProduct) error {
+	result := r.db.Model(&models.PassProduct{}).
+		Where("id = ?", product.ID).
+		Select("Name", "Description", "Type", "price_amount", "price_currency", "ValidityDays", "IncludedRides", "MinutesPerRide",
+			"IncludedMinutes", "WaiveUnlockFee", "MemberDiscountPercent", "Active").
+		Updates(product)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pass product not found")
+	}
+
+	return nil
+}
+
+// DeletePassProduct deletes a pass product. Passes already bought stay valid until they expire,
+// and are no longer renewed.
+//
+// Parameters:
+// - id: the ID of the product to be deleted.
+//
+// Returns:
+// - error: an error if the product is not found or could not be deleted.
+func (r *passRepositoryImp) DeletePassProduct(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.PassProduct{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pass product not found")
+	}
+
+	return nil
+}
+
+// CreatePass records a pass bought by a rider. A rider has at most one usable pass of each
+// product, so a ride bundle can only be bought again once its rides are used up. The rider row
+// is locked while checking it so concurrent purchases cannot both succeed.
+//
+// Parameters:
+// - pass: a pointer to a models.Pass object representing the pass to be created.
+//
+// Returns:
+// - error: an error if the rider already has a usable pass of the product, or the pass could
+// not be created.
+func (r *passRepositoryImp) CreatePass(pass *models.Pass) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", pass.UserID).Error; err != nil {
+			return err
+		}
+
+		var passes []models.Pass
+		err := tx.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Where("user_id = ? AND product_id = ? AND status = ? AND expires_at > ?", pass.UserID, pass.ProductID, models.PASS_STATUS_ACTIVE, pass.StartsAt).
+			Find(&passes).Error
+		if err != nil {
+			return err
+		}
+
+		for _, active := range passes {
+			if active.HasRidesLeft() {
+				return fmt.Errorf("you already have an active pass of this product")
+			}
+		}
+
+		return tx.Omit("Product").Create(pass).Error
+	})
+}
+
+// GetPassesByUserID retrieves the passes of a rider with their products, the most recent first.
+//
+// Parameters:
+// - userID: the ID of the rider.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Pass: a pointer to a slice of models.Pass.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *passRepositoryImp) GetPassesByUserID(userID string, pagination pkg.Pagination) (*[]models.Pass, *pkg.Pagination, error) {
+	var passes []models.Pass
+
+	query := r.db.Where("user_id = ?", userID).Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.Pass{}, &pagination, query)).
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("starts_at DESC").
+		Find(&passes).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &passes, &pagination, nil
+}
+
+// GetPassByID retrieves a pass with its product by its ID.
+//
+// Parameters:
+// - id: the ID of the pass to retrieve.
+//
+// Returns:
+// - *models.Pass: a pointer to the pass if found, or nil if not found.
+// - error: an error if there was a problem retrieving the pass, or nil if successful.
+func (r *passRepositoryImp) GetPassByID(id string) (*models.Pass, error) {
+	var pass models.Pass
+
+	err := r.db.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).First(&pass, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pass not found")
+		}
+
+		return nil, err
+	}
+
+	return &pass, nil
+}
+
+// CancelPass turns off the auto-renewal of an active pass. The pass stays usable until it
+// expires.
+//
+// Parameters:
+// - id: the ID of the pass to cancel.
+//
+// Returns:
+// - error: an error if the pass is not active, already cancelled, or could not be updated.
+func (r *passRepositoryImp) CancelPass(id string) error {
+	result := r.db.Model(&models.Pass{}).
+		Where("id = ? AND status = ? AND cancelled_at IS NULL", id, models.PASS_STATUS_ACTIVE).
+		Updates(map[string]interface{}{"auto_renew": false, "cancelled_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pass is not active or already cancelled")
+	}
+
+	return nil
+}
+
+// RenewPasses expires the active passes that reached their expiry date, and the ride bundles
+// that auto-renew and ran out of rides. The passes that auto-renew are bought again at the
+// current price of their product, starting now, unless the product is no longer on sale.
+//
+// Parameters:
+// - now: the current time.
+//
+// Returns:
+// - int64: the number of passes expired.
+// - error: an error if any.
+func (r *passRepositoryImp) RenewPasses(now time.Time) (int64, error) {
+	var passes []models.Pass
+
+	err := r.db.Joins("Product").
+		Where("passes.status = ?", models.PASS_STATUS_ACTIVE).
+		Where(r.db.Where("passes.expires_at <= ?", now).
+			Or(`passes.auto_renew AND "Product".included_rides > 0 AND passes.rides_used >= "Product".included_rides`)).
+		Find(&passes).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, pass := range passes {
+		expired := false
+
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.Pass{}).Where("id = ? AND status = ?", pass.ID, models.PASS_STATUS_ACTIVE).Update("status", models.PASS_STATUS_EXPIRED)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			expired = true
+
+			if !pass.AutoRenew || pass.Product == nil || !pass.Product.Active {
+				return nil
+			}
+
+			renewal := &models.Pass{
+				ID:        uuid.Must(uuid.NewRandom()),
+				UserID:    pass.UserID,
+				ProductID: pass.ProductID,
+				Status:    models.PASS_STATUS_ACTIVE,
+				Price:     pass.Product.Price,
+				StartsAt:  now,
+				ExpiresAt: now.AddDate(0, 0, pass.Product.ValidityDays),
+				AutoRenew: true,
+			}
+
+			if err := tx.Omit("Product").Create(renewal).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&models.Pass{}).Where("id = ?", pass.ID).Update("renewed_by_id", renewal.ID).Error
+		})
+		if err != nil {
+			return count, err
+		}
+
+		if expired {
+			count++
+		}
+	}
+
+	return count, nil
+}
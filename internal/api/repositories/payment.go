@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockPayment reads a payment with its row locked until the end of the transaction.
+func lockPayment(tx *gorm.DB, query string, args ...interface{}) (*models.Payment, error) {
+	var p models.Payment
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment not found")
+		}
+
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// recordPaymentResult locks a payment and applies the result of an operation of the payment
+// provider to it, as a single transaction.
+func recordPaymentResult(db *gorm.DB, id uuid.UUID, result *payment.Result) (*models.Payment, error) {
+	var p *models.Payment
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = lockPayment(tx, "id = ?", id); err != nil {
+			return err
+		}
+
+		return applyPaymentResult(tx, p, *result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// applyPaymentResult moves a payment to the status reported by the payment provider and stores
+// it. Only payments still pending or authorized move: a late or repeated result cannot undo a
+// capture, a void or a failure. When the payment is captured, the captured amount is credited to
+// the wallet of the rider.
+func applyPaymentResult(tx *gorm.DB, p *models.Payment, result payment.Result) error {
+	if p.GatewayReference == nil && result.PaymentID != "" {
+		reference := result.PaymentID
+		p.GatewayReference = &reference
+	}
+
+	open := p.Status == models.PAYMENT_STATUS_PENDING || p.Status == models.PAYMENT_STATUS_AUTHORIZED
+
+	switch result.Status {
+	case payment.StatusAuthorized:
+		if p.Status == models.PAYMENT_STATUS_PENDING {
+			p.Status = models.PAYMENT_STATUS_AUTHORIZED
+		}
+	case payment.StatusCaptured:
+		if open {
+			p.Status = models.PAYMENT_STATUS_CAPTURED
+			p.CapturedAmount = result.Amount
+		}
+	case payment.StatusVoided:
+		if open {
+			p.Status = models.PAYMENT_STATUS_VOIDED
+		}
+	case payment.StatusFailed:
+		if open {
+			p.Status = models.PAYMENT_STATUS_FAILED
+			p.FailureCode = result.FailureCode
+			p.FailureMessage = result.FailureMessage
+		}
+	}
+
+	if err := tx.Save(p).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("gateway reference already used by another payment")
+		}
+
+		return err
+	}
+
+	if p.Status == models.PAYMENT_STATUS_CAPTURED {
+		return postCardPayment(tx, p)
+	}
+
+	return nil
+}
+
+// postCardPayment credits the wallet of a rider with what was captured from their card, from
+// the funding account. A payment is posted once.
+func postCardPayment(tx *gorm.DB, p *models.Payment) error {
+	if p.CapturedAmount.IsZero() {
+		return nil
+	}
+
+	key := "card_payment:" + p.ID.String()
+
+	var count int64
+	if err := tx.Model(&models.LedgerTransaction{}).Where("idempotency_key = ?", key).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	entries, err := transferEntries(tx, models.LEDGER_ACCOUNT_FUNDING, nil, models.LEDGER_ACCOUNT_WALLET, &p.UserID, p.CapturedAmount)
+	if err != nil {
+		return err
+	}
+
+	return postLedgerTransaction(tx, &models.LedgerTransaction{
+		ID:             uuid.Must(uuid.NewRandom()),
+		Type:           models.LEDGER_TRANSACTION_CARD_PAYMENT,
+		Description:    "Card payment",
+		RentalID:       p.RentalID,
+		PaymentID:      &p.ID,
+		IdempotencyKey: &key,
+	}, entries)
+}
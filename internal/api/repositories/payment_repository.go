@@ -0,0 +1,379 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	CreatePaymentMethod(method *models.PaymentMethod) error
+	GetPaymentMethodsByUserID(userID string) (*[]models.PaymentMethod, error)
+	GetPaymentMethodByID(id string) (*models.PaymentMethod, error)
+	SetDefaultPaymentMethod(method *models.PaymentMethod) error
+	DeletePaymentMethod(method *models.PaymentMethod) error
+	GetPayments(userID string, rentalID string, pagination pkg.Pagination) (*[]models.Payment, *pkg.Pagination, error)
+	GetPaymentByID(id string) (*models.Payment, error)
+	RecordPaymentResult(id uuid.UUID, result *payment.Result) (*models.Payment, error)
+	CheckPaymentRefund(id uuid.UUID, amount money.Money) error
+	RecordPaymentRefund(id uuid.UUID, result *payment.Result, reason string, createdByID uuid.UUID) (*models.Payment, error)
+	ApplyPaymentEvent(event *payment.Event, payload []byte) error
+}
+
+type paymentRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewPaymentRepository creates a new payment repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - PaymentRepository: an implementation of the PaymentRepository interface.
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepositoryImp{
+		db: db,
+	}
+}
+
+// CreatePaymentMethod stores a card on file. The first card of a rider becomes their default
+// payment method.
+//
+// Parameters:
+// - method: a pointer to a models.PaymentMethod object representing the card.
+//
+// Returns:
+// - error: an error if the card could not be stored.
+func (r *paymentRepositoryImp) CreatePaymentMethod(method *models.PaymentMethod) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, "id = ?", method.UserID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.PaymentMethod{}).Where("user_id = ?", method.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		method.IsDefault = count == 0
+
+		return tx.Create(method).Error
+	})
+}
+
+// GetPaymentMethodsByUserID retrieves the cards on file of a rider, the default one first.
+//
+// Parameters:
+// - userID: the ID of the rider.
+//
+// Returns:
+// - *[]models.PaymentMethod: a pointer to a slice of models.PaymentMethod.
+// - error: an error if any.
+func (r *paymentRepositoryImp) GetPaymentMethodsByUserID(userID string) (*[]models.PaymentMethod, error) {
+	var methods []models.PaymentMethod
+
+	if err := r.db.Where("user_id = ?", userID).Order("is_default DESC, created_at DESC").Find(&methods).Error; err != nil {
+		return nil, err
+	}
+
+	return &methods, nil
+}
+
+// GetPaymentMethodByID retrieves a card on file.
+//
+// Parameters:
+// - id: the ID of the payment method.
+//
+// Returns:
+// - *models.PaymentMethod: a pointer to the payment method if found.
+// - error: an error if the payment method is not found or could not be retrieved.
+func (r *paymentRepositoryImp) GetPaymentMethodByID(id string) (*models.PaymentMethod, error) {
+	var method models.PaymentMethod
+
+	if err := r.db.First(&method, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment method not found")
+		}
+
+		return nil, err
+	}
+
+	return &method, nil
+}
+
+// SetDefaultPaymentMethod makes a card the default payment method of its rider.
+//
+// Parameters:
+// - method: a pointer to a models.PaymentMethod object representing the card.
+//
+// Returns:
+// - error: an error if the payment methods could not be updated.
+func (r *paymentRepositoryImp) SetDefaultPaymentMethod(method *models.PaymentMethod) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PaymentMethod{}).
+			Where("user_id = ? AND id <> ?", method.UserID, method.ID).
+			Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+
+		method.IsDefault = true
+
+		return tx.Model(method).Update("is_default", true).Error
+	})
+}
+
+// DeletePaymentMethod removes a card on file. When it was the default payment method, the most
+// recent remaining card becomes the default.
+//
+// Parameters:
+// - method: a pointer to a models.PaymentMethod object representing the card.
+//
+// Returns:
+// - error: an error if the payment methods could not be updated.
+func (r *paymentRepositoryImp) DeletePaymentMethod(method *models.PaymentMethod) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(method).Error; err != nil {
+			return err
+		}
+
+		if !method.IsDefault {
+			return nil
+		}
+
+		var next models.PaymentMethod
+		err := tx.Where("user_id = ?", method.UserID).Order("created_at DESC").First(&next).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+// GetPayments retrieves payments, the most recent first, optionally only those of a rider or a
+// rental.
+//
+// Parameters:
+// - userID: the ID of the rider, or an empty string.
+// - rentalID: the ID of the rental, or an empty string.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Payment: a pointer to a slice of models.Payment.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *paymentRepositoryImp) GetPayments(userID string, rentalID string, pagination pkg.Pagination) (*[]models.Payment, *pkg.Pagination, error) {
+	var payments []models.Payment
+
+	query := r.db
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if rentalID != "" {
+		query = query.Where("rental_id = ?", rentalID)
+	}
+
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Scopes(pkg.Paginate(&models.Payment{}, &pagination, query)).Order("created_at DESC").Find(&payments).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &payments, &pagination, nil
+}
+
+// GetPaymentByID retrieves a payment.
+//
+// Parameters:
+// - id: the ID of the payment.
+//
+// Returns:
+// - *models.Payment: a pointer to the payment if found.
+// - error: an error if the payment is not found or could not be retrieved.
+func (r *paymentRepositoryImp) GetPaymentByID(id string) (*models.Payment, error) {
+	var p models.Payment
+
+	if err := r.db.First(&p, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment not found")
+		}
+
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// RecordPaymentResult applies the result of an operation of the payment provider to a payment.
+//
+// Parameters:
+// - id: the ID of the payment.
+// - result: the result reported by the provider.
+//
+// Returns:
+// - *models.Payment: a pointer to the updated payment.
+// - error: an error if the payment is not found or could not be updated.
+func (r *paymentRepositoryImp) RecordPaymentResult(id uuid.UUID, result *payment.Result) (*models.Payment, error) {
+	return recordPaymentResult(r.db, id, result)
+}
+
+// CheckPaymentRefund checks that an amount can be refunded from a payment: it must not exceed
+// what is left of the captured amount, nor what is left of the charge of its rental.
+//
+// Parameters:
+// - id: the ID of the payment.
+// - amount: the amount to refund.
+//
+// Returns:
+// - error: an error if the amount cannot be refunded.
+func (r *paymentRepositoryImp) CheckPaymentRefund(id uuid.UUID, amount money.Money) error {
+	p, err := r.GetPaymentByID(id.String())
+	if err != nil {
+		return err
+	}
+
+	return checkPaymentRefund(r.db, p, amount)
+}
+
+// RecordPaymentRefund records a refund made by the payment provider. The refunded amount is
+// taken back from the revenue and returned to the card through the wallet of the rider.
+//
+// Parameters:
+// - id: the ID of the payment.
+// - result: the result of the refund reported by the provider.
+// - reason: why the payment is refunded.
+// - createdByID: the ID of the admin making the refund.
+//
+// Returns:
+// - *models.Payment: a pointer to the updated payment.
+// - error: an error if the payment is not found or could not be updated.
+func (r *paymentRepositoryImp) RecordPaymentRefund(id uuid.UUID, result *payment.Result, reason string, createdByID uuid.UUID) (*models.Payment, error) {
+	var p *models.Payment
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = lockPayment(tx, "id = ?", id); err != nil {
+			return err
+		}
+
+		p.RefundedAmount = p.RefundedAmount.Add(result.Amount)
+		if p.RefundedAmount.Cmp(p.CapturedAmount) >= 0 {
+			p.Status = models.PAYMENT_STATUS_REFUNDED
+		}
+
+		if err := tx.Save(p).Error; err != nil {
+			return err
+		}
+
+		refund, err := transferEntries(tx, models.LEDGER_ACCOUNT_REVENUE, nil, models.LEDGER_ACCOUNT_WALLET, &p.UserID, result.Amount)
+		if err != nil {
+			return err
+		}
+
+		payout, err := transferEntries(tx, models.LEDGER_ACCOUNT_WALLET, &p.UserID, models.LEDGER_ACCOUNT_FUNDING, nil, result.Amount)
+		if err != nil {
+			return err
+		}
+
+		return postLedgerTransaction(tx, &models.LedgerTransaction{
+			ID:          uuid.Must(uuid.NewRandom()),
+			Type:        models.LEDGER_TRANSACTION_CARD_REFUND,
+			Description: "Card refund",
+			Reason:      reason,
+			RentalID:    p.RentalID,
+			PaymentID:   &p.ID,
+			CreatedByID: &createdByID,
+		}, append(refund, payout...))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// ApplyPaymentEvent applies a webhook event of the payment provider to its payment. The event is
+// stored, so an event delivered again is ignored.
+//
+// Parameters:
+// - event: the event reported by the provider.
+// - payload: the raw payload of the webhook.
+//
+// Returns:
+// - error: an error if the payment of the event is not found or could not be updated.
+func (r *paymentRepositoryImp) ApplyPaymentEvent(event *payment.Event, payload []byte) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		p, err := lockPayment(tx, "gateway_reference = ?", event.PaymentID)
+		if err != nil {
+			return err
+		}
+
+		stored := &models.PaymentEvent{
+			ID:        event.ID,
+			PaymentID: &p.ID,
+			Status:    models.PaymentStatusEnum(event.Status),
+			Payload:   string(payload),
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(stored)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return applyPaymentResult(tx, p, payment.Result{
+			PaymentID:      event.PaymentID,
+			Status:         event.Status,
+			Amount:         event.Amount,
+			FailureCode:    event.FailureCode,
+			FailureMessage: event.FailureMessage,
+		})
+	})
+}
+
+// checkPaymentRefund checks that an amount can be refunded from a payment.
+func checkPaymentRefund(tx *gorm.DB, p *models.Payment, amount money.Money) error {
+	if p.Status != models.PAYMENT_STATUS_CAPTURED {
+		return fmt.Errorf("only captured payments can be refunded")
+	}
+
+	left := p.CapturedAmount.Sub(p.RefundedAmount)
+	if amount.Currency != left.Currency || amount.Cmp(left) > 0 {
+		return fmt.Errorf("refund exceeds the amount left to refund of %s", left)
+	}
+
+	if p.RentalID == nil {
+		return nil
+	}
+
+	var rental models.Rental
+	if err := tx.First(&rental, "id = ?", p.RentalID).Error; err != nil {
+		return err
+	}
+
+	charged, err := rentalNetCharge(tx, rental)
+	if err != nil {
+		return err
+	}
+
+	if amount.Cmp(charged) > 0 {
+		return fmt.Errorf("refund exceeds the amount left to refund of %s", charged)
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PenaltyRepository interface {
+	GetPenalties(userID string, status string, pagination pkg.Pagination) (*[]models.Penalty, *pkg.Pagination, error)
+	GetPenaltyByID(id string) (*models.Penalty, error)
+	PayPenalty(id string) (*models.Penalty, error)
+	WaivePenalty(id string, reason string, waivedByID uuid.UUID) (*models.Penalty, error)
+}
+
+type penaltyRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewPenaltyRepository creates a new penalty repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - PenaltyRepository: an implementation of the PenaltyRepository interface.
+func NewPenaltyRepository(db *gorm.DB) PenaltyRepository {
+	return &penaltyRepositoryImp{
+		db: db,
+	}
+}
+
+// GetPenalties retrieves penalties, the most recent first, optionally only those of a rider or
+// with a status.
+//
+// Parameters:
+// - userID: the ID of the rider, or an empty string.
+// - status: the status of the penalties, or an empty string.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Penalty: a pointer to a slice of models.Penalty.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *penaltyRepositoryImp) GetPenalties(userID string, status string, pagination pkg.Pagination) (*[]models.Penalty, *pkg.Pagination, error) {
+	var penalties []models.Penalty
+
+	query := r.db
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query = query.Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.Penalty{}, &pagination, query)).
+		Order("created_at DESC, id").
+		Find(&penalties).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &penalties, &pagination, nil
+}
+
+// GetPenaltyByID retrieves a penalty by its ID.
+//
+// Parameters:
+// - id: the ID of the penalty to retrieve.
+//
+// Returns:
+// - *models.Penalty: a pointer to the penalty if found.
+// - error: an error if the penalty is not found or could not be retrieved.
+func (r *penaltyRepositoryImp) GetPenaltyByID(id string) (*models.Penalty, error) {
+	var penalty models.Penalty
+
+	if err := r.db.First(&penalty, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("penalty not found")
+		}
+
+		return nil, err
+	}
+
+	return &penalty, nil
+}
+
+// PayPenalty pays an unpaid penalty from the wallet of its rider.
+//
+// Parameters:
+// - id: the ID of the penalty.
+//
+// Returns:
+// - *models.Penalty: a pointer to the paid penalty.
+// - error: an error if the penalty is not found or not unpaid, the wallet balance does not
+// cover it, or the payment could not be posted.
+func (r *penaltyRepositoryImp) PayPenalty(id string) (*models.Penalty, error) {
+	var penalty *models.Penalty
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		penalty, err = settlePenalty(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return penalty, nil
+}
+
+// WaivePenalty cancels an unpaid penalty, which no longer blocks its rider.
+//
+// Parameters:
+// - id: the ID of the penalty.
+// - reason: why the penalty is waived.
+// - waivedByID: the ID of the admin waiving the penalty.
+//
+// Returns:
+// - *models.Penalty: a pointer to the waived penalty.
+// - error: an error if the penalty is not found or not unpaid, or could not be updated.
+func (r *penaltyRepositoryImp) WaivePenalty(id string, reason string, waivedByID uuid.UUID) (*models.Penalty, error) {
+	result := r.db.Model(&models.Penalty{}).
+		Where("id = ? AND status = ?", id, models.PENALTY_STATUS_UNPAID).
+		Updates(map[string]interface{}{
+			"status":       models.PENALTY_STATUS_WAIVED,
+			"waive_reason": reason,
+			"waived_by_id": waivedByID,
+			"settled_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	penalty, err := r.GetPenaltyByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("penalty is not unpaid")
+	}
+
+	return penalty, nil
+}
+
+// settlePenalty pays an unpaid penalty from the wallet of its rider, in favor of the revenue
+// account. The wallet account is locked, so concurrent payments cannot spend the same balance.
+func settlePenalty(tx *gorm.DB, id string) (*models.Penalty, error) {
+	var penalty models.Penalty
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&penalty, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("penalty not found")
+		}
+
+		return nil, err
+	}
+
+	if penalty.Status != models.PENALTY_STATUS_UNPAID {
+		return nil, fmt.Errorf("penalty is not unpaid")
+	}
+
+	balance, err := walletBalance(tx, penalty.UserID, penalty.Amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance.Cmp(penalty.Amount) < 0 {
+		return nil, fmt.Errorf("wallet balance of %s does not cover the penalty", balance)
+	}
+
+	entries, err := transferEntries(tx, models.LEDGER_ACCOUNT_WALLET, &penalty.UserID, models.LEDGER_ACCOUNT_REVENUE, nil, penalty.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "penalty:" + penalty.ID.String()
+	transaction := &models.LedgerTransaction{
+		ID:             uuid.Must(uuid.NewRandom()),
+		Type:           models.LEDGER_TRANSACTION_PENALTY,
+		Description:    penalty.Description,
+		RentalID:       &penalty.RentalID,
+		IdempotencyKey: &key,
+	}
+
+	if err := postLedgerTransaction(tx, transaction, entries); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	penalty.Status = models.PENALTY_STATUS_PAID
+	penalty.TransactionID = &transaction.ID
+	penalty.SettledAt = &now
+
+	err = tx.Model(&penalty).Updates(map[string]interface{}{
+		"status":         penalty.Status,
+		"transaction_id": penalty.TransactionID,
+		"settled_at":     penalty.SettledAt,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &penalty, nil
+}
+
+// walletBalance locks the wallet of a rider in a currency and returns its balance.
+func walletBalance(tx *gorm.DB, userID uuid.UUID, currency string) (money.Money, error) {
+	account, err := ledgerAccount(tx, models.LEDGER_ACCOUNT_WALLET, &userID, currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(account, "id = ?", account.ID).Error; err != nil {
+		return money.Money{}, err
+	}
+
+	var balance struct {
+		Amount int64
+	}
+
+	err = tx.Model(&models.LedgerEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount_amount ELSE -amount_amount END), 0) AS amount", models.LEDGER_ENTRY_CREDIT).
+		Where("account_id = ?", account.ID).
+		Scan(&balance).Error
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	return money.New(balance.Amount, currency), nil
+}
@@ -0,0 +1,387 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PricingPlanRepository interface {
+	CreatePricingPlan(plan *models.PricingPlan) error
+	GetAllPricingPlans(pagination pkg.Pagination) (*[]models.PricingPlan, *pkg.Pagination, error)
+	GetPricingPlanByID(id string) (*models.PricingPlan, error)
+	UpdatePricingPlan(plan *models.PricingPlan) error
+	DeletePricingPlan(id string) error
+	AssignPricingPlan(assignment *models.PricingPlanAssignment) error
+	DeletePricingPlanAssignment(id string) error
+	CreatePricingRule(rule *models.PricingRule) error
+	GetAllPricingRules() (*[]models.PricingRule, error)
+	GetPricingRuleByID(id string) (*models.PricingRule, error)
+	UpdatePricingRule(rule *models.PricingRule) error
+	DeletePricingRule(id string) error
+	CreateHoliday(holiday *models.Holiday) error
+	GetHolidays(from string, to string) (*[]models.Holiday, error)
+	DeleteHoliday(id string) error
+	GetBikeModelByID(id string) (*models.BikeModel, error)
+	GetStationByID(id string) (*models.Station, error)
+}
+
+type pricingPlanRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewPricingPlanRepository creates a new pricing plan repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - PricingPlanRepository: an implementation of the PricingPlanRepository interface.
+func NewPricingPlanRepository(db *gorm.DB) PricingPlanRepository {
+	return &pricingPlanRepositoryImp{
+		db: db,
+	}
+}
+
+// CreatePricingPlan creates a new pricing plan in the database.
+//
+// Parameters:
+// - plan: a pointer to a models.PricingPlan object representing the plan to be created.
+//
+// Returns:
+// - error: an error if there was a problem creating the plan, or nil if the plan was created successfully.
+func (r *pricingPlanRepositoryImp) CreatePricingPlan(plan *models.PricingPlan) error {
+	return r.db.Omit("Assignments").Create(plan).Error
+}
+
+// GetAllPricingPlans retrieves all pricing plans with their assignments.
+//
+// Parameters:
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.PricingPlan: a pointer to a slice of models.PricingPlan.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *pricingPlanRepositoryImp) GetAllPricingPlans(pagination pkg.Pagination) (*[]models.PricingPlan, *pkg.Pagination, error) {
+	var plans []models.PricingPlan
+
+	err := r.db.Scopes(pkg.Paginate(&models.PricingPlan{}, &pagination, r.db)).Preload("Assignments").Order("name").Find(&plans).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &plans, &pagination, nil
+}
+
+// GetPricingPlanByID retrieves a pricing plan with its assignments by its ID.
+//
+// Parameters:
+// - id: the ID of the plan to retrieve.
+//
+// Returns:
+// - *models.PricingPlan: a pointer to the plan if found, or nil if not found.
+// - error: an error if there was a problem retrieving the plan, or nil if successful.
+func (r *pricingPlanRepositoryImp) GetPricingPlanByID(id string) (*models.PricingPlan, error) {
+	var plan models.PricingPlan
+
+	if err := r.db.Preload("Assignments").First(&plan, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pricing plan not found")
+		}
+
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// UpdatePricingPlan replaces the settings of a pricing plan. Active rentals of the plan are
+// priced with the new settings when they are returned.
+//
+// Parameters:
+// - plan: a pointer to a models.PricingPlan object representing the plan to be updated.
+//
+// Returns:
+// - error: an error if the plan is not found or could not be updated.
+func (r *pricingPlanRepositoryImp) UpdatePricingPlan(plan *models.PricingPlan) error {
+	result := r.db.Model(&models.PricingPlan{}).
+		Where("id = ?", plan.ID).
+		Select("Name", "Description", "unlock_fee_amount", "unlock_fee_currency", "per_minute_rate_amount", "per_minute_rate_currency",
+			"BillingIncrementSeconds", "Rounding", "FreeMinutes", "daily_cap_amount", "daily_cap_currency", "minimum_charge_amount", "minimum_charge_currency",
+			"MaxDurationMinutes", "overtime_fee_amount", "overtime_fee_currency",
+			"paused_per_minute_rate_amount", "paused_per_minute_rate_currency", "MaxPauseMinutes").
+		Updates(plan)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pricing plan not found")
+	}
+
+	return nil
+}
+
+// DeletePricingPlan deletes a pricing plan and its assignments.
+//
+// Parameters:
+// - id: the ID of the plan to be deleted.
+//
+// Returns:
+// - error: an error if the plan is not found or could not be deleted.
+func (r *pricingPlanRepositoryImp) DeletePricingPlan(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pricing_plan_id = ?", id).Delete(&models.PricingPlanAssignment{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ?", id).Delete(&models.PricingPlan{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("pricing plan not found")
+		}
+
+		return nil
+	})
+}
+
+// AssignPricingPlan applies a plan to a scope, replacing the plan previously assigned to it.
+//
+// Parameters:
+// - assignment: a pointer to a models.PricingPlanAssignment object representing the assignment.
+//
+// Returns:
+// - error: an error if there was a problem storing the assignment.
+func (r *pricingPlanRepositoryImp) AssignPricingPlan(assignment *models.PricingPlanAssignment) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "scope_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pricing_plan_id", "created_at"}),
+	}).Create(assignment).Error
+}
+
+// DeletePricingPlanAssignment removes a plan assignment.
+//
+// Parameters:
+// - id: the ID of the assignment to remove.
+//
+// Returns:
+// - error: an error if the assignment is not found or could not be deleted.
+func (r *pricingPlanRepositoryImp) DeletePricingPlanAssignment(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.PricingPlanAssignment{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pricing plan assignment not found")
+	}
+
+	return nil
+}
+
+// CreatePricingRule creates a new pricing rule in the database.
+//
+// Parameters:
+// - rule: a pointer to a models.PricingRule object representing the rule to be created.
+//
+// Returns:
+// - error: an error if there was a problem creating the rule, or nil if the rule was created successfully.
+func (r *pricingPlanRepositoryImp) CreatePricingRule(rule *models.PricingRule) error {
+	return r.db.Create(rule).Error
+}
+
+// GetAllPricingRules retrieves every pricing rule, active or not.
+//
+// Returns:
+// - *[]models.PricingRule: a pointer to a slice of models.PricingRule.
+// - error: an error if any.
+func (r *pricingPlanRepositoryImp) GetAllPricingRules() (*[]models.PricingRule, error) {
+	var rules []models.PricingRule
+
+	if err := r.db.Order("type, name").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+// GetPricingRuleByID retrieves a pricing rule by its ID.
+//
+// Parameters:
+// - id: the ID of the rule to retrieve.
+//
+// Returns:
+// - *models.PricingRule: a pointer to the rule if found, or nil if not found.
+// - error: an error if there was a problem retrieving the rule, or nil if successful.
+func (r *pricingPlanRepositoryImp) GetPricingRuleByID(id string) (*models.PricingRule, error) {
+	var rule models.PricingRule
+
+	if err := r.db.First(&rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pricing rule not found")
+		}
+
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// UpdatePricingRule replaces the settings of a pricing rule.
+//
+// Parameters:
+// - rule: a pointer to a models.PricingRule object representing the rule to be updated.
+//
+// Returns:
+// - error: an error if the rule is not found or could not be updated.
+func (r *pricingPlanRepositoryImp) UpdatePricingRule(rule *models.PricingRule) error {
+	result := r.db.Model(&models.PricingRule{}).
+		Where("id = ?", rule.ID).
+		Select("Name", "Type", "Multiplier", "Weekdays", "StartHour", "EndHour", "MaxAvailableBikes", "Active").
+		Updates(rule)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pricing rule not found")
+	}
+
+	return nil
+}
+
+// DeletePricingRule deletes a pricing rule.
+//
+// Parameters:
+// - id: the ID of the rule to be deleted.
+//
+// Returns:
+// - error: an error if the rule is not found or could not be deleted.
+func (r *pricingPlanRepositoryImp) DeletePricingRule(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.PricingRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pricing rule not found")
+	}
+
+	return nil
+}
+
+// CreateHoliday adds a day to the holiday calendar.
+//
+// Parameters:
+// - holiday: a pointer to a models.Holiday object representing the day to be added.
+//
+// Returns:
+// - error: an error if the day is already in the calendar or could not be added.
+func (r *pricingPlanRepositoryImp) CreateHoliday(holiday *models.Holiday) error {
+	err := r.db.Create(holiday).Error
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("holiday already exists")
+	}
+
+	return err
+}
+
+// GetHolidays retrieves the holidays between two dates, both included. Empty bounds are open.
+//
+// Parameters:
+// - from: the first date, formatted as YYYY-MM-DD.
+// - to: the last date, formatted as YYYY-MM-DD.
+//
+// Returns:
+// - *[]models.Holiday: a pointer to a slice of models.Holiday ordered by date.
+// - error: an error if any.
+func (r *pricingPlanRepositoryImp) GetHolidays(from string, to string) (*[]models.Holiday, error) {
+	var holidays []models.Holiday
+
+	query := r.db.Order("date, country")
+	if from != "" {
+		query = query.Where("date >= ?", from)
+	}
+
+	if to != "" {
+		query = query.Where("date <= ?", to)
+	}
+
+	if err := query.Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+
+	return &holidays, nil
+}
+
+// DeleteHoliday removes a day from the holiday calendar.
+//
+// Parameters:
+// - id: the ID of the holiday to remove.
+//
+// Returns:
+// - error: an error if the holiday is not found or could not be deleted.
+func (r *pricingPlanRepositoryImp) DeleteHoliday(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.Holiday{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("holiday not found")
+	}
+
+	return nil
+}
+
+// GetBikeModelByID retrieves a catalog model from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the model to retrieve.
+//
+// Returns:
+// - *models.BikeModel: a pointer to the model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the model, or nil if successful.
+func (r *pricingPlanRepositoryImp) GetBikeModelByID(id string) (*models.BikeModel, error) {
+	var model models.BikeModel
+
+	if err := r.db.First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike model not found")
+		}
+
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+// GetStationByID retrieves a station from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the station to retrieve.
+//
+// Returns:
+// - *models.Station: a pointer to the station if found, or nil if not found.
+// - error: an error if there was a problem retrieving the station, or nil if successful.
+func (r *pricingPlanRepositoryImp) GetStationByID(id string) (*models.Station, error) {
+	var station models.Station
+
+	if err := r.db.First(&station, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("station not found")
+		}
+
+		return nil, err
+	}
+
+	return &station, nil
+}
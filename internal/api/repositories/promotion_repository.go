@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+)
+
+type PromotionRepository interface {
+	CreatePromotion(promotion *models.Promotion) error
+	GetAllPromotions(pagination pkg.Pagination) (*[]models.Promotion, *pkg.Pagination, error)
+	GetPromotionByID(id string) (*models.Promotion, error)
+	UpdatePromotion(promotion *models.Promotion) error
+	DeletePromotion(id string) error
+	GetPromotionRedemptions(id string, pagination pkg.Pagination) (*[]models.PromotionRedemption, *pkg.Pagination, error)
+	GetBikeModelByID(id string) (*models.BikeModel, error)
+}
+
+type promotionRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new promotion repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - PromotionRepository: an implementation of the PromotionRepository interface.
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepositoryImp{
+		db: db,
+	}
+}
+
+// CreatePromotion creates a new promotion in the database.
+//
+// Parameters:
+// - promotion: a pointer to a models.Promotion object representing the promotion to be created.
+//
+// Returns:
+// - error: an error if the code is already taken or the promotion could not be created.
+func (r *promotionRepositoryImp) CreatePromotion(promotion *models.Promotion) error {
+	err := r.db.Create(promotion).Error
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("promo code already exists")
+	}
+
+	return err
+}
+
+// GetAllPromotions retrieves all promotions, the most recent first.
+//
+// Parameters:
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Promotion: a pointer to a slice of models.Promotion.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *promotionRepositoryImp) GetAllPromotions(pagination pkg.Pagination) (*[]models.Promotion, *pkg.Pagination, error) {
+	var promotions []models.Promotion
+
+	err := r.db.Scopes(pkg.Paginate(&models.Promotion{}, &pagination, r.db)).Order("created_at DESC").Find(&promotions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &promotions, &pagination, nil
+}
+
+// GetPromotionByID retrieves a promotion by its ID.
+//
+// Parameters:
+// - id: the ID of the promotion to retrieve.
+//
+// Returns:
+// - *models.Promotion: a pointer to the promotion if found, or nil if not found.
+// - error: an error if there was a problem retrieving the promotion, or nil if successful.
+func (r *promotionRepositoryImp) GetPromotionByID(id string) (*models.Promotion, error) {
+	var promotion models.Promotion
+
+	if err := r.db.First(&promotion, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("promotion not found")
+		}
+
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+// UpdatePromotion replaces the settings of a promotion. Rentals already started with the
+// promotion keep the discount it had when they started.
+//
+// Parameters:
+// - promotion: a pointer to a models.Promotion object representing the promotion to be updated.
+//
+// Returns:
+// - error: an error if the promotion is not found, the code is already taken, or the promotion
+// could not be updated.
+func (r *promotionRepositoryImp) UpdatePromotion(promotion *models.Promotion) error {
+	result := r.db.Model(&models.Promotion{}).
+		Where("id = ?", promotion.ID).
+		Select("Code", "Description", "Type", "PercentOff", "amount_off_amount", "amount_off_currency", "FreeMinutes", "StartsAt", "EndsAt",
+			"MaxRedemptions", "MaxRedemptionsPerUser", "FirstRideOnly", "EligibleBikeModelIDs", "Active").
+		Updates(promotion)
+
+	if isDuplicateKeyError(result.Error) {
+		return fmt.Errorf("promo code already exists")
+	}
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("promotion not found")
+	}
+
+	return nil
+}
+
+// DeletePromotion deletes a promotion. Rentals already started with it still get the discount.
+//
+// Parameters:
+// - id: the ID of the promotion to be deleted.
+//
+// Returns:
+// - error: an error if the promotion is not found or could not be deleted.
+func (r *promotionRepositoryImp) DeletePromotion(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.Promotion{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("promotion not found")
+	}
+
+	return nil
+}
+
+// GetPromotionRedemptions retrieves the redemptions of a promotion, the most recent first.
+//
+// Parameters:
+// - id: the ID of the promotion.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.PromotionRedemption: a pointer to a slice of models.PromotionRedemption.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *promotionRepositoryImp) GetPromotionRedemptions(id string, pagination pkg.Pagination) (*[]models.PromotionRedemption, *pkg.Pagination, error) {
+	var redemptions []models.PromotionRedemption
+
+	query := r.db.Where("promotion_id = ?", id).Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.PromotionRedemption{}, &pagination, query)).Order("created_at DESC").Find(&redemptions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &redemptions, &pagination, nil
+}
+
+// GetBikeModelByID retrieves a bike model by its ID.
+//
+// Parameters:
+// - id: the ID of the bike model to retrieve.
+//
+// Returns:
+// - *models.BikeModel: a pointer to the bike model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the bike model, or nil if successful.
+func (r *promotionRepositoryImp) GetBikeModelByID(id string) (*models.BikeModel, error) {
+	var bikeModel models.BikeModel
+
+	if err := r.db.First(&bikeModel, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike model not found")
+		}
+
+		return nil, err
+	}
+
+	return &bikeModel, nil
+}
@@ -0,0 +1,409 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Document counters and the prefixes of their numbers.
+const (
+	receiptCounter = "receipt"
+	receiptPrefix  = "RCT"
+	invoiceCounter = "invoice"
+	invoicePrefix  = "INV"
+)
+
+type ReceiptRepository interface {
+	CreateReceipt(receipt *models.Receipt) error
+	GetReceiptByRentalID(rentalID string) (*models.Receipt, error)
+	MarkReceiptEmailed(id uuid.UUID) error
+	GetRentalByID(id string) (*models.Rental, error)
+	GetUserByID(id string) (*models.User, error)
+	GetBikeByID(id string) (*models.Bike, error)
+	GetRentalPayments(rentalID string) (*[]models.Payment, error)
+	GetPaymentMethodByID(id string) (*models.PaymentMethod, error)
+	GetCorporateAccountByID(id string) (*models.CorporateAccount, error)
+	GetCorporateAccounts() (*[]models.CorporateAccount, error)
+	GetUninvoicedReceipts(corporateAccountID string, start time.Time, end time.Time) (*[]models.Receipt, error)
+	CreateInvoice(invoice *models.Invoice, receiptIDs []uuid.UUID) error
+	GetInvoices(corporateAccountID string, pagination pkg.Pagination) (*[]models.Invoice, *pkg.Pagination, error)
+	GetInvoiceByID(id string) (*models.Invoice, error)
+	MarkInvoiceEmailed(id uuid.UUID) error
+}
+
+type receiptRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewReceiptRepository creates a new receipt repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - ReceiptRepository: an implementation of the ReceiptRepository interface.
+func NewReceiptRepository(db *gorm.DB) ReceiptRepository {
+	return &receiptRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateReceipt numbers a receipt and stores it, as a single transaction. The number is also
+// written on the document of the receipt.
+//
+// Parameters:
+// - receipt: a pointer to a models.Receipt object representing the receipt to be created.
+//
+// Returns:
+// - error: an error if the rental already has a receipt or the receipt could not be created.
+func (r *receiptRepositoryImp) CreateReceipt(receipt *models.Receipt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		number, err := nextDocumentNumber(tx, receiptCounter, receiptPrefix)
+		if err != nil {
+			return err
+		}
+
+		receipt.Number = number
+		receipt.Document.Number = number
+
+		err = tx.Create(receipt).Error
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("receipt already exists")
+		}
+
+		return err
+	})
+}
+
+// GetReceiptByRentalID retrieves the receipt of a rental.
+//
+// Parameters:
+// - rentalID: the ID of the rental.
+//
+// Returns:
+// - *models.Receipt: a pointer to the receipt if found.
+// - error: an error if the receipt is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetReceiptByRentalID(rentalID string) (*models.Receipt, error) {
+	var receipt models.Receipt
+
+	if err := r.db.First(&receipt, "rental_id = ?", rentalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("receipt not found")
+		}
+
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// MarkReceiptEmailed records that a receipt was sent to its rider.
+//
+// Parameters:
+// - id: the ID of the receipt.
+//
+// Returns:
+// - error: an error if the receipt could not be updated.
+func (r *receiptRepositoryImp) MarkReceiptEmailed(id uuid.UUID) error {
+	return r.db.Model(&models.Receipt{}).Where("id = ?", id).Update("emailed_at", time.Now()).Error
+}
+
+// GetRentalByID retrieves a rental from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the rental to retrieve.
+//
+// Returns:
+// - *models.Rental: a pointer to the rental if found.
+// - error: an error if the rental is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetRentalByID(id string) (*models.Rental, error) {
+	var rental models.Rental
+
+	if err := r.db.Preload("TaxLines").First(&rental, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rental not found")
+		}
+
+		return nil, err
+	}
+
+	return &rental, nil
+}
+
+// GetUserByID retrieves a user from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the user to retrieve.
+//
+// Returns:
+// - *models.User: a pointer to the user if found.
+// - error: an error if the user is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetUserByID(id string) (*models.User, error) {
+	var user models.User
+
+	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetBikeByID retrieves a bike with its model, including a bike deleted since it was rented.
+//
+// Parameters:
+// - id: the ID of the bike to retrieve.
+//
+// Returns:
+// - *models.Bike: a pointer to the bike if found.
+// - error: an error if the bike is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetBikeByID(id string) (*models.Bike, error) {
+	var bike models.Bike
+
+	if err := r.db.Unscoped().Preload("Model", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).First(&bike, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike not found")
+		}
+
+		return nil, err
+	}
+
+	return &bike, nil
+}
+
+// GetRentalPayments retrieves the payments of a rental, the oldest first.
+//
+// Parameters:
+// - rentalID: the ID of the rental.
+//
+// Returns:
+// - *[]models.Payment: a pointer to a slice of models.Payment.
+// - error: an error if the payments could not be retrieved.
+func (r *receiptRepositoryImp) GetRentalPayments(rentalID string) (*[]models.Payment, error) {
+	var payments []models.Payment
+
+	if err := r.db.Where("rental_id = ?", rentalID).Order("created_at").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	return &payments, nil
+}
+
+// GetPaymentMethodByID retrieves a card, including a card removed since it was used.
+//
+// Parameters:
+// - id: the ID of the payment method.
+//
+// Returns:
+// - *models.PaymentMethod: a pointer to the payment method if found.
+// - error: an error if the payment method is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetPaymentMethodByID(id string) (*models.PaymentMethod, error) {
+	var method models.PaymentMethod
+
+	if err := r.db.Unscoped().First(&method, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment method not found")
+		}
+
+		return nil, err
+	}
+
+	return &method, nil
+}
+
+// GetCorporateAccountByID retrieves a corporate account, including an account deleted since.
+//
+// Parameters:
+// - id: the ID of the corporate account.
+//
+// Returns:
+// - *models.CorporateAccount: a pointer to the corporate account if found.
+// - error: an error if the corporate account is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetCorporateAccountByID(id string) (*models.CorporateAccount, error) {
+	var account models.CorporateAccount
+
+	if err := r.db.Unscoped().First(&account, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("corporate account not found")
+		}
+
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// GetCorporateAccounts retrieves every corporate account, including the accounts deleted since
+// their last invoice.
+//
+// Returns:
+// - *[]models.CorporateAccount: a pointer to a slice of models.CorporateAccount.
+// - error: an error if the corporate accounts could not be retrieved.
+func (r *receiptRepositoryImp) GetCorporateAccounts() (*[]models.CorporateAccount, error) {
+	var accounts []models.CorporateAccount
+
+	if err := r.db.Unscoped().Order("name").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	return &accounts, nil
+}
+
+// GetUninvoicedReceipts retrieves the receipts billed to a corporate account, issued in a period
+// and not invoiced yet, the oldest first.
+//
+// Parameters:
+// - corporateAccountID: the ID of the corporate account.
+// - start: the start of the period.
+// - end: the end of the period, excluded.
+//
+// Returns:
+// - *[]models.Receipt: a pointer to a slice of models.Receipt.
+// - error: an error if the receipts could not be retrieved.
+func (r *receiptRepositoryImp) GetUninvoicedReceipts(corporateAccountID string, start time.Time, end time.Time) (*[]models.Receipt, error) {
+	var receipts []models.Receipt
+
+	err := r.db.
+		Where("corporate_account_id = ? AND invoice_id IS NULL AND issued_at >= ? AND issued_at < ?", corporateAccountID, start, end).
+		Order("issued_at").
+		Find(&receipts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &receipts, nil
+}
+
+// CreateInvoice numbers an invoice, stores it and attaches its receipts to it, as a single
+// transaction. The number is also written on the document of the invoice. A receipt is only
+// invoiced once.
+//
+// Parameters:
+// - invoice: a pointer to a models.Invoice object representing the invoice to be created.
+// - receiptIDs: the IDs of the receipts the invoice bills.
+//
+// Returns:
+// - error: an error if the account was already invoiced for the period, a receipt was already
+// invoiced, or the invoice could not be created.
+func (r *receiptRepositoryImp) CreateInvoice(invoice *models.Invoice, receiptIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		number, err := nextDocumentNumber(tx, invoiceCounter, invoicePrefix)
+		if err != nil {
+			return err
+		}
+
+		invoice.Number = number
+		invoice.Document.Number = number
+
+		if err := tx.Create(invoice).Error; err != nil {
+			if isDuplicateKeyError(err) {
+				return fmt.Errorf("invoice already exists")
+			}
+
+			return err
+		}
+
+		result := tx.Model(&models.Receipt{}).
+			Where("id IN ? AND invoice_id IS NULL", receiptIDs).
+			Update("invoice_id", invoice.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected != int64(len(receiptIDs)) {
+			return fmt.Errorf("receipt already invoiced")
+		}
+
+		return nil
+	})
+}
+
+// GetInvoices retrieves the invoices, of a corporate account when corporateAccountID is not
+// empty, the most recent first.
+//
+// Parameters:
+// - corporateAccountID: the ID of the corporate account, or an empty string.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Invoice: a pointer to a slice of models.Invoice.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *receiptRepositoryImp) GetInvoices(corporateAccountID string, pagination pkg.Pagination) (*[]models.Invoice, *pkg.Pagination, error) {
+	var invoices []models.Invoice
+
+	query := r.db
+	if corporateAccountID != "" {
+		query = query.Where("corporate_account_id = ?", corporateAccountID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.Invoice{}, &pagination, query)).Order("period_start DESC, number DESC").Find(&invoices).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &invoices, &pagination, nil
+}
+
+// GetInvoiceByID retrieves an invoice.
+//
+// Parameters:
+// - id: the ID of the invoice.
+//
+// Returns:
+// - *models.Invoice: a pointer to the invoice if found.
+// - error: an error if the invoice is not found or could not be retrieved.
+func (r *receiptRepositoryImp) GetInvoiceByID(id string) (*models.Invoice, error) {
+	var invoice models.Invoice
+
+	if err := r.db.First(&invoice, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("invoice not found")
+		}
+
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// MarkInvoiceEmailed records that an invoice was sent to its corporate account.
+//
+// Parameters:
+// - id: the ID of the invoice.
+//
+// Returns:
+// - error: an error if the invoice could not be updated.
+func (r *receiptRepositoryImp) MarkInvoiceEmailed(id uuid.UUID) error {
+	return r.db.Model(&models.Invoice{}).Where("id = ?", id).Update("emailed_at", time.Now()).Error
+}
+
+// nextDocumentNumber takes the next number of a kind of document, such as "RCT-000042". The row
+// of the counter stays locked until the end of the transaction, so documents are numbered in the
+// order they are stored and a rolled back transaction gives its number back.
+func nextDocumentNumber(tx *gorm.DB, name string, prefix string) (string, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.DocumentCounter{Name: name}).Error; err != nil {
+		return "", err
+	}
+
+	var counter models.DocumentCounter
+
+	err := tx.Model(&counter).
+		Clauses(clause.Returning{}).
+		Where("name = ?", name).
+		Update("value", gorm.Expr("value + 1")).Error
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%06d", prefix, counter.Value), nil
+}
@@ -3,20 +3,66 @@ package repositories
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
 	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/geo"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RentalRepository interface {
-	CreateRental(rental *models.Rental) error
+	CreateRental(rental *models.Rental, nonce *models.ScanNonce, reservationBuffer time.Duration, maxActiveRentals int) error
 	GetBikeByID(id string) (*models.Bike, error)
+	GetBikeByCode(code string) (*models.Bike, error)
 	GetAllRentals(pagination pkg.Pagination) (*[]models.Rental, *pkg.Pagination, error)
 	GetRentalByUserID(id string) (*[]models.Rental, error)
 	GetRentalByID(id string) (*models.Rental, error)
 	UpdateBikeStatus(bikeID string, status models.BikeStatusEnum) error
-	UpdateRental(rental *models.Rental) error
+	UpdateBikePosition(bikeID string, latitude, longitude float64) error
+	GetParkingZones() (*[]models.Zone, error)
+	GetPricingPlanForBike(bike *models.Bike) (*models.PricingPlan, error)
+	GetPricingPlanByID(id string) (*models.PricingPlan, error)
+	GetActivePricingRules() (*[]models.PricingRule, error)
+	GetPromotionByCode(code string) (*models.Promotion, error)
+	GetPromotionByID(id string) (*models.Promotion, error)
+	CheckPromotionRedemption(promotion *models.Promotion, userID uuid.UUID) error
+	GetPassesForRide(userID string, startTime time.Time) (*[]models.Pass, error)
+	GetPassUsage(rentalID string) (*models.PassUsage, error)
+	GetPassByID(id string) (*models.Pass, error)
+	IsHoliday(date string, country string) (bool, error)
+	GetTaxRatesInForce(country string, city string, at time.Time) (*[]models.TaxRate, error)
+	GetStationByID(id string) (*models.Station, error)
+	GetNearestStation(latitude, longitude, radiusMeters float64) (*models.Station, error)
+	CountAvailableBikesAtStation(stationID string, excludeBikeID string) (int, error)
+	UpdateBikeStation(bikeID string, stationID *uuid.UUID) error
+	CreateTripPoints(points []models.TripPoint) error
+	GetTripPoints(rentalID string) (*[]models.TripPoint, error)
+	CompleteRental(rental *models.Rental, passUsage *models.PassUsage, promotionDiscount money.Money) error
+	GetDefaultPaymentMethod(userID string) (*models.PaymentMethod, error)
+	CreatePayment(payment *models.Payment) error
+	RecordPaymentResult(id uuid.UUID, result *payment.Result) (*models.Payment, error)
+	GetPaymentByID(id string) (*models.Payment, error)
+	ReplaceRentalHold(rentalID uuid.UUID, currentID *uuid.UUID, holdID uuid.UUID) error
+	GetRentalsWithExpiringHolds(before time.Time) (*[]models.Rental, error)
+	CancelRental(rental *models.Rental) error
+	PauseRental(id uuid.UUID, at time.Time, maxPause time.Duration) (*models.Rental, error)
+	ResumeRental(id uuid.UUID, at time.Time) (*models.Rental, error)
+	GetRentalsDueForOvertime(now time.Time, defaultMaxDuration time.Duration, warning time.Duration, abandonedAfter time.Duration) (*[]models.Rental, error)
+	GetRentalsByOvertimeStage(stage models.RentalOvertimeStageEnum, pagination pkg.Pagination) (*[]models.Rental, *pkg.Pagination, error)
+	AdvanceOvertimeStage(id uuid.UUID, from models.RentalOvertimeStageEnum, to models.RentalOvertimeStageEnum, at time.Time) (bool, error)
+	GetUserByID(id string) (*models.User, error)
+	CountUnpaidPenalties(userID string) (int64, error)
+	CountActiveRentals(userID string) (int64, error)
+	GetWalletBalances(userID string) ([]money.Money, error)
+	SettlePenalty(id string) (*models.Penalty, error)
+	CreateLockCommand(command *models.LockCommand) error
+	UpdateLockCommand(command *models.LockCommand) error
 }
 
 type rentalRepositoryImp struct {
@@ -35,27 +81,171 @@ func NewRentalRepository(db *gorm.DB) RentalRepository {
 	}
 }
 
-// CreateRental creates a new rental in the database.
+// CreateRental books a bike for a rider and creates the rental, as a single transaction.
 //
-// It takes a pointer to a models.Rental struct as a parameter, which represents the rental to be created.
-// The function returns an error if there was an issue creating the rental or updating the bike's status.
-func (r *rentalRepositoryImp) CreateRental(rental *models.Rental) error {
-	tx := r.db.Begin()
-	if tx.Error != nil {
-		return tx.Error
-	}
+// The bike row is locked with SELECT ... FOR UPDATE before any check, so concurrent requests
+// for the same bike are serialized: the first one books the bike and the others see it booked.
+// A bike can be rented when it is available, or held by the rider, in which case the hold is
+// converted. A pending reservation of the bike starting within reservationBuffer blocks other
+// riders, and is fulfilled when its own rider rents the bike. When the rental has a promotion,
+// its row is locked too so that its redemption limits hold under concurrent rentals, and the
+// redemption is recorded. The row of the rider is locked first, so concurrent rentals of the
+// same rider cannot go over maxActiveRentals. The nonce of the scanned QR payload, when there is
+// one, is used up with the rental, so a payload is only burned by a rental that was created.
+// Expired nonces are purged on the way since their payloads are rejected anyway.
+//
+// Parameters:
+// - rental: a pointer to a models.Rental object representing the rental to be created.
+// - nonce: the nonce of the scanned QR payload, or nil when the bike was not rented by QR code.
+// - reservationBuffer: how long before a reservation starts the bike is kept for its rider.
+// - maxActiveRentals: how many rentals the rider can have in progress at once.
+//
+// Returns:
+// - error: an error if the rider has too many active rentals, the bike is not found, cannot be
+// rented by the rider, the promo code cannot be redeemed, the QR code was already used, or the
+// rental could not be created.
+func (r *rentalRepositoryImp) CreateRental(rental *models.Rental, nonce *models.ScanNonce, reservationBuffer time.Duration, maxActiveRentals int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
 
-	if err := tx.Create(rental).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, "id = ?", rental.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("user not found")
+			}
 
-	if err := tx.Model(&models.Bike{}).Where("id = ?", rental.BikeID).Update("status", models.BIKE_STATUS_BOOKED).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&models.Rental{}).Where("user_id = ? AND status IN ?", rental.UserID, models.RentalInProgressStatuses).Count(&active).Error; err != nil {
+			return err
+		}
+
+		if active >= int64(maxActiveRentals) {
+			return fmt.Errorf("too many active rentals")
+		}
+
+		var bike models.Bike
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bike, "id = ?", rental.BikeID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("bike not found")
+			}
+
+			return err
+		}
+
+		switch bike.Status {
+		case models.BIKE_STATUS_AVAILABLE:
+		case models.BIKE_STATUS_HELD:
+			var hold models.BikeHold
+			if err := tx.First(&hold, "bike_id = ? AND status = ?", bike.ID, models.BIKE_HOLD_STATUS_ACTIVE).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("bike is not available to rent")
+				}
+
+				return err
+			}
+
+			if hold.UserID != rental.UserID {
+				return fmt.Errorf("bike is held by another rider")
+			}
+
+			if !hold.ExpiresAt.After(now) {
+				return fmt.Errorf("your hold on this bike has expired")
+			}
+
+			err := tx.Model(&hold).Updates(map[string]interface{}{"status": models.BIKE_HOLD_STATUS_CONVERTED, "ended_at": now}).Error
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("bike is not available to rent")
+		}
+
+		var reservations []models.Reservation
+		err := tx.Where("bike_id = ? AND status = ? AND start_time < ?", bike.ID, models.RESERVATION_STATUS_PENDING, now.Add(reservationBuffer)).
+			Order("start_time").
+			Limit(1).
+			Find(&reservations).Error
+		if err != nil {
+			return err
+		}
+
+		if len(reservations) > 0 && reservations[0].UserID != rental.UserID {
+			return fmt.Errorf("bike is reserved by another rider")
+		}
+
+		if rental.PromotionID != nil {
+			var promotion models.Promotion
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, "id = ?", rental.PromotionID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("promo code not found")
+				}
+
+				return err
+			}
+
+			if err := checkPromotionRedemption(tx, &promotion, rental.UserID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(rental).Error; err != nil {
+			return err
+		}
+
+		if nonce != nil {
+			if err := tx.Where("expires_at < ?", now).Delete(&models.ScanNonce{}).Error; err != nil {
+				return err
+			}
+
+			nonce.RentalID = &rental.ID
+			if err := tx.Create(nonce).Error; err != nil {
+				if isDuplicateKeyError(err) {
+					return fmt.Errorf("qr code already used")
+				}
+
+				return err
+			}
+		}
+
+		if rental.PromotionID != nil {
+			redemption := &models.PromotionRedemption{
+				ID:          uuid.Must(uuid.NewRandom()),
+				PromotionID: *rental.PromotionID,
+				UserID:      rental.UserID,
+				RentalID:    rental.ID,
+				Status:      models.PROMOTION_REDEMPTION_STATUS_PENDING,
+			}
+
+			if err := tx.Create(redemption).Error; err != nil {
+				return err
+			}
+		}
 
-	return tx.Commit().Error
+		if len(reservations) > 0 {
+			err := tx.Model(&reservations[0]).Updates(map[string]interface{}{
+				"status":    models.RESERVATION_STATUS_FULFILLED,
+				"rental_id": rental.ID,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.Bike{}).
+			Where("id = ? AND status IN ?", bike.ID, []models.BikeStatusEnum{models.BIKE_STATUS_AVAILABLE, models.BIKE_STATUS_HELD}).
+			Update("status", models.BIKE_STATUS_BOOKED)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("bike is not available to rent")
+		}
+
+		return nil
+	})
 }
 
 // GetBikeByID retrieves a bike from the database by its ID.
@@ -69,7 +259,28 @@ func (r *rentalRepositoryImp) CreateRental(rental *models.Rental) error {
 func (r *rentalRepositoryImp) GetBikeByID(id string) (*models.Bike, error) {
 	var bike models.Bike
 
-	if err := r.db.Where("id = ?", id).First(&bike).Error; err != nil {
+	if err := r.db.Preload("Model").Where("id = ?", id).First(&bike).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bike not found")
+		}
+		return nil, err
+	}
+
+	return &bike, nil
+}
+
+// GetBikeByCode retrieves a bike from the database by its short code.
+//
+// Parameters:
+// - code: the code of the bike to retrieve.
+//
+// Returns:
+// - *models.Bike: a pointer to the bike model if found, or nil if not found.
+// - error: an error if there was a problem retrieving the bike, or nil if successful.
+func (r *rentalRepositoryImp) GetBikeByCode(code string) (*models.Bike, error) {
+	var bike models.Bike
+
+	if err := r.db.Preload("Model").Where("code = ?", code).First(&bike).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("bike not found")
 		}
@@ -121,7 +332,7 @@ func (r *rentalRepositoryImp) GetRentalByUserID(id string) (*[]models.Rental, er
 // - error: an error if there was a problem retrieving the rental, or nil if successful.
 func (r *rentalRepositoryImp) GetRentalByID(id string) (*models.Rental, error) {
 	var rental models.Rental
-	if err := r.db.Where("id = ?", id).First(&rental).Error; err != nil {
+	if err := r.db.Preload("TaxLines").Preload("Segments", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).Where("id = ?", id).First(&rental).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("rental not found")
 		}
@@ -142,10 +353,1059 @@ func (r *rentalRepositoryImp) UpdateBikeStatus(bikeID string, status models.Bike
 	return r.db.Model(&models.Bike{}).Where("id = ?", bikeID).Update("status", status).Error
 }
 
-// UpdateRental updates a rental in the repository.
+// UpdateBikePosition stores the last known position of a bike.
+//
+// Parameters:
+// - bikeID: the ID of the bike to update.
+// - latitude: the latitude of the bike.
+// - longitude: the longitude of the bike.
+//
+// Returns:
+// - error: an error if there was a problem updating the bike's position.
+func (r *rentalRepositoryImp) UpdateBikePosition(bikeID string, latitude, longitude float64) error {
+	return r.db.Model(&models.Bike{}).Where("id = ?", bikeID).Updates(map[string]interface{}{
+		"latitude":  latitude,
+		"longitude": longitude,
+	}).Error
+}
+
+// GetParkingZones retrieves the zones that restrict where bikes may be returned: the
+// operating areas and the no-parking zones.
+//
+// Returns:
+// - *[]models.Zone: a pointer to a slice of models.Zone.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetParkingZones() (*[]models.Zone, error) {
+	var zones []models.Zone
+
+	err := r.db.Where("type IN ?", []models.ZoneTypeEnum{models.ZONE_TYPE_OPERATING, models.ZONE_TYPE_NO_PARKING}).Find(&zones).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &zones, nil
+}
+
+// GetPricingPlanForBike resolves the pricing plan that applies to a bike. The plan assigned to
+// the bike model wins over the plan of its station, which wins over the plan of the station
+// city, which wins over the default plan.
+//
+// Parameters:
+// - bike: the bike being priced.
+//
+// Returns:
+// - *models.PricingPlan: a pointer to the plan, or nil when no plan applies.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetPricingPlanForBike(bike *models.Bike) (*models.PricingPlan, error) {
+	candidates := []models.PricingPlanAssignment{}
+
+	if bike.ModelID != nil {
+		candidates = append(candidates, models.PricingPlanAssignment{Scope: models.PRICING_PLAN_SCOPE_BIKE_MODEL, ScopeKey: bike.ModelID.String()})
+	}
+
+	if bike.StationID != nil {
+		var station models.Station
+		if err := r.db.Unscoped().First(&station, "id = ?", bike.StationID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		candidates = append(candidates, models.PricingPlanAssignment{Scope: models.PRICING_PLAN_SCOPE_STATION, ScopeKey: bike.StationID.String()})
+		if station.City != "" {
+			candidates = append(candidates, models.PricingPlanAssignment{Scope: models.PRICING_PLAN_SCOPE_CITY, ScopeKey: strings.ToLower(station.City)})
+		}
+	}
+
+	candidates = append(candidates, models.PricingPlanAssignment{Scope: models.PRICING_PLAN_SCOPE_DEFAULT})
+
+	for _, candidate := range candidates {
+		var assignment models.PricingPlanAssignment
+
+		err := r.db.Where("scope = ? AND scope_key = ?", candidate.Scope, candidate.ScopeKey).First(&assignment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return r.GetPricingPlanByID(assignment.PricingPlanID.String())
+	}
+
+	return nil, nil
+}
+
+// GetPricingPlanByID retrieves a pricing plan by its ID, including deleted plans so that
+// rentals started with a plan can still be priced with it.
+//
+// Parameters:
+// - id: the ID of the plan to retrieve.
+//
+// Returns:
+// - *models.PricingPlan: a pointer to the plan if found, or nil if not found.
+// - error: an error if there was a problem retrieving the plan, or nil if successful.
+func (r *rentalRepositoryImp) GetPricingPlanByID(id string) (*models.PricingPlan, error) {
+	var plan models.PricingPlan
+
+	if err := r.db.Unscoped().First(&plan, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pricing plan not found")
+		}
+
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// GetActivePricingRules retrieves the pricing rules that are turned on.
+//
+// Returns:
+// - *[]models.PricingRule: a pointer to a slice of models.PricingRule.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetActivePricingRules() (*[]models.PricingRule, error) {
+	var rules []models.PricingRule
+
+	if err := r.db.Where("active = ?", true).Order("type, name").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+// GetPromotionByCode retrieves a promotion by its code.
+//
+// Parameters:
+// - code: the promo code, in upper case.
+//
+// Returns:
+// - *models.Promotion: a pointer to the promotion if found, or nil if not found.
+// - error: an error if there was a problem retrieving the promotion, or nil if successful.
+func (r *rentalRepositoryImp) GetPromotionByCode(code string) (*models.Promotion, error) {
+	var promotion models.Promotion
+
+	if err := r.db.First(&promotion, "code = ?", code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("promo code not found")
+		}
+
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+// GetPromotionByID retrieves a promotion by its ID, including deleted promotions, which still
+// apply to the rentals started with them.
+//
+// Parameters:
+// - id: the ID of the promotion to retrieve.
+//
+// Returns:
+// - *models.Promotion: a pointer to the promotion if found, or nil if not found.
+// - error: an error if there was a problem retrieving the promotion, or nil if successful.
+func (r *rentalRepositoryImp) GetPromotionByID(id string) (*models.Promotion, error) {
+	var promotion models.Promotion
+
+	if err := r.db.Unscoped().First(&promotion, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("promotion not found")
+		}
+
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+// CheckPromotionRedemption checks that a rider can redeem a promotion on a new rental, without
+// redeeming it.
+//
+// Parameters:
+// - promotion: a pointer to the models.Promotion to redeem.
+// - userID: the ID of the rider.
+//
+// Returns:
+// - error: an error if a redemption limit of the promotion is reached for the rider.
+func (r *rentalRepositoryImp) CheckPromotionRedemption(promotion *models.Promotion, userID uuid.UUID) error {
+	return checkPromotionRedemption(r.db, promotion, userID)
+}
+
+// updatePromotionRedemption sets the status of the promo code redemption of a rental and the
+// discount it gave.
+func updatePromotionRedemption(tx *gorm.DB, rentalID uuid.UUID, status models.PromotionRedemptionStatusEnum, discount money.Money) error {
+	return tx.Model(&models.PromotionRedemption{}).
+		Where("rental_id = ?", rentalID).
+		Updates(map[string]interface{}{"status": status, "discount_amount": discount.Amount, "discount_currency": discount.Currency}).Error
+}
+
+// GetPassesForRide retrieves the passes of a rider that were valid when a ride started, with
+// their products, the first to expire first.
+//
+// Parameters:
+// - userID: the ID of the rider.
+// - startTime: when the ride started.
+//
+// Returns:
+// - *[]models.Pass: a pointer to a slice of models.Pass.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetPassesForRide(userID string, startTime time.Time) (*[]models.Pass, error) {
+	var passes []models.Pass
+
+	err := r.db.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ? AND starts_at <= ? AND expires_at > ?", userID, startTime, startTime).
+		Order("expires_at").
+		Find(&passes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &passes, nil
+}
+
+// consumePass records what a pass covered on a rental and adds it to the rides and minutes used
+// of the pass. The pass row is locked, and the usage is only added while it fits in the rides and
+// minutes the pass includes, so concurrent rides cannot use the same quota twice.
+func consumePass(tx *gorm.DB, usage *models.PassUsage) error {
+	var pass models.Pass
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pass, "id = ?", usage.PassID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("pass not found")
+		}
+
+		return err
+	}
+
+	var product models.PassProduct
+	if err := tx.Unscoped().First(&product, "id = ?", pass.ProductID).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&models.Pass{}).
+		Where("id = ?", pass.ID).
+		Where("? = 0 OR rides_used + ? <= ?", product.IncludedRides, usage.Rides, product.IncludedRides).
+		Where("? = 0 OR minutes_used + ? <= ?", product.IncludedMinutes, usage.Minutes, product.IncludedMinutes).
+		Updates(map[string]interface{}{
+			"rides_used":   gorm.Expr("rides_used + ?", usage.Rides),
+			"minutes_used": gorm.Expr("minutes_used + ?", usage.Minutes),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pass quota is used up")
+	}
+
+	return tx.Create(usage).Error
+}
+
+// GetPassUsage retrieves what a pass covered on a rental.
+//
+// Parameters:
+// - rentalID: the ID of the rental.
+//
+// Returns:
+// - *models.PassUsage: a pointer to the usage, or nil when no pass covered the rental.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetPassUsage(rentalID string) (*models.PassUsage, error) {
+	var usages []models.PassUsage
+
+	if err := r.db.Where("rental_id = ?", rentalID).Limit(1).Find(&usages).Error; err != nil {
+		return nil, err
+	}
+
+	if len(usages) == 0 {
+		return nil, nil
+	}
+
+	return &usages[0], nil
+}
+
+// GetPassByID retrieves a pass by its ID, with its product even if it was deleted since.
+//
+// Parameters:
+// - id: the ID of the pass to retrieve.
+//
+// Returns:
+// - *models.Pass: a pointer to the pass if found, or nil if not found.
+// - error: an error if there was a problem retrieving the pass, or nil if successful.
+func (r *rentalRepositoryImp) GetPassByID(id string) (*models.Pass, error) {
+	var pass models.Pass
+
+	err := r.db.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).First(&pass, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("pass not found")
+		}
+
+		return nil, err
+	}
+
+	return &pass, nil
+}
+
+// IsHoliday reports whether a date is in the holiday calendar, either for every country or for
+// the given one.
+//
+// Parameters:
+// - date: the date, formatted as YYYY-MM-DD.
+// - country: the ISO country code, or an empty string to only look at global holidays.
+//
+// Returns:
+// - bool: true when the date is a holiday.
+// - error: an error if any.
+func (r *rentalRepositoryImp) IsHoliday(date string, country string) (bool, error) {
+	var count int64
+
+	err := r.db.Model(&models.Holiday{}).Where("date = ? AND country IN ?", date, []string{"", country}).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetTaxRatesInForce retrieves the tax rates of a country and of one of its cities in force at
+// a time, that is for each tax the version that took effect last before it. Taxes ended by a
+// rate of zero are left out.
+//
+// Parameters:
+// - country: the ISO country code.
+// - city: the city name, or an empty string to only get the rates of the country.
+// - at: the time the rates must be in force at.
+//
+// Returns:
+// - *[]models.TaxRate: a pointer to a slice of models.TaxRate, the country rates first.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetTaxRatesInForce(country string, city string, at time.Time) (*[]models.TaxRate, error) {
+	var rates []models.TaxRate
+
+	versions := r.db.Model(&models.TaxRate{}).
+		Select("DISTINCT ON (city, name) *").
+		Where("country = ? AND city IN ? AND effective_from <= ?", strings.ToUpper(country), []string{"", strings.ToLower(city)}, at).
+		Order("city, name, effective_from DESC")
+
+	if err := r.db.Table("(?) AS versions", versions).Where("percent > 0").Order("city, name").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+
+	return &rates, nil
+}
+
+// GetStationByID retrieves a station by its ID.
+//
+// Parameters:
+// - id: the ID of the station to retrieve.
+//
+// Returns:
+// - *models.Station: a pointer to the station if found, or nil if not found.
+// - error: an error if there was a problem retrieving the station, or nil if successful.
+func (r *rentalRepositoryImp) GetStationByID(id string) (*models.Station, error) {
+	var station models.Station
+
+	if err := r.db.First(&station, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("station not found")
+		}
+
+		return nil, err
+	}
+
+	return &station, nil
+}
+
+// GetNearestStation retrieves the station closest to a position, within a radius.
+//
+// Parameters:
+// - latitude: the latitude of the position.
+// - longitude: the longitude of the position.
+// - radiusMeters: how far from the position the station may be.
 //
-// It takes a pointer to a models.Rental struct as a parameter, which represents the rental to be updated.
-// The function returns an error if there was an issue updating the rental.
-func (r *rentalRepositoryImp) UpdateRental(rental *models.Rental) error {
-	return r.db.Save(rental).Error
+// Returns:
+// - *models.Station: a pointer to the station, or nil when no station is within the radius.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetNearestStation(latitude, longitude, radiusMeters float64) (*models.Station, error) {
+	var stations []models.Station
+
+	// Narrow the candidates down to a bounding box before measuring the exact distances.
+	box := geo.BoundingBoxAround(geo.Point{Latitude: latitude, Longitude: longitude}, radiusMeters)
+
+	err := r.db.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", box.MinLatitude, box.MaxLatitude, box.MinLongitude, box.MaxLongitude).
+		Find(&stations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var nearest *models.Station
+	best := radiusMeters
+
+	for i := range stations {
+		distance := geo.Distance(geo.Point{Latitude: latitude, Longitude: longitude}, geo.Point{Latitude: stations[i].Latitude, Longitude: stations[i].Longitude})
+		if distance <= best {
+			nearest, best = &stations[i], distance
+		}
+	}
+
+	return nearest, nil
+}
+
+// CountAvailableBikesAtStation counts the bikes that can be rented at a station.
+//
+// Parameters:
+// - stationID: the ID of the station.
+// - excludeBikeID: the ID of a bike left out of the count, or an empty string.
+//
+// Returns:
+// - int: the number of available bikes.
+// - error: an error if any.
+func (r *rentalRepositoryImp) CountAvailableBikesAtStation(stationID string, excludeBikeID string) (int, error) {
+	var count int64
+
+	query := r.db.Model(&models.Bike{}).Where("station_id = ? AND status = ?", stationID, models.BIKE_STATUS_AVAILABLE)
+	if excludeBikeID != "" {
+		query = query.Where("id <> ?", excludeBikeID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// UpdateBikeStation sets the station a bike is parked at.
+//
+// Parameters:
+// - bikeID: the ID of the bike.
+// - stationID: the ID of the station, or nil when the bike is parked away from stations.
+//
+// Returns:
+// - error: an error if any.
+func (r *rentalRepositoryImp) UpdateBikeStation(bikeID string, stationID *uuid.UUID) error {
+	return r.db.Model(&models.Bike{}).Where("id = ?", bikeID).Update("station_id", stationID).Error
+}
+
+// CreateTripPoints stores GPS fixes of a rental. Fixes already stored for the same rental and
+// time are ignored, so devices can safely resend a batch.
+//
+// Parameters:
+// - points: the fixes to store.
+//
+// Returns:
+// - error: an error if there was a problem storing the fixes.
+func (r *rentalRepositoryImp) CreateTripPoints(points []models.TripPoint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&points).Error
+}
+
+// GetTripPoints retrieves the route of a rental, ordered by time.
+//
+// Parameters:
+// - rentalID: the ID of the rental.
+//
+// Returns:
+// - *[]models.TripPoint: a pointer to a slice of models.TripPoint.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetTripPoints(rentalID string) (*[]models.TripPoint, error) {
+	var points []models.TripPoint
+
+	if err := r.db.Where("rental_id = ?", rentalID).Order("recorded_at").Find(&points).Error; err != nil {
+		return nil, err
+	}
+
+	return &points, nil
+}
+
+// CompleteRental stores a returned rental with its tax lines and penalties and debits its total
+// cost from the wallet of the rider, as a single transaction. The penalties are left unpaid. What
+// the pass of the rider covered and the promo code redemption of the rental, if any, are recorded
+// in the same transaction, so the return fails as a whole when the pass quota was used up in the
+// meantime, and the bike is made available again. The rental is only updated while it is still
+// active, so a rental returned twice at the same time is charged once. Its pre-authorization hold
+// is left as stored, since it may be renewed concurrently.
+//
+// Parameters:
+// - rental: a pointer to a models.Rental object representing the completed rental.
+// - passUsage: what the pass of the rider covered on the rental, or nil when no pass applied.
+// - promotionDiscount: the amount the promo code of the rental took off its price.
+//
+// Returns:
+// - error: an error if the rental is no longer active, the pass quota is used up, or there was a
+// problem storing it.
+func (r *rentalRepositoryImp) CompleteRental(rental *models.Rental, passUsage *models.PassUsage, promotionDiscount money.Money) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Rental{}).
+			Where("id = ? AND status IN ?", rental.ID, models.RentalInProgressStatuses).
+			Select("*").Omit("ID", "HoldPaymentID", "CreatedAt", "TaxLines", "Penalties", "Segments").
+			Updates(rental)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("rental is not active")
+		}
+
+		err := tx.Model(&models.RentalSegment{}).
+			Where("rental_id = ? AND end_time IS NULL", rental.ID).
+			Update("end_time", rental.EndTime).Error
+		if err != nil {
+			return err
+		}
+
+		if len(rental.TaxLines) > 0 {
+			if err := tx.Create(&rental.TaxLines).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(rental.Penalties) > 0 {
+			if err := tx.Create(&rental.Penalties).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Bike{}).Where("id = ?", rental.BikeID).Update("status", models.BIKE_STATUS_AVAILABLE).Error; err != nil {
+			return err
+		}
+
+		if passUsage != nil {
+			if err := consumePass(tx, passUsage); err != nil {
+				return err
+			}
+		}
+
+		if rental.PromotionID != nil {
+			if err := updatePromotionRedemption(tx, rental.ID, models.PROMOTION_REDEMPTION_STATUS_APPLIED, promotionDiscount); err != nil {
+				return err
+			}
+		}
+
+		return chargeRental(tx, rental)
+	})
+}
+
+// GetDefaultPaymentMethod retrieves the card that pays for the rentals of a rider.
+//
+// Parameters:
+// - userID: the ID of the rider.
+//
+// Returns:
+// - *models.PaymentMethod: a pointer to the default payment method, or nil when the rider has no card on file.
+// - error: an error if the payment method could not be retrieved.
+func (r *rentalRepositoryImp) GetDefaultPaymentMethod(userID string) (*models.PaymentMethod, error) {
+	var methods []models.PaymentMethod
+
+	if err := r.db.Where("user_id = ? AND is_default = ?", userID, true).Limit(1).Find(&methods).Error; err != nil {
+		return nil, err
+	}
+
+	if len(methods) == 0 {
+		return nil, nil
+	}
+
+	return &methods[0], nil
+}
+
+// CreatePayment stores a payment before it is sent to the payment provider.
+//
+// Parameters:
+// - payment: a pointer to a models.Payment object representing the payment.
+//
+// Returns:
+// - error: an error if the payment could not be stored.
+func (r *rentalRepositoryImp) CreatePayment(payment *models.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+// RecordPaymentResult applies the result of an operation of the payment provider to a payment.
+//
+// Parameters:
+// - id: the ID of the payment.
+// - result: the result reported by the provider.
+//
+// Returns:
+// - *models.Payment: a pointer to the updated payment.
+// - error: an error if the payment is not found or could not be updated.
+func (r *rentalRepositoryImp) RecordPaymentResult(id uuid.UUID, result *payment.Result) (*models.Payment, error) {
+	return recordPaymentResult(r.db, id, result)
+}
+
+// GetPaymentByID retrieves a payment.
+//
+// Parameters:
+// - id: the ID of the payment.
+//
+// Returns:
+// - *models.Payment: a pointer to the payment if found.
+// - error: an error if the payment is not found or could not be retrieved.
+func (r *rentalRepositoryImp) GetPaymentByID(id string) (*models.Payment, error) {
+	var p models.Payment
+
+	if err := r.db.First(&p, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment not found")
+		}
+
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// ReplaceRentalHold makes a payment the pre-authorization hold of an active rental. The hold is
+// only replaced while it is still currentID, or while the rental has no hold when currentID is
+// nil, so a renewal cannot overwrite a hold placed or used concurrently.
+//
+// Parameters:
+// - rentalID: the ID of the rental.
+// - currentID: the ID of the hold being replaced, or nil.
+// - holdID: the ID of the new hold.
+//
+// Returns:
+// - error: an error if the rental is no longer active, its hold changed, or it could not be updated.
+func (r *rentalRepositoryImp) ReplaceRentalHold(rentalID uuid.UUID, currentID *uuid.UUID, holdID uuid.UUID) error {
+	query := r.db.Model(&models.Rental{}).Where("id = ? AND status IN ?", rentalID, models.RentalInProgressStatuses)
+	if currentID == nil {
+		query = query.Where("hold_payment_id IS NULL")
+	} else {
+		query = query.Where("hold_payment_id = ?", *currentID)
+	}
+
+	result := query.Update("hold_payment_id", holdID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("rental is not active or its hold changed")
+	}
+
+	return nil
+}
+
+// GetRentalsWithExpiringHolds retrieves the active rentals whose pre-authorization hold is still
+// authorized and expires before a given time.
+//
+// Parameters:
+// - before: the time the holds expire before.
+//
+// Returns:
+// - *[]models.Rental: a pointer to a slice of models.Rental.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetRentalsWithExpiringHolds(before time.Time) (*[]models.Rental, error) {
+	var rentals []models.Rental
+
+	err := r.db.
+		Joins("JOIN payments ON payments.id = rentals.hold_payment_id").
+		Where("rentals.status IN ? AND payments.status = ? AND payments.expires_at < ?", models.RentalInProgressStatuses, models.PAYMENT_STATUS_AUTHORIZED, before).
+		Find(&rentals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &rentals, nil
+}
+
+// CancelRental marks a rental that never really started as cancelled and makes its bike
+// available again, in a single transaction. A reservation fulfilled by the rental becomes
+// pending again, the promo code redeemed by the rental is released, and so is the QR payload
+// nonce it used, so the rider can scan the same code again.
+//
+// Parameters:
+// - rental: a pointer to a models.Rental object representing the rental to cancel.
+//
+// Returns:
+// - error: an error if there was a problem updating the rental or the bike.
+func (r *rentalRepositoryImp) CancelRental(rental *models.Rental) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		rental.Status = models.RENTAL_STATUS_CANCELLED
+		rental.EndTime = time.Now()
+
+		if err := tx.Save(rental).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.Reservation{}).
+			Where("rental_id = ? AND status = ?", rental.ID, models.RESERVATION_STATUS_FULFILLED).
+			Updates(map[string]interface{}{"status": models.RESERVATION_STATUS_PENDING, "rental_id": nil}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.PromotionRedemption{}).
+			Where("rental_id = ? AND status = ?", rental.ID, models.PROMOTION_REDEMPTION_STATUS_PENDING).
+			Update("status", models.PROMOTION_REDEMPTION_STATUS_RELEASED).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("rental_id = ?", rental.ID).Delete(&models.ScanNonce{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Bike{}).Where("id = ?", rental.BikeID).Update("status", models.BIKE_STATUS_AVAILABLE).Error
+	})
+}
+
+// PauseRental pauses an active rental: its riding segment is closed and a paused segment is
+// opened. The rental row is locked, so concurrent pauses and returns are serialized.
+//
+// Parameters:
+// - id: the ID of the rental.
+// - at: when the rental is paused.
+// - maxPause: how long the rental can be paused in total.
+//
+// Returns:
+// - *models.Rental: a pointer to the paused rental, with its segments.
+// - error: an error if the rental is not found or not active, its pause duration is used up, or
+// it could not be updated.
+func (r *rentalRepositoryImp) PauseRental(id uuid.UUID, at time.Time, maxPause time.Duration) (*models.Rental, error) {
+	return switchRentalSegment(r.db, id, at, models.RENTAL_STATUS_ACTIVE, models.RENTAL_STATUS_PAUSED, func(rental *models.Rental) error {
+		if models.PausedDuration(rental.Segments, at) >= maxPause {
+			return fmt.Errorf("the maximum pause duration of the rental is used up")
+		}
+
+		return nil
+	})
+}
+
+// ResumeRental resumes a paused rental: its paused segment is closed and a riding segment is
+// opened.
+//
+// Parameters:
+// - id: the ID of the rental.
+// - at: when the rental is resumed.
+//
+// Returns:
+// - *models.Rental: a pointer to the resumed rental, with its segments.
+// - error: an error if the rental is not found or not paused, or it could not be updated.
+func (r *rentalRepositoryImp) ResumeRental(id uuid.UUID, at time.Time) (*models.Rental, error) {
+	return switchRentalSegment(r.db, id, at, models.RENTAL_STATUS_PAUSED, models.RENTAL_STATUS_ACTIVE, nil)
+}
+
+// GetRentalsDueForOvertime retrieves the rentals in progress that reached their next overtime
+// stage, the oldest first: rentals not warned yet whose maximum end is at most warning away,
+// warned rentals past their maximum end, and overdue rentals abandonedAfter past it. The maximum
+// end of a rental is its start time plus its maximum duration, or defaultMaxDuration when it has
+// none.
+//
+// Parameters:
+// - now: the time the rentals are checked at.
+// - defaultMaxDuration: how long the rentals without a maximum duration can last.
+// - warning: how long before its maximum end the rider of a rental is warned.
+// - abandonedAfter: how long after its maximum end a rental is suspected abandoned.
+//
+// Returns:
+// - *[]models.Rental: a pointer to a slice of models.Rental.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetRentalsDueForOvertime(now time.Time, defaultMaxDuration time.Duration, warning time.Duration, abandonedAfter time.Duration) (*[]models.Rental, error) {
+	var rentals []models.Rental
+
+	maxEnd := "start_time + COALESCE(NULLIF(max_duration_minutes, 0) * 60, ?) * interval '1 second'"
+	defaultSeconds := int64(defaultMaxDuration / time.Second)
+
+	err := r.db.Where("status IN ?", models.RentalInProgressStatuses).
+		Where(
+			r.db.Where("overtime_stage = ? AND "+maxEnd+" <= ?", models.RENTAL_OVERTIME_NONE, defaultSeconds, now.Add(warning)).
+				Or("overtime_stage = ? AND "+maxEnd+" <= ?", models.RENTAL_OVERTIME_WARNED, defaultSeconds, now).
+				Or("overtime_stage = ? AND "+maxEnd+" <= ?", models.RENTAL_OVERTIME_OVERDUE, defaultSeconds, now.Add(-abandonedAfter)),
+		).
+		Order("start_time").
+		Find(&rentals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &rentals, nil
+}
+
+// GetRentalsByOvertimeStage retrieves the active rentals that reached an overtime stage, the
+// oldest first.
+//
+// Parameters:
+// - stage: the overtime stage of the rentals.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Rental: a pointer to a slice of models.Rental.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetRentalsByOvertimeStage(stage models.RentalOvertimeStageEnum, pagination pkg.Pagination) (*[]models.Rental, *pkg.Pagination, error) {
+	var rentals []models.Rental
+
+	query := r.db.Where("status IN ? AND overtime_stage = ?", models.RentalInProgressStatuses, stage).Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.Rental{}, &pagination, query)).
+		Order("start_time, id").
+		Find(&rentals).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &rentals, &pagination, nil
+}
+
+// AdvanceOvertimeStage moves an active rental from an overtime stage to the next one. The rental
+// is only updated while it is active and still at the from stage, so each stage is reached once.
+//
+// Parameters:
+// - id: the ID of the rental.
+// - from: the stage the rental is expected to be at.
+// - to: the stage the rental reached.
+// - at: when the rental reached the stage.
+//
+// Returns:
+// - bool: true when the rental was moved to the stage.
+// - error: an error if the rental could not be updated.
+func (r *rentalRepositoryImp) AdvanceOvertimeStage(id uuid.UUID, from models.RentalOvertimeStageEnum, to models.RentalOvertimeStageEnum, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Rental{}).
+		Where("id = ? AND status IN ? AND overtime_stage = ?", id, models.RentalInProgressStatuses, from).
+		Updates(map[string]interface{}{"overtime_stage": to, "overtime_stage_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// GetUserByID retrieves a user from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the user to retrieve.
+//
+// Returns:
+// - *models.User: a pointer to the user if found, or nil if not found.
+// - error: an error if there was a problem retrieving the user, or nil if successful.
+func (r *rentalRepositoryImp) GetUserByID(id string) (*models.User, error) {
+	var user models.User
+
+	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CountUnpaidPenalties counts the penalties a rider has not paid yet.
+//
+// Parameters:
+// - userID: the ID of the rider.
+//
+// Returns:
+// - int64: the number of unpaid penalties.
+// - error: an error if any.
+func (r *rentalRepositoryImp) CountUnpaidPenalties(userID string) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.Penalty{}).Where("user_id = ? AND status = ?", userID, models.PENALTY_STATUS_UNPAID).Count(&count).Error
+
+	return count, err
+}
+
+// CountActiveRentals counts the rentals a rider has in progress.
+//
+// Parameters:
+// - userID: the ID of the rider.
+//
+// Returns:
+// - int64: the number of active rentals.
+// - error: an error if any.
+func (r *rentalRepositoryImp) CountActiveRentals(userID string) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.Rental{}).Where("user_id = ? AND status IN ?", userID, models.RentalInProgressStatuses).Count(&count).Error
+
+	return count, err
+}
+
+// GetWalletBalances reads the balances of the wallet of a rider, one per currency.
+//
+// Parameters:
+// - userID: the ID of the rider.
+//
+// Returns:
+// - []money.Money: the balances of the wallet.
+// - error: an error if any.
+func (r *rentalRepositoryImp) GetWalletBalances(userID string) ([]money.Money, error) {
+	var balances []struct {
+		Currency string
+		Amount   int64
+	}
+
+	err := r.db.Model(&models.LedgerEntry{}).
+		Select("amount_currency AS currency, SUM(CASE WHEN direction = ? THEN amount_amount ELSE -amount_amount END) AS amount", models.LEDGER_ENTRY_CREDIT).
+		Where("account_id IN (?)", r.db.Model(&models.LedgerAccount{}).Select("id").Where("type = ? AND user_id = ?", models.LEDGER_ACCOUNT_WALLET, userID)).
+		Group("amount_currency").
+		Order("amount_currency").
+		Scan(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]money.Money, 0, len(balances))
+	for _, balance := range balances {
+		result = append(result, money.New(balance.Amount, balance.Currency))
+	}
+
+	return result, nil
+}
+
+// SettlePenalty pays an unpaid penalty from the wallet of its rider.
+//
+// Parameters:
+// - id: the ID of the penalty.
+//
+// Returns:
+// - *models.Penalty: a pointer to the paid penalty.
+// - error: an error if the penalty is not unpaid, the wallet balance does not cover it, or the
+// payment could not be posted.
+func (r *rentalRepositoryImp) SettlePenalty(id string) (*models.Penalty, error) {
+	var penalty *models.Penalty
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		penalty, err = settlePenalty(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return penalty, nil
+}
+
+// CreateLockCommand records a command sent to the lock of a bike.
+//
+// Parameters:
+// - command: a pointer to a models.LockCommand object representing the command.
+//
+// Returns:
+// - error: an error if there was a problem creating the command.
+func (r *rentalRepositoryImp) CreateLockCommand(command *models.LockCommand) error {
+	return r.db.Create(command).Error
+}
+
+// UpdateLockCommand updates the delivery status of a lock command.
+//
+// Parameters:
+// - command: a pointer to a models.LockCommand object representing the command.
+//
+// Returns:
+// - error: an error if there was a problem updating the command.
+func (r *rentalRepositoryImp) UpdateLockCommand(command *models.LockCommand) error {
+	return r.db.Save(command).Error
+}
+
+// checkPromotionRedemption checks the global and per rider redemption limits of a promotion and
+// its first ride condition. Released redemptions and cancelled rentals are not counted.
+func checkPromotionRedemption(tx *gorm.DB, promotion *models.Promotion, userID uuid.UUID) error {
+	redeemed := tx.Model(&models.PromotionRedemption{}).
+		Where("promotion_id = ? AND status <> ?", promotion.ID, models.PROMOTION_REDEMPTION_STATUS_RELEASED)
+
+	if promotion.MaxRedemptions > 0 {
+		var count int64
+		if err := redeemed.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count >= int64(promotion.MaxRedemptions) {
+			return fmt.Errorf("promo code has reached its redemption limit")
+		}
+	}
+
+	if promotion.MaxRedemptionsPerUser > 0 {
+		var count int64
+		if err := redeemed.Session(&gorm.Session{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count >= int64(promotion.MaxRedemptionsPerUser) {
+			return fmt.Errorf("you have already used this promo code")
+		}
+	}
+
+	if promotion.FirstRideOnly {
+		var count int64
+		err := tx.Model(&models.Rental{}).Where("user_id = ? AND status <> ?", userID, models.RENTAL_STATUS_CANCELLED).Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("promo code is only valid on your first ride")
+		}
+	}
+
+	return nil
+}
+
+// switchRentalSegment moves a locked rental from one in progress status to the other, closing
+// its open segment and opening a segment of the new status, as a single transaction. The check,
+// when given, is run on the locked rental before it is updated.
+func switchRentalSegment(db *gorm.DB, id uuid.UUID, at time.Time, from models.RentalStatusEnum, to models.RentalStatusEnum, check func(rental *models.Rental) error) (*models.Rental, error) {
+	var rental *models.Rental
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rental, err = lockRental(tx, id); err != nil {
+			return err
+		}
+
+		if rental.Status != from {
+			return fmt.Errorf("rental is not %s", from)
+		}
+
+		if err := tx.Where("rental_id = ?", rental.ID).Order("start_time").Find(&rental.Segments).Error; err != nil {
+			return err
+		}
+
+		if check != nil {
+			if err := check(rental); err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&models.RentalSegment{}).
+			Where("rental_id = ? AND end_time IS NULL", rental.ID).
+			Update("end_time", at).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range rental.Segments {
+			if rental.Segments[i].EndTime == nil {
+				rental.Segments[i].EndTime = &at
+			}
+		}
+
+		segmentType := models.RENTAL_SEGMENT_RIDING
+		if to == models.RENTAL_STATUS_PAUSED {
+			segmentType = models.RENTAL_SEGMENT_PAUSED
+		}
+
+		segment := models.RentalSegment{
+			ID:        uuid.Must(uuid.NewRandom()),
+			RentalID:  rental.ID,
+			Type:      segmentType,
+			StartTime: at,
+		}
+
+		if err := tx.Create(&segment).Error; err != nil {
+			return err
+		}
+
+		rental.Segments = append(rental.Segments, segment)
+		rental.Status = to
+
+		return tx.Model(&models.Rental{}).Where("id = ?", rental.ID).Update("status", to).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rental, nil
 }
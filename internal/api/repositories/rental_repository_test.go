@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// concurrentRentals is how many rentals are started at once by the concurrency tests.
+const concurrentRentals = 10
+
+// openTestDatabase connects to the PostgreSQL database in the "TEST_DATABASE_URL" environment
+// variable and migrates the tables a rental touches. The test is skipped when it is not set.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open the test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Promotion{}, &models.PromotionRedemption{}, &models.Bike{}, &models.Rental{}, &models.RentalSegment{}, &models.ScanNonce{}, &models.Reservation{}, &models.BikeHold{})
+	if err != nil {
+		t.Fatalf("failed to migrate the test database: %v", err)
+	}
+
+	return db
+}
+
+// createTestUser creates a verified rider removed with their rentals when the test ends.
+func createTestUser(t *testing.T, db *gorm.DB) *models.User {
+	t.Helper()
+
+	id := uuid.Must(uuid.NewRandom())
+	user := &models.User{
+		ID:       id,
+		Email:    fmt.Sprintf("%s@example.com", id),
+		Password: "password",
+		Name:     "Rider",
+		Status:   models.UserStatusActive,
+		Role:     models.UserRoleDefault,
+		Verified: true,
+	}
+
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		deleteTestRentals(db, "user_id = ?", user.ID)
+		db.Delete(user)
+	})
+
+	return user
+}
+
+// createTestBike creates an available bike removed when the test ends.
+func createTestBike(t *testing.T, db *gorm.DB) *models.Bike {
+	t.Helper()
+
+	bike := &models.Bike{
+		ID:           uuid.Must(uuid.NewRandom()),
+		Name:         "Bike",
+		Description:  "Bike",
+		PricePerHour: money.New(1000, "USD"),
+		Location:     "Station",
+		Status:       models.BIKE_STATUS_AVAILABLE,
+		Image:        "https://example.com/bike.png",
+	}
+
+	if err := db.Create(bike).Error; err != nil {
+		t.Fatalf("failed to create bike: %v", err)
+	}
+
+	t.Cleanup(func() {
+		deleteTestRentals(db, "bike_id = ?", bike.ID)
+		db.Unscoped().Delete(bike)
+	})
+
+	return bike
+}
+
+// deleteTestRentals deletes the rentals matching a condition with their segments.
+func deleteTestRentals(db *gorm.DB, query string, args ...interface{}) {
+	rentals := db.Unscoped().Model(&models.Rental{}).Select("id").Where(query, args...)
+	db.Where("rental_id IN (?)", rentals).Delete(&models.RentalSegment{})
+	db.Unscoped().Where(query, args...).Delete(&models.Rental{})
+}
+
+// newTestRental returns a rental of a bike by a rider starting now.
+func newTestRental(user *models.User, bike *models.Bike) *models.Rental {
+	now := time.Now()
+
+	return &models.Rental{
+		ID:        uuid.Must(uuid.NewRandom()),
+		UserID:    user.ID,
+		BikeID:    bike.ID,
+		StartTime: now,
+		EndTime:   now,
+		Status:    models.RENTAL_STATUS_ACTIVE,
+	}
+}
+
+// createRentalsConcurrently starts the rentals at once and returns the error of each of them.
+func createRentalsConcurrently(repo RentalRepository, rentals []*models.Rental, maxActiveRentals int) []error {
+	errs := make([]error, len(rentals))
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i, rental := range rentals {
+		wg.Add(1)
+		go func(i int, rental *models.Rental) {
+			defer wg.Done()
+			<-start
+			errs[i] = repo.CreateRental(rental, nil, 0, maxActiveRentals)
+		}(i, rental)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+func TestCreateRentalBooksABikeOnce(t *testing.T) {
+	db := openTestDatabase(t)
+	repo := NewRentalRepository(db)
+	bike := createTestBike(t, db)
+
+	rentals := make([]*models.Rental, concurrentRentals)
+	for i := range rentals {
+		rentals[i] = newTestRental(createTestUser(t, db), bike)
+	}
+
+	created := 0
+	for _, err := range createRentalsConcurrently(repo, rentals, 1) {
+		switch {
+		case err == nil:
+			created++
+		case err.Error() != "bike is not available to rent":
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if created != 1 {
+		t.Errorf("expected exactly 1 rental to be created, got %d", created)
+	}
+
+	var reloaded models.Bike
+	if err := db.First(&reloaded, "id = ?", bike.ID).Error; err != nil {
+		t.Fatalf("failed to reload bike: %v", err)
+	}
+
+	if reloaded.Status != models.BIKE_STATUS_BOOKED {
+		t.Errorf("expected bike to be booked, got %s", reloaded.Status)
+	}
+}
+
+func TestCreateRentalLimitsActiveRentalsOfARider(t *testing.T) {
+	db := openTestDatabase(t)
+	repo := NewRentalRepository(db)
+	user := createTestUser(t, db)
+
+	rentals := make([]*models.Rental, concurrentRentals)
+	for i := range rentals {
+		rentals[i] = newTestRental(user, createTestBike(t, db))
+	}
+
+	created := 0
+	for _, err := range createRentalsConcurrently(repo, rentals, 1) {
+		switch {
+		case err == nil:
+			created++
+		case err.Error() != "too many active rentals":
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if created != 1 {
+		t.Errorf("expected exactly 1 rental to be created, got %d", created)
+	}
+
+	var active int64
+	if err := db.Model(&models.Rental{}).Where("user_id = ? AND status IN ?", user.ID, models.RentalInProgressStatuses).Count(&active).Error; err != nil {
+		t.Fatalf("failed to count rentals: %v", err)
+	}
+
+	if active != 1 {
+		t.Errorf("expected the rider to have 1 active rental, got %d", active)
+	}
+}
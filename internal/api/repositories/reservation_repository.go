@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReservationRepository interface {
+	CreateReservation(reservation *models.Reservation, rentalBuffer time.Duration) error
+	CreateStationReservation(reservation *models.Reservation, rentalBuffer time.Duration) error
+	GetReservationsByUserID(userID string, status string, pagination pkg.Pagination) (*[]models.Reservation, *pkg.Pagination, error)
+	GetAllReservations(status string, pagination pkg.Pagination) (*[]models.Reservation, *pkg.Pagination, error)
+	GetReservationByID(id string) (*models.Reservation, error)
+	CancelReservation(id string) error
+	MarkNoShows(startedBefore time.Time) (int64, error)
+}
+
+type reservationRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewReservationRepository creates a new reservation repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - ReservationRepository: an implementation of the ReservationRepository interface.
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateReservation books a bike for the window of the reservation.
+//
+// The bike row is locked for the duration of the transaction, so concurrent reservations and
+// rentals of the same bike are serialized and cannot both pass the overlap checks.
+//
+// Parameters:
+// - reservation: a pointer to a models.Reservation object with the bike and window to book.
+// - rentalBuffer: how far ahead an active rental of the bike blocks new reservations.
+//
+// Returns:
+// - error: an error if the bike is not found, not reservable, already booked for the window,
+// or the reservation could not be created.
+func (r *reservationRepositoryImp) CreateReservation(reservation *models.Reservation, rentalBuffer time.Duration) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var bike models.Bike
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bike, "id = ?", reservation.BikeID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("bike not found")
+			}
+
+			return err
+		}
+
+		if err := checkReservationConflicts(tx, &bike, reservation, rentalBuffer); err != nil {
+			return err
+		}
+
+		return tx.Create(reservation).Error
+	})
+}
+
+// CreateStationReservation books any bike of the station of the reservation that is free for
+// its window, and stores the chosen bike in the reservation.
+//
+// Parameters:
+// - reservation: a pointer to a models.Reservation object with the station and window to book.
+// - rentalBuffer: how far ahead an active rental of a bike blocks new reservations.
+//
+// Returns:
+// - error: an error if the station has no bike free for the window, or the reservation could
+// not be created.
+func (r *reservationRepositoryImp) CreateStationReservation(reservation *models.Reservation, rentalBuffer time.Duration) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var bikes []models.Bike
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("station_id = ?", reservation.StationID).
+			Order("id").
+			Find(&bikes).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range bikes {
+			var conflict *reservationConflictError
+
+			err := checkReservationConflicts(tx, &bikes[i], reservation, rentalBuffer)
+			if errors.As(err, &conflict) {
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			reservation.BikeID = bikes[i].ID
+
+			return tx.Create(reservation).Error
+		}
+
+		return fmt.Errorf("no bike is available at this station for this period")
+	})
+}
+
+// reservationConflictError is returned when a specific bike cannot be reserved, so station
+// reservations can move on to the next bike.
+type reservationConflictError struct {
+	message string
+}
+
+func (e *reservationConflictError) Error() string {
+	return e.message
+}
+
+// checkReservationConflicts makes sure a locked bike can be reserved for the window of the
+// reservation, and that the rider has no other reservation overlapping it.
+func checkReservationConflicts(tx *gorm.DB, bike *models.Bike, reservation *models.Reservation, rentalBuffer time.Duration) error {
+	if bike.Status == models.BIKE_STATUS_MAINTENANCE || bike.Status == models.BIKE_STATUS_NOTAVAILABLE {
+		return &reservationConflictError{"bike is not available for reservations"}
+	}
+
+	var overlapping int64
+	err := tx.Model(&models.Reservation{}).
+		Where("user_id = ? AND status = ?", reservation.UserID, models.RESERVATION_STATUS_PENDING).
+		Where("start_time < ? AND end_time > ?", reservation.EndTime, reservation.StartTime).
+		Count(&overlapping).Error
+	if err != nil {
+		return err
+	}
+
+	if overlapping > 0 {
+		return fmt.Errorf("you already have a reservation for this period")
+	}
+
+	err = tx.Model(&models.Reservation{}).
+		Where("bike_id = ? AND status = ?", bike.ID, models.RESERVATION_STATUS_PENDING).
+		Where("start_time < ? AND end_time > ?", reservation.EndTime, reservation.StartTime).
+		Count(&overlapping).Error
+	if err != nil {
+		return err
+	}
+
+	if overlapping > 0 {
+		return &reservationConflictError{"bike is already reserved for this period"}
+	}
+
+	// Active rentals have no end yet: they are assumed to last at least until the buffer.
+	if reservation.StartTime.Before(time.Now().Add(rentalBuffer)) {
+		err = tx.Model(&models.Rental{}).
+			Where("bike_id = ? AND status IN ?", bike.ID, models.RentalInProgressStatuses).
+			Count(&overlapping).Error
+		if err != nil {
+			return err
+		}
+
+		if overlapping > 0 {
+			return &reservationConflictError{"bike is rented and may not be back in time"}
+		}
+	}
+
+	return nil
+}
+
+// GetReservationsByUserID retrieves the reservations of a user, most recent first.
+//
+// Parameters:
+// - userID: the ID of the user.
+// - status: the status of the reservations to retrieve, or an empty string for every status.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Reservation: a pointer to a slice of models.Reservation.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *reservationRepositoryImp) GetReservationsByUserID(userID string, status string, pagination pkg.Pagination) (*[]models.Reservation, *pkg.Pagination, error) {
+	return r.findReservations(r.db.Where("user_id = ?", userID).Session(&gorm.Session{}), status, pagination)
+}
+
+// GetAllReservations retrieves every reservation, most recent first.
+//
+// Parameters:
+// - status: the status of the reservations to retrieve, or an empty string for every status.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Reservation: a pointer to a slice of models.Reservation.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *reservationRepositoryImp) GetAllReservations(status string, pagination pkg.Pagination) (*[]models.Reservation, *pkg.Pagination, error) {
+	return r.findReservations(r.db, status, pagination)
+}
+
+func (r *reservationRepositoryImp) findReservations(query *gorm.DB, status string, pagination pkg.Pagination) (*[]models.Reservation, *pkg.Pagination, error) {
+	var reservations []models.Reservation
+
+	if status != "" {
+		query = query.Where("status = ?", status).Session(&gorm.Session{})
+	}
+
+	err := query.Scopes(pkg.Paginate(&models.Reservation{}, &pagination, query)).Order("start_time DESC").Find(&reservations).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &reservations, &pagination, nil
+}
+
+// GetReservationByID retrieves a reservation from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the reservation to retrieve.
+//
+// Returns:
+// - *models.Reservation: a pointer to the reservation if found, or nil if not found.
+// - error: an error if there was a problem retrieving the reservation, or nil if successful.
+func (r *reservationRepositoryImp) GetReservationByID(id string) (*models.Reservation, error) {
+	var reservation models.Reservation
+
+	if err := r.db.First(&reservation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("reservation not found")
+		}
+
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// CancelReservation cancels a pending reservation.
+//
+// Parameters:
+// - id: the ID of the reservation to cancel.
+//
+// Returns:
+// - error: an error if the reservation is no longer pending or could not be updated.
+func (r *reservationRepositoryImp) CancelReservation(id string) error {
+	result := r.db.Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", id, models.RESERVATION_STATUS_PENDING).
+		Updates(map[string]interface{}{"status": models.RESERVATION_STATUS_CANCELLED, "cancelled_at": time.Now()})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("reservation is not pending")
+	}
+
+	return nil
+}
+
+// MarkNoShows marks as no-shows the pending reservations that should have started before the
+// given time.
+//
+// Parameters:
+// - startedBefore: reservations starting before this time are no-shows.
+//
+// Returns:
+// - int64: the number of reservations marked.
+// - error: an error if the reservations could not be updated.
+func (r *reservationRepositoryImp) MarkNoShows(startedBefore time.Time) (int64, error) {
+	result := r.db.Model(&models.Reservation{}).
+		Where("status = ? AND start_time < ?", models.RESERVATION_STATUS_PENDING, startedBefore).
+		Update("status", models.RESERVATION_STATUS_NO_SHOW)
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"gorm.io/gorm"
+)
+
+type StationRepository interface {
+	CreateStation(station *models.Station) error
+	GetAllStations(city string, pagination pkg.Pagination) (*[]models.Station, *pkg.Pagination, error)
+	GetStationByID(id string) (*models.Station, error)
+	GetStationBikes(id string) (*[]models.Bike, error)
+	UpdateStation(station *models.Station) error
+	DeleteStation(id string) error
+}
+
+type stationRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewStationRepository creates a new station repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - StationRepository: an implementation of the StationRepository interface.
+func NewStationRepository(db *gorm.DB) StationRepository {
+	return &stationRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateStation creates a new station in the database.
+//
+// Parameters:
+// - station: a pointer to a models.Station object representing the station to be created.
+//
+// Returns:
+// - error: an error if there was a problem creating the station, or nil if the station was created successfully.
+func (r *stationRepositoryImp) CreateStation(station *models.Station) error {
+	return r.db.Create(station).Error
+}
+
+// GetAllStations retrieves the stations from the database, optionally filtered by city.
+//
+// Parameters:
+// - city: the city of the stations to retrieve, or an empty string for every city.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.Station: a pointer to a slice of models.Station.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *stationRepositoryImp) GetAllStations(city string, pagination pkg.Pagination) (*[]models.Station, *pkg.Pagination, error) {
+	var stations []models.Station
+
+	query := r.db
+	if city != "" {
+		query = query.Where("LOWER(city) = LOWER(?)", city).Session(&gorm.Session{})
+	}
+
+	err := query.Scopes(pkg.Paginate(&models.Station{}, &pagination, query)).Order("city, name").Find(&stations).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &stations, &pagination, nil
+}
+
+// GetStationByID retrieves a station from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the station to retrieve.
+//
+// Returns:
+// - *models.Station: a pointer to the station if found, or nil if not found.
+// - error: an error if there was a problem retrieving the station, or nil if successful.
+func (r *stationRepositoryImp) GetStationByID(id string) (*models.Station, error) {
+	var station models.Station
+
+	if err := r.db.First(&station, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("station not found")
+		}
+
+		return nil, err
+	}
+
+	return &station, nil
+}
+
+// GetStationBikes retrieves the bikes parked at a station.
+//
+// Parameters:
+// - id: the ID of the station.
+//
+// Returns:
+// - *[]models.Bike: a pointer to a slice of models.Bike.
+// - error: an error if any.
+func (r *stationRepositoryImp) GetStationBikes(id string) (*[]models.Bike, error) {
+	var bikes []models.Bike
+
+	if err := r.db.Preload("Model").Where("station_id = ?", id).Order("code").Find(&bikes).Error; err != nil {
+		return nil, err
+	}
+
+	return &bikes, nil
+}
+
+// UpdateStation updates a station in the database.
+//
+// Parameters:
+// - station: a pointer to a models.Station object representing the station to be updated.
+//
+// Returns:
+// - error: an error if the station is not found or could not be updated.
+func (r *stationRepositoryImp) UpdateStation(station *models.Station) error {
+	result := r.db.Model(&models.Station{}).Omit("ID", "CreatedAt").Where("id = ?", station.ID).Updates(station)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("station not found")
+	}
+
+	return nil
+}
+
+// DeleteStation deletes a station that no longer has bikes.
+//
+// Parameters:
+// - id: the ID of the station to be deleted.
+//
+// Returns:
+// - error: an error if the station is not found, still has bikes, or could not be deleted.
+func (r *stationRepositoryImp) DeleteStation(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var bikes int64
+		if err := tx.Model(&models.Bike{}).Where("station_id = ?", id).Count(&bikes).Error; err != nil {
+			return err
+		}
+
+		if bikes > 0 {
+			return fmt.Errorf("station still has %d bikes", bikes)
+		}
+
+		result := tx.Where("id = ?", id).Delete(&models.Station{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("station not found")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+)
+
+type TaxRepository interface {
+	CreateTaxRate(rate *models.TaxRate) error
+	GetTaxRates(country string) (*[]models.TaxRate, error)
+	GetTaxRateByID(id string) (*models.TaxRate, error)
+	DeleteTaxRate(id string, now time.Time) error
+	GetRevenue(from time.Time, to time.Time) (*[]models.RevenueRow, *[]models.RevenueTaxRow, error)
+}
+
+type taxRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewTaxRepository creates a new tax repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - TaxRepository: an implementation of the TaxRepository interface.
+func NewTaxRepository(db *gorm.DB) TaxRepository {
+	return &taxRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateTaxRate adds a tax rate, or a new version of a rate.
+//
+// Parameters:
+// - rate: a pointer to a models.TaxRate object representing the rate to be added.
+//
+// Returns:
+// - error: an error if a version of the rate already takes effect at the same time, or the rate could not be added.
+func (r *taxRepositoryImp) CreateTaxRate(rate *models.TaxRate) error {
+	err := r.db.Create(rate).Error
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("tax rate already exists")
+	}
+
+	return err
+}
+
+// GetTaxRates retrieves every version of the tax rates, of every country or of one of them, the
+// latest version of each rate first.
+//
+// Parameters:
+// - country: the ISO country code, or an empty string for every country.
+//
+// Returns:
+// - *[]models.TaxRate: a pointer to a slice of models.TaxRate.
+// - error: an error if any.
+func (r *taxRepositoryImp) GetTaxRates(country string) (*[]models.TaxRate, error) {
+	var rates []models.TaxRate
+
+	query := r.db.Order("country, city, name, effective_from DESC")
+	if country != "" {
+		query = query.Where("country = ?", country)
+	}
+
+	if err := query.Find(&rates).Error; err != nil {
+		return nil, err
+	}
+
+	return &rates, nil
+}
+
+// GetTaxRateByID retrieves a tax rate by its ID.
+//
+// Parameters:
+// - id: the ID of the rate to retrieve.
+//
+// Returns:
+// - *models.TaxRate: a pointer to the rate if found.
+// - error: an error if the rate is not found or could not be retrieved.
+func (r *taxRepositoryImp) GetTaxRateByID(id string) (*models.TaxRate, error) {
+	var rate models.TaxRate
+
+	if err := r.db.First(&rate, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tax rate not found")
+		}
+
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// DeleteTaxRate deletes a tax rate that is not in force yet. Rates that took effect may have
+// been charged, so they are kept: a new version replaces them instead.
+//
+// Parameters:
+// - id: the ID of the rate to be deleted.
+// - now: the current time.
+//
+// Returns:
+// - error: an error if the rate is not found, already took effect or could not be deleted.
+func (r *taxRepositoryImp) DeleteTaxRate(id string, now time.Time) error {
+	result := r.db.Where("id = ? AND effective_from > ?", id, now).Delete(&models.TaxRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := r.GetTaxRateByID(id); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("tax rate already in force")
+	}
+
+	return nil
+}
+
+// GetRevenue sums the rentals completed over a period, per currency, and the taxes charged on
+// them, per rate.
+//
+// Parameters:
+// - from: the start of the period, included.
+// - to: the end of the period, excluded.
+//
+// Returns:
+// - *[]models.RevenueRow: a pointer to a slice of models.RevenueRow ordered by currency.
+// - *[]models.RevenueTaxRow: a pointer to a slice of models.RevenueTaxRow ordered by jurisdiction and rate.
+// - error: an error if any.
+func (r *taxRepositoryImp) GetRevenue(from time.Time, to time.Time) (*[]models.RevenueRow, *[]models.RevenueTaxRow, error) {
+	rentals := r.db.Model(&models.Rental{}).
+		Where("status = ? AND end_time >= ? AND end_time < ? AND total_cost_currency <> ''", models.RENTAL_STATUS_COMPLETED, from, to)
+
+	var revenue []struct {
+		Currency string
+		Rentals  int64
+		Gross    int64
+		Taxes    int64
+	}
+
+	err := rentals.Session(&gorm.Session{}).
+		Select("total_cost_currency AS currency, COUNT(*) AS rentals, SUM(total_cost_amount) AS gross, SUM(tax_total_amount) AS taxes").
+		Group("total_cost_currency").
+		Order("total_cost_currency").
+		Scan(&revenue).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var taxes []struct {
+		Name      string
+		Country   string
+		City      string
+		Percent   float64
+		Inclusive bool
+		Currency  string
+		Rentals   int64
+		Base      int64
+		Amount    int64
+	}
+
+	err = r.db.Model(&models.RentalTaxLine{}).
+		Select("name, country, city, percent, inclusive, amount_currency AS currency, COUNT(*) AS rentals, SUM(base_amount) AS base, SUM(amount_amount) AS amount").
+		Where("rental_id IN (?)", rentals.Session(&gorm.Session{}).Select("id")).
+		Group("name, country, city, percent, inclusive, amount_currency").
+		Order("country, city, name, percent, amount_currency").
+		Scan(&taxes).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revenueRows := make([]models.RevenueRow, 0, len(revenue))
+	for _, row := range revenue {
+		revenueRows = append(revenueRows, models.RevenueRow{
+			Currency: row.Currency,
+			Rentals:  row.Rentals,
+			Gross:    money.New(row.Gross, row.Currency),
+			Taxes:    money.New(row.Taxes, row.Currency),
+			Net:      money.New(row.Gross-row.Taxes, row.Currency),
+		})
+	}
+
+	taxRows := make([]models.RevenueTaxRow, 0, len(taxes))
+	for _, row := range taxes {
+		taxRows = append(taxRows, models.RevenueTaxRow{
+			Name:      row.Name,
+			Country:   row.Country,
+			City:      row.City,
+			Percent:   row.Percent,
+			Inclusive: row.Inclusive,
+			Rentals:   row.Rentals,
+			Base:      money.New(row.Base, row.Currency),
+			Amount:    money.New(row.Amount, row.Currency),
+		})
+	}
+
+	return &revenueRows, &taxRows, nil
+}
@@ -0,0 +1,454 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WalletRepository interface {
+	GetWalletSnapshot(userID string, pagination pkg.Pagination) (*models.WalletSnapshot, *pkg.Pagination, error)
+	TopUpWallet(userID uuid.UUID, amount money.Money) (*models.LedgerTransaction, error)
+	AdjustWallet(userID uuid.UUID, amount money.Money, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error)
+	RefundRental(rentalID string, amount *money.Money, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error)
+	ReverseLedgerTransaction(id string, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error)
+	GetLedgerTransactions(userID string, rentalID string, pagination pkg.Pagination) (*[]models.LedgerTransaction, *pkg.Pagination, error)
+	GetUserByID(id string) (*models.User, error)
+}
+
+type walletRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewWalletRepository creates a new wallet repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - WalletRepository: an implementation of the WalletRepository interface.
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepositoryImp{
+		db: db,
+	}
+}
+
+// GetWalletSnapshot reads the balances of the wallet of a rider, one per currency, and its latest
+// entries with their transactions. Everything is read in a single repeatable read transaction, so
+// the balances and the entries match even while new entries are posted.
+//
+// Parameters:
+// - userID: the ID of the rider.
+// - pagination: a pkg.Pagination object representing the pagination settings of the entries.
+// Returns:
+// - *models.WalletSnapshot: a pointer to the snapshot of the wallet.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *walletRepositoryImp) GetWalletSnapshot(userID string, pagination pkg.Pagination) (*models.WalletSnapshot, *pkg.Pagination, error) {
+	snapshot := &models.WalletSnapshot{Balances: []money.Money{}, Entries: []models.LedgerEntry{}}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT now()").Scan(&snapshot.AsOf).Error; err != nil {
+			return err
+		}
+
+		accounts := tx.Model(&models.LedgerAccount{}).Select("id").Where("type = ? AND user_id = ?", models.LEDGER_ACCOUNT_WALLET, userID)
+
+		var balances []struct {
+			Currency string
+			Amount   int64
+		}
+
+		err := tx.Model(&models.LedgerEntry{}).
+			Select("amount_currency AS currency, SUM(CASE WHEN direction = ? THEN amount_amount ELSE -amount_amount END) AS amount", models.LEDGER_ENTRY_CREDIT).
+			Where("account_id IN (?)", accounts).
+			Group("amount_currency").
+			Order("amount_currency").
+			Scan(&balances).Error
+		if err != nil {
+			return err
+		}
+
+		for _, balance := range balances {
+			snapshot.Balances = append(snapshot.Balances, money.New(balance.Amount, balance.Currency))
+		}
+
+		query := tx.Where("account_id IN (?)", accounts).Session(&gorm.Session{})
+
+		return query.Scopes(pkg.Paginate(&models.LedgerEntry{}, &pagination, query)).
+			Preload("Transaction").
+			Order("created_at DESC, id").
+			Find(&snapshot.Entries).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot.UserID, _ = uuid.Parse(userID)
+
+	return snapshot, &pagination, nil
+}
+
+// TopUpWallet credits the wallet of a rider with money paid in, from the funding account.
+//
+// Parameters:
+// - userID: the ID of the rider.
+// - amount: the positive amount paid in.
+//
+// Returns:
+// - *models.LedgerTransaction: a pointer to the posted transaction.
+// - error: an error if the transaction could not be posted.
+func (r *walletRepositoryImp) TopUpWallet(userID uuid.UUID, amount money.Money) (*models.LedgerTransaction, error) {
+	transaction := &models.LedgerTransaction{
+		ID:          uuid.Must(uuid.NewRandom()),
+		Type:        models.LEDGER_TRANSACTION_TOP_UP,
+		Description: "Wallet top-up",
+		CreatedByID: &userID,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		entries, err := transferEntries(tx, models.LEDGER_ACCOUNT_FUNDING, nil, models.LEDGER_ACCOUNT_WALLET, &userID, amount)
+		if err != nil {
+			return err
+		}
+
+		return postLedgerTransaction(tx, transaction, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// AdjustWallet credits the wallet of a rider with a positive amount, or debits it with a
+// negative one, against the adjustments account.
+//
+// Parameters:
+// - userID: the ID of the rider.
+// - amount: the non-zero amount of the adjustment.
+// - reason: why the wallet is adjusted.
+// - createdByID: the ID of the admin making the adjustment.
+//
+// Returns:
+// - *models.LedgerTransaction: a pointer to the posted transaction.
+// - error: an error if the transaction could not be posted.
+func (r *walletRepositoryImp) AdjustWallet(userID uuid.UUID, amount money.Money, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error) {
+	transaction := &models.LedgerTransaction{
+		ID:          uuid.Must(uuid.NewRandom()),
+		Type:        models.LEDGER_TRANSACTION_ADJUSTMENT,
+		Description: "Wallet adjustment",
+		Reason:      reason,
+		CreatedByID: &createdByID,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var entries []models.LedgerEntry
+		var err error
+
+		if amount.IsNegative() {
+			entries, err = transferEntries(tx, models.LEDGER_ACCOUNT_WALLET, &userID, models.LEDGER_ACCOUNT_ADJUSTMENTS, nil, amount.Neg())
+		} else {
+			entries, err = transferEntries(tx, models.LEDGER_ACCOUNT_ADJUSTMENTS, nil, models.LEDGER_ACCOUNT_WALLET, &userID, amount)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return postLedgerTransaction(tx, transaction, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// RefundRental credits back to the wallet of its rider some or all of what a rental was charged.
+// The rental row is locked, so concurrent refunds cannot refund more than was charged.
+//
+// Parameters:
+// - rentalID: the ID of the rental.
+// - amount: the amount to refund, or nil to refund everything not refunded yet.
+// - reason: why the rental is refunded.
+// - createdByID: the ID of the admin making the refund.
+//
+// Returns:
+// - *models.LedgerTransaction: a pointer to the posted transaction.
+// - error: an error if the rental is not found, the amount exceeds what is left to refund, or
+// the transaction could not be posted.
+func (r *walletRepositoryImp) RefundRental(rentalID string, amount *money.Money, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error) {
+	var transaction *models.LedgerTransaction
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rental models.Rental
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, "id = ?", rentalID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("rental not found")
+			}
+
+			return err
+		}
+
+		var err error
+		transaction, err = refundRental(tx, rental, amount, reason, createdByID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// ReverseLedgerTransaction posts a reversal of a transaction, which mirrors its entries. A
+// transaction can be reversed once, and reversals cannot be reversed.
+//
+// Parameters:
+// - id: the ID of the transaction to reverse.
+// - reason: why the transaction is reversed.
+// - createdByID: the ID of the admin reversing the transaction.
+//
+// Returns:
+// - *models.LedgerTransaction: a pointer to the posted reversal.
+// - error: an error if the transaction is not found, cannot be reversed, or the reversal could
+// not be posted.
+func (r *walletRepositoryImp) ReverseLedgerTransaction(id string, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error) {
+	var reversal *models.LedgerTransaction
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var original models.LedgerTransaction
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, "id = ?", id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("ledger transaction not found")
+			}
+
+			return err
+		}
+
+		if original.Type == models.LEDGER_TRANSACTION_REVERSAL {
+			return fmt.Errorf("a reversal cannot be reversed")
+		}
+
+		var count int64
+		if err := tx.Model(&models.LedgerTransaction{}).Where("reversal_of_id = ?", original.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("ledger transaction already reversed")
+		}
+
+		var entries []models.LedgerEntry
+		if err := tx.Where("transaction_id = ?", original.ID).Find(&entries).Error; err != nil {
+			return err
+		}
+
+		mirrored := make([]models.LedgerEntry, 0, len(entries))
+		for _, entry := range entries {
+			direction := models.LEDGER_ENTRY_DEBIT
+			if entry.Direction == models.LEDGER_ENTRY_DEBIT {
+				direction = models.LEDGER_ENTRY_CREDIT
+			}
+
+			mirrored = append(mirrored, models.LedgerEntry{AccountID: entry.AccountID, Direction: direction, Amount: entry.Amount})
+		}
+
+		reversal = &models.LedgerTransaction{
+			ID:           uuid.Must(uuid.NewRandom()),
+			Type:         models.LEDGER_TRANSACTION_REVERSAL,
+			Description:  "Reversal of " + string(original.Type),
+			Reason:       reason,
+			RentalID:     original.RentalID,
+			ReversalOfID: &original.ID,
+			CreatedByID:  &createdByID,
+		}
+
+		return postLedgerTransaction(tx, reversal, mirrored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+// GetLedgerTransactions retrieves ledger transactions with their entries, the most recent first,
+// optionally only those touching the wallet of a rider or about a rental.
+//
+// Parameters:
+// - userID: the ID of the rider, or an empty string.
+// - rentalID: the ID of the rental, or an empty string.
+// - pagination: a pkg.Pagination object representing the pagination settings.
+// Returns:
+// - *[]models.LedgerTransaction: a pointer to a slice of models.LedgerTransaction.
+// - *pkg.Pagination: a pointer to the pagination parameter.
+// - error: an error if any.
+func (r *walletRepositoryImp) GetLedgerTransactions(userID string, rentalID string, pagination pkg.Pagination) (*[]models.LedgerTransaction, *pkg.Pagination, error) {
+	var transactions []models.LedgerTransaction
+
+	query := r.db
+	if userID != "" {
+		accounts := r.db.Model(&models.LedgerAccount{}).Select("id").Where("user_id = ?", userID)
+		entries := r.db.Model(&models.LedgerEntry{}).Select("transaction_id").Where("account_id IN (?)", accounts)
+		query = query.Where("id IN (?)", entries)
+	}
+
+	if rentalID != "" {
+		query = query.Where("rental_id = ?", rentalID)
+	}
+
+	query = query.Session(&gorm.Session{})
+
+	err := query.Scopes(pkg.Paginate(&models.LedgerTransaction{}, &pagination, query)).
+		Preload("Entries").
+		Order("created_at DESC, id").
+		Find(&transactions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &transactions, &pagination, nil
+}
+
+// GetUserByID retrieves a user from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the user to retrieve.
+//
+// Returns:
+// - *models.User: a pointer to the user if found, or nil if not found.
+// - error: an error if there was a problem retrieving the user, or nil if successful.
+func (r *walletRepositoryImp) GetUserByID(id string) (*models.User, error) {
+	var user models.User
+
+	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// rentalNetCharge returns the revenue a rental brought in, that is what its rider was charged
+// for it less what was already refunded or reversed, to the wallet or to a card.
+func rentalNetCharge(tx *gorm.DB, rental models.Rental) (money.Money, error) {
+	var net struct {
+		Amount int64
+	}
+
+	transactions := tx.Model(&models.LedgerTransaction{}).Select("id").Where("rental_id = ?", rental.ID)
+	accounts := tx.Model(&models.LedgerAccount{}).Select("id").Where("type = ?", models.LEDGER_ACCOUNT_REVENUE)
+
+	err := tx.Model(&models.LedgerEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount_amount ELSE -amount_amount END), 0) AS amount", models.LEDGER_ENTRY_CREDIT).
+		Where("transaction_id IN (?) AND account_id IN (?) AND amount_currency = ?", transactions, accounts, rental.TotalCost.Currency).
+		Scan(&net).Error
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	return money.New(net.Amount, rental.TotalCost.Currency), nil
+}
+
+// refundRental credits back to the wallet of the rider of a locked rental some or all of what
+// it was charged, or everything not refunded yet when amount is nil.
+func refundRental(tx *gorm.DB, rental models.Rental, amount *money.Money, reason string, createdByID uuid.UUID) (*models.LedgerTransaction, error) {
+	charged, err := rentalNetCharge(tx, rental)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := charged
+	if amount != nil {
+		if amount.Currency != charged.Currency {
+			return nil, fmt.Errorf("refund currency must be %s", charged.Currency)
+		}
+
+		refund = *amount
+	}
+
+	if charged.IsZero() || charged.IsNegative() {
+		return nil, fmt.Errorf("rental has nothing left to refund")
+	}
+
+	if refund.Cmp(charged) > 0 {
+		return nil, fmt.Errorf("refund exceeds the amount left to refund of %s", charged)
+	}
+
+	entries, err := transferEntries(tx, models.LEDGER_ACCOUNT_REVENUE, nil, models.LEDGER_ACCOUNT_WALLET, &rental.UserID, refund)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.LedgerTransaction{
+		ID:          uuid.Must(uuid.NewRandom()),
+		Type:        models.LEDGER_TRANSACTION_REFUND,
+		Description: "Rental refund",
+		Reason:      reason,
+		RentalID:    &rental.ID,
+		CreatedByID: &createdByID,
+	}
+
+	if err := postLedgerTransaction(tx, transaction, entries); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// chargeRental debits the wallet of the rider of a completed rental with its total cost, in
+// favor of the revenue account. Free rentals are not posted, and a rental is charged once.
+func chargeRental(tx *gorm.DB, rental *models.Rental) error {
+	if rental.TotalCost.IsZero() || rental.TotalCost.IsNegative() {
+		return nil
+	}
+
+	entries, err := transferEntries(tx, models.LEDGER_ACCOUNT_WALLET, &rental.UserID, models.LEDGER_ACCOUNT_REVENUE, nil, rental.TotalCost)
+	if err != nil {
+		return err
+	}
+
+	key := rentalChargeKey(rental.ID)
+	transaction := &models.LedgerTransaction{
+		ID:             uuid.Must(uuid.NewRandom()),
+		Type:           models.LEDGER_TRANSACTION_RENTAL_CHARGE,
+		Description:    "Rental charge",
+		RentalID:       &rental.ID,
+		IdempotencyKey: &key,
+	}
+
+	return postLedgerTransaction(tx, transaction, entries)
+}
+
+// rentalChargeID returns the ID of the transaction that charged a rental, or nil when the rental
+// was free.
+func rentalChargeID(tx *gorm.DB, rentalID uuid.UUID) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := tx.Model(&models.LedgerTransaction{}).
+		Where("idempotency_key = ?", rentalChargeKey(rentalID)).
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	return &ids[0], nil
+}
+
+// rentalChargeKey returns the idempotency key of the transaction that charges a rental.
+func rentalChargeKey(rentalID uuid.UUID) string {
+	return "rental_charge:" + rentalID.String()
+}
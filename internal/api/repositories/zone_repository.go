@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"gorm.io/gorm"
+)
+
+type ZoneRepository interface {
+	CreateZone(zone *models.Zone) error
+	CreateZones(zones []models.Zone) error
+	GetAllZones(zoneType string) (*[]models.Zone, error)
+	GetZoneByID(id string) (*models.Zone, error)
+	UpdateZone(zone *models.Zone) error
+	DeleteZone(id string) error
+}
+
+type zoneRepositoryImp struct {
+	db *gorm.DB
+}
+
+// NewZoneRepository creates a new zone repository instance.
+//
+// Parameters:
+// - db: a pointer to a gorm.DB object representing the database connection.
+// Returns:
+// - ZoneRepository: an implementation of the ZoneRepository interface.
+func NewZoneRepository(db *gorm.DB) ZoneRepository {
+	return &zoneRepositoryImp{
+		db: db,
+	}
+}
+
+// CreateZone creates a new zone in the database.
+//
+// Parameters:
+// - zone: a pointer to a models.Zone object representing the zone to be created.
+//
+// Returns:
+// - error: an error if there was a problem creating the zone, or nil if the zone was created successfully.
+func (r *zoneRepositoryImp) CreateZone(zone *models.Zone) error {
+	return r.db.Create(zone).Error
+}
+
+// CreateZones creates several zones in a single transaction, so either all of them are
+// created or none is.
+//
+// Parameters:
+// - zones: the zones to be created.
+//
+// Returns:
+// - error: an error if any zone could not be created.
+func (r *zoneRepositoryImp) CreateZones(zones []models.Zone) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&zones).Error
+	})
+}
+
+// GetAllZones retrieves the zones from the database, optionally filtered by type.
+//
+// Parameters:
+// - zoneType: the type of the zones to retrieve, or an empty string for every type.
+//
+// Returns:
+// - *[]models.Zone: a pointer to a slice of models.Zone.
+// - error: an error if any.
+func (r *zoneRepositoryImp) GetAllZones(zoneType string) (*[]models.Zone, error) {
+	var zones []models.Zone
+
+	query := r.db.Order("name")
+	if zoneType != "" {
+		query = query.Where("type = ?", zoneType)
+	}
+
+	if err := query.Find(&zones).Error; err != nil {
+		return nil, err
+	}
+
+	return &zones, nil
+}
+
+// GetZoneByID retrieves a zone from the database by its ID.
+//
+// Parameters:
+// - id: the ID of the zone to retrieve.
+//
+// Returns:
+// - *models.Zone: a pointer to the zone if found, or nil if not found.
+// - error: an error if there was a problem retrieving the zone, or nil if successful.
+func (r *zoneRepositoryImp) GetZoneByID(id string) (*models.Zone, error) {
+	var zone models.Zone
+
+	if err := r.db.First(&zone, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("zone not found")
+		}
+
+		return nil, err
+	}
+
+	return &zone, nil
+}
+
+// UpdateZone replaces the editable fields of a zone.
+//
+// Parameters:
+// - zone: a pointer to a models.Zone object representing the zone to be updated.
+//
+// Returns:
+// - error: an error if the zone is not found or could not be updated.
+func (r *zoneRepositoryImp) UpdateZone(zone *models.Zone) error {
+	result := r.db.Model(&models.Zone{}).
+		Where("id = ?", zone.ID).
+		Select("Name", "Type", "Geometry", "fee_amount", "fee_currency", "SpeedLimitKmh").
+		Updates(zone)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("zone not found")
+	}
+
+	return nil
+}
+
+// DeleteZone deletes a zone from the database based on its ID.
+//
+// Parameters:
+// - id: the ID of the zone to be deleted.
+//
+// Returns:
+// - error: an error if the zone is not found or could not be deleted.
+func (r *zoneRepositoryImp) DeleteZone(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.Zone{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("zone not found")
+	}
+
+	return nil
+}
@@ -63,7 +63,8 @@ func (s *AuthService) sendVerificationEmail(user *models.User) {
 	}
 }
 
-// CreateUser creates a new user based on the information provided in the request body.
+// CreateUser creates a new user based on the information provided in the request body. The user
+// accepts the current terms of service by sending its version in "terms_version".
 //
 // Parameters:
 // - c: a pointer to the gin.Context object for handling HTTP request and response.
@@ -86,6 +87,14 @@ func (s *AuthService) CreateUser(c *gin.Context) {
 	user.Role = models.UserRoleDefault
 	user.Status = models.UserStatusInactive
 	user.Verified = false
+	user.TermsAcceptedAt = nil
+
+	if version := termsVersion(); version != "" && user.TermsVersion == version {
+		acceptedAt := time.Now()
+		user.TermsAcceptedAt = &acceptedAt
+	} else {
+		user.TermsVersion = ""
+	}
 
 	if err = utils.ValidateModel(user); err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
@@ -0,0 +1,617 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+const (
+	bikeImportMaxBodySize = 10 << 20
+	bikeImportMaxRows     = 5000
+	bikeExportBatchSize   = 200
+)
+
+// bikeCSVColumns lists the columns written by the CSV export. The import accepts the same
+// header, ignoring the read-only columns (id, code and timestamps).
+var bikeCSVColumns = []string{"id", "code", "model_id", "station_id", "serial_number", "frame_number", "purchase_date", "name", "description", "price_per_hour", "currency", "location", "latitude", "longitude", "status", "image", "created_at", "updated_at"}
+
+// BikeImportRowResult reports the outcome of a single row of a bulk import.
+type BikeImportRowResult struct {
+	Row    int        `json:"row"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Status string     `json:"status"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// BikeImportReport is the response body of a bulk import.
+type BikeImportReport struct {
+	DryRun  bool                  `json:"dry_run"`
+	Mode    string                `json:"mode"`
+	Total   int                   `json:"total"`
+	Valid   int                   `json:"valid"`
+	Invalid int                   `json:"invalid"`
+	Created int                   `json:"created"`
+	Rows    []BikeImportRowResult `json:"rows"`
+}
+
+type bikeImportRow struct {
+	bike models.Bike
+	err  error
+}
+
+// ImportBikes creates bikes in bulk from a CSV or JSON payload.
+//
+// The format is taken from the "format" query parameter ("csv" or "json") and falls back to the
+// request Content-Type. Every row is validated with utils.ValidateModel, and a serial number
+// repeated in the batch or already used by a bike is reported on its row. When "dry_run" is true,
+// nothing is persisted. The "mode" query parameter selects between "atomic" (the default, where a
+// single invalid row aborts the whole import) and "partial" (where valid rows are committed and
+// invalid ones are reported).
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeService) ImportBikes(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	mode := c.DefaultQuery("mode", "atomic")
+	if mode != "atomic" && mode != "partial" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "mode must be one of: atomic partial"})
+		return
+	}
+
+	format := bikeBulkFormat(c)
+	if format == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "format must be one of: csv json"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, bikeImportMaxBodySize)
+
+	var rows []bikeImportRow
+	var err error
+	if format == "csv" {
+		rows, err = parseBikesCSV(body)
+	} else {
+		rows, err = parseBikesJSON(body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "no rows to import"})
+		return
+	}
+
+	if len(rows) > bikeImportMaxRows {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("imports are limited to %d rows", bikeImportMaxRows)})
+		return
+	}
+
+	report := BikeImportReport{DryRun: dryRun, Mode: mode, Total: len(rows), Rows: make([]BikeImportRowResult, len(rows))}
+	valid := []int{}
+	knownModels := map[uuid.UUID]error{}
+	knownStations := map[uuid.UUID]error{}
+
+	serialNumbers := []string{}
+	for i := range rows {
+		if rows[i].err == nil && rows[i].bike.SerialNumber != "" {
+			serialNumbers = append(serialNumbers, rows[i].bike.SerialNumber)
+		}
+	}
+
+	existing, err := s.repo.GetExistingSerialNumbers(serialNumbers)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to import bikes"})
+		return
+	}
+
+	// seenSerials maps each serial number to the first row that uses it.
+	seenSerials := map[string]int{}
+	for _, serial := range existing {
+		seenSerials[serial] = 0
+	}
+
+	for i := range rows {
+		result := BikeImportRowResult{Row: i + 1, Status: "valid"}
+
+		if rows[i].err == nil {
+			rows[i].bike.ID = uuid.Must(uuid.NewRandom())
+			rows[i].bike.Model = nil
+
+			code, err := utils.GenerateBikeCode()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to import bikes"})
+				return
+			}
+			rows[i].bike.Code = code
+
+			rows[i].err = utils.ValidateModel(&rows[i].bike)
+		}
+
+		if rows[i].err == nil && rows[i].bike.SerialNumber != "" {
+			if row, seen := seenSerials[rows[i].bike.SerialNumber]; !seen {
+				seenSerials[rows[i].bike.SerialNumber] = i + 1
+			} else if row == 0 {
+				rows[i].err = fmt.Errorf("serial number %s is already used by another bike", rows[i].bike.SerialNumber)
+			} else {
+				rows[i].err = fmt.Errorf("serial number %s is repeated from row %d", rows[i].bike.SerialNumber, row)
+			}
+		}
+
+		if rows[i].err == nil && rows[i].bike.ModelID != nil {
+			modelErr, checked := knownModels[*rows[i].bike.ModelID]
+			if !checked {
+				_, modelErr = s.repo.GetBikeModelByID(rows[i].bike.ModelID.String())
+				knownModels[*rows[i].bike.ModelID] = modelErr
+			}
+
+			if modelErr != nil {
+				if !strings.Contains(modelErr.Error(), "bike model not found") {
+					c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike model"})
+					return
+				}
+
+				rows[i].err = modelErr
+			}
+		}
+
+		if rows[i].err == nil && rows[i].bike.StationID != nil {
+			stationErr, checked := knownStations[*rows[i].bike.StationID]
+			if !checked {
+				_, stationErr = s.repo.GetStationByID(rows[i].bike.StationID.String())
+				knownStations[*rows[i].bike.StationID] = stationErr
+			}
+
+			if stationErr != nil {
+				if !strings.Contains(stationErr.Error(), "station not found") {
+					c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get station"})
+					return
+				}
+
+				rows[i].err = stationErr
+			}
+		}
+
+		if rows[i].err != nil {
+			result.Status = "invalid"
+			result.Error = rows[i].err.Error()
+			report.Invalid++
+		} else {
+			result.ID = &rows[i].bike.ID
+			report.Valid++
+			valid = append(valid, i)
+		}
+
+		report.Rows[i] = result
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	if mode == "atomic" {
+		if report.Invalid > 0 {
+			c.JSON(http.StatusUnprocessableEntity, report)
+			return
+		}
+
+		bikes := make([]models.Bike, len(rows))
+		for i := range rows {
+			bikes[i] = rows[i].bike
+		}
+
+		if err := s.repo.CreateBikes(bikes); err != nil {
+			var rowErr *repositories.BikeRowError
+			if errors.As(err, &rowErr) && errors.Is(err, repositories.ErrDuplicateSerialNumber) && rowErr.Row > 0 && rowErr.Row <= len(rows) {
+				row := rowErr.Row - 1
+				report.Rows[row].Status = "invalid"
+				report.Rows[row].Error = fmt.Sprintf("serial number %s is already used by another bike", rows[row].bike.SerialNumber)
+				report.Rows[row].ID = nil
+				report.Valid--
+				report.Invalid++
+
+				c.JSON(http.StatusUnprocessableEntity, report)
+				return
+			}
+
+			slog.Error("failed to import bikes", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to import bikes"})
+			return
+		}
+
+		for i := range report.Rows {
+			report.Rows[i].Status = "created"
+		}
+		report.Created = len(bikes)
+
+		c.JSON(http.StatusCreated, report)
+		return
+	}
+
+	for _, i := range valid {
+		if err := s.repo.CreateBike(&rows[i].bike); err != nil {
+			report.Rows[i].Status = "failed"
+			report.Rows[i].Error = "an error occurred when trying to create the bike"
+
+			if errors.Is(err, repositories.ErrDuplicateSerialNumber) {
+				report.Rows[i].Error = fmt.Sprintf("serial number %s is already used by another bike", rows[i].bike.SerialNumber)
+			} else {
+				slog.Error("failed to import bike", "row", i+1, "error", err)
+			}
+			continue
+		}
+
+		report.Rows[i].Status = "created"
+		report.Created++
+	}
+
+	status := http.StatusCreated
+	if report.Created == 0 {
+		status = http.StatusUnprocessableEntity
+	} else if report.Created < report.Total {
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, report)
+}
+
+// ExportBikes streams the whole fleet as CSV or JSON.
+//
+// The format is taken from the "format" query parameter and defaults to JSON. Bikes are read
+// from the repository in batches and flushed to the client as they are loaded.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeService) ExportBikes(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "format must be one of: csv json"})
+		return
+	}
+
+	filename := fmt.Sprintf("bikes-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	var err error
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		err = s.exportBikesCSV(c)
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		err = s.exportBikesJSON(c)
+	}
+
+	if err != nil {
+		slog.Error("failed to export bikes", "error", err)
+		c.Abort()
+	}
+}
+
+func (s *BikeService) exportBikesCSV(c *gin.Context) error {
+	writer := csv.NewWriter(c.Writer)
+
+	if err := writer.Write(bikeCSVColumns); err != nil {
+		return err
+	}
+
+	err := s.repo.StreamBikes(bikeExportBatchSize, func(bikes []models.Bike) error {
+		for _, bike := range bikes {
+			modelID := ""
+			if bike.ModelID != nil {
+				modelID = bike.ModelID.String()
+			}
+
+			stationID := ""
+			if bike.StationID != nil {
+				stationID = bike.StationID.String()
+			}
+
+			purchaseDate := ""
+			if bike.PurchaseDate != nil {
+				purchaseDate = bike.PurchaseDate.Format(time.DateOnly)
+			}
+
+			price := ""
+			if bike.PricePerHour.Currency != "" {
+				price = bike.PricePerHour.Decimal()
+			}
+
+			latitude, longitude := "", ""
+			if bike.Latitude != nil && bike.Longitude != nil {
+				latitude = strconv.FormatFloat(*bike.Latitude, 'f', -1, 64)
+				longitude = strconv.FormatFloat(*bike.Longitude, 'f', -1, 64)
+			}
+
+			record := []string{
+				bike.ID.String(),
+				bike.Code,
+				modelID,
+				stationID,
+				bike.SerialNumber,
+				bike.FrameNumber,
+				purchaseDate,
+				bike.Name,
+				bike.Description,
+				price,
+				bike.PricePerHour.Currency,
+				bike.Location,
+				latitude,
+				longitude,
+				string(bike.Status),
+				bike.Image,
+				bike.CreatedAt.Format(time.RFC3339),
+				bike.UpdatedAt.Format(time.RFC3339),
+			}
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		c.Writer.Flush()
+
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func (s *BikeService) exportBikesJSON(c *gin.Context) error {
+	if _, err := io.WriteString(c.Writer, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := s.repo.StreamBikes(bikeExportBatchSize, func(bikes []models.Bike) error {
+		for _, bike := range bikes {
+			if !first {
+				if _, err := io.WriteString(c.Writer, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			data, err := json.Marshal(bike)
+			if err != nil {
+				return err
+			}
+
+			if _, err := c.Writer.Write(data); err != nil {
+				return err
+			}
+		}
+
+		c.Writer.Flush()
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(c.Writer, "]")
+
+	return err
+}
+
+// bikeBulkFormat resolves the payload format of a bulk request from the "format" query
+// parameter or the Content-Type header. It returns an empty string when the format is unknown.
+func bikeBulkFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		if format == "csv" || format == "json" {
+			return format
+		}
+
+		return ""
+	}
+
+	switch c.ContentType() {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/json":
+		return "json"
+	default:
+		return ""
+	}
+}
+
+// parseBikesCSV reads bikes from a CSV document whose first line is a header naming the columns.
+// Errors that affect a single row are attached to that row; errors that make the whole document
+// unreadable are returned.
+func parseBikesCSV(r io.Reader) ([]bikeImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		return nil, errors.New("invalid csv header")
+	}
+
+	known := map[string]bool{}
+	for _, column := range bikeCSVColumns {
+		known[column] = true
+	}
+
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !known[header[i]] {
+			return nil, fmt.Errorf("unknown column: %s", header[i])
+		}
+	}
+
+	// Allow rows with a different number of fields so the error is reported on the row.
+	reader.FieldsPerRecord = -1
+
+	rows := []bikeImportRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, bikeImportRow{err: errors.New("malformed csv row")})
+				continue
+			}
+
+			return nil, err
+		}
+
+		rows = append(rows, parseBikeCSVRecord(header, record))
+	}
+
+	return rows, nil
+}
+
+func parseBikeCSVRecord(header []string, record []string) bikeImportRow {
+	row := bikeImportRow{}
+
+	if len(record) != len(header) {
+		row.err = fmt.Errorf("expected %d columns, got %d", len(header), len(record))
+		return row
+	}
+
+	price, currency := "", utils.DefaultCurrency()
+
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+
+		switch column {
+		case "model_id":
+			if value == "" {
+				continue
+			}
+
+			modelID, err := uuid.Parse(value)
+			if err != nil {
+				row.err = errors.New("model_id must be a valid UUID")
+				return row
+			}
+			row.bike.ModelID = &modelID
+		case "station_id":
+			if value == "" {
+				continue
+			}
+
+			stationID, err := uuid.Parse(value)
+			if err != nil {
+				row.err = errors.New("station_id must be a valid UUID")
+				return row
+			}
+			row.bike.StationID = &stationID
+		case "serial_number":
+			row.bike.SerialNumber = value
+		case "frame_number":
+			row.bike.FrameNumber = value
+		case "purchase_date":
+			if value == "" {
+				continue
+			}
+
+			purchaseDate, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				row.err = errors.New("purchase_date must be a date in the format YYYY-MM-DD")
+				return row
+			}
+			row.bike.PurchaseDate = &purchaseDate
+		case "name":
+			row.bike.Name = value
+		case "description":
+			row.bike.Description = value
+		case "price_per_hour":
+			price = value
+		case "currency":
+			if value != "" {
+				currency = strings.ToUpper(value)
+			}
+		case "location":
+			row.bike.Location = value
+		case "latitude", "longitude":
+			if value == "" {
+				continue
+			}
+
+			coordinate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				row.err = fmt.Errorf("%s must be a number", column)
+				return row
+			}
+
+			if column == "latitude" {
+				row.bike.Latitude = &coordinate
+			} else {
+				row.bike.Longitude = &coordinate
+			}
+		case "status":
+			row.bike.Status = models.BikeStatusEnum(value)
+		case "image":
+			row.bike.Image = value
+		}
+	}
+
+	// The price is read once every column is known, since it is in the currency of the row.
+	if price != "" {
+		amount, err := money.Parse(price, currency)
+		if err != nil {
+			row.err = fmt.Errorf("price_per_hour: %w", err)
+			return row
+		}
+		row.bike.PricePerHour = amount
+	}
+
+	return row
+}
+
+// parseBikesJSON reads bikes from a JSON array of bike objects. A row that cannot be decoded
+// into a bike is reported on that row.
+func parseBikesJSON(r io.Reader) ([]bikeImportRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, errors.New("invalid body: expected a json array of bikes")
+	}
+
+	rows := make([]bikeImportRow, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &rows[i].bike); err != nil {
+			rows[i].err = errors.New("invalid bike object")
+		}
+	}
+
+	return rows, nil
+}
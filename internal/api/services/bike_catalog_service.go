@@ -0,0 +1,199 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+type BikeCatalogService struct {
+	repo repositories.BikeCatalogRepository
+}
+
+// NewBikeCatalogService creates a new instance of the BikeCatalogService struct.
+//
+// It takes a repositories.BikeCatalogRepository as a parameter and returns a pointer
+// to a BikeCatalogService.
+func NewBikeCatalogService(repo repositories.BikeCatalogRepository) *BikeCatalogService {
+	return &BikeCatalogService{repo: repo}
+}
+
+// CreateBikeModel creates a new catalog model based on the JSON input in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeCatalogService) CreateBikeModel(c *gin.Context) {
+	model := new(models.BikeModel)
+
+	if err := c.BindJSON(model); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	model.ID = uuid.Must(uuid.NewRandom())
+
+	if err := utils.ValidateModel(model); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreateBikeModel(model); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new bike model"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model)
+}
+
+// GetAllBikeModels retrieves the catalog models and returns them in the response.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeCatalogService) GetAllBikeModels(c *gin.Context) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	bikeModels, pagination, err := s.repo.GetAllBikeModels(*pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike models"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bikeModels, "pagination": pagination})
+}
+
+// GetBikeModelByID retrieves a catalog model by its ID and returns it in the response.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeCatalogService) GetBikeModelByID(c *gin.Context) {
+	model, err := s.repo.GetBikeModelByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "bike model not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike model"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model)
+}
+
+// UpdateBikeModel updates a catalog model based on the input JSON data in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeCatalogService) UpdateBikeModel(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "bike model not found"})
+		return
+	}
+
+	var model models.BikeModel
+	if err := c.BindJSON(&model); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	model.ID = id
+
+	if err := s.repo.UpdateBikeModel(&model); err != nil {
+		if strings.Contains(err.Error(), "bike model not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update bike model"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "bike model updated successfully"})
+}
+
+// RepriceBikeModel changes the default hourly price of a catalog model, repricing every bike
+// of that model in one place.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeCatalogService) RepriceBikeModel(c *gin.Context) {
+	var body struct {
+		PricePerHour money.Money `json:"price_per_hour" validate:"required,money=positive"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	bikes, err := s.repo.UpdateBikeModelPrice(c.Param("id"), body.PricePerHour)
+	if err != nil {
+		if strings.Contains(err.Error(), "bike model not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update bike model price"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "bike model price updated successfully", "bikes_affected": bikes})
+}
+
+// DeleteBikeModel deletes a catalog model that is no longer referenced by any bike.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeCatalogService) DeleteBikeModel(c *gin.Context) {
+	if err := s.repo.DeleteBikeModel(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "bike model not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		if strings.Contains(err.Error(), "bike model is in use") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete bike model"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
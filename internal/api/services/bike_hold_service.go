@@ -0,0 +1,231 @@
+package services
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/geo"
+)
+
+// holdSweepInterval is how often active holds are checked for expiry.
+const holdSweepInterval = 15 * time.Second
+
+type BikeHoldService struct {
+	repo repositories.BikeHoldRepository
+}
+
+// NewBikeHoldService creates a new instance of the BikeHoldService struct.
+//
+// It takes a repositories.BikeHoldRepository as a parameter and returns a pointer
+// to a BikeHoldService.
+func NewBikeHoldService(repo repositories.BikeHoldRepository) *BikeHoldService {
+	return &BikeHoldService{repo: repo}
+}
+
+// CreateHold holds an available bike for the logged user for "HOLD_DURATION" (15 minutes by
+// default), so nobody else can rent it while the rider walks to it.
+//
+// A rider can hold a single bike at a time, and must wait "HOLD_COOLDOWN" (10 minutes by
+// default) after releasing a hold or letting one expire. When "latitude" and "longitude" are
+// sent and the bike position is known, the rider must be within "HOLD_MAX_DISTANCE_METERS"
+// (1000 meters by default) of the bike.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeHoldService) CreateHold(c *gin.Context) {
+	var body struct {
+		BikeID    *uuid.UUID `json:"bike_id" validate:"required"`
+		Latitude  *float64   `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+		Longitude *float64   `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	bike, err := s.repo.GetBikeByID(body.BikeID.String())
+	if err != nil {
+		if strings.Contains(err.Error(), "bike not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to hold a bike"})
+		return
+	}
+
+	if body.Latitude != nil && bike.Latitude != nil && bike.Longitude != nil {
+		rider := geo.Point{Latitude: *body.Latitude, Longitude: *body.Longitude}
+		position := geo.Point{Latitude: *bike.Latitude, Longitude: *bike.Longitude}
+
+		if geo.Distance(rider, position) > holdMaxDistanceMeters() {
+			c.JSON(http.StatusForbidden, gin.H{"message": "you must be near the bike to hold it"})
+			return
+		}
+	}
+
+	hold := &models.BikeHold{
+		ID:        uuid.Must(uuid.NewRandom()),
+		UserID:    loggedUser.ID,
+		BikeID:    bike.ID,
+		Status:    models.BIKE_HOLD_STATUS_ACTIVE,
+		ExpiresAt: time.Now().Add(holdDuration()),
+	}
+
+	if err := s.repo.CreateHold(hold, holdCooldown(), reservationRentalBuffer()); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "bike not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "you must wait"):
+			c.JSON(http.StatusTooManyRequests, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "not available to hold"),
+			strings.Contains(err.Error(), "reserved by another rider"),
+			strings.Contains(err.Error(), "already have an active hold"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to hold a bike"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// GetCurrentHold retrieves the active hold of the logged user.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeHoldService) GetCurrentHold(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	hold, err := s.repo.GetActiveHoldByUserID(loggedUser.ID.String())
+	if err != nil {
+		if strings.Contains(err.Error(), "hold not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get hold"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// ReleaseHold ends a hold of the logged user before it expires, making the bike available
+// again. Admins can release any hold.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeHoldService) ReleaseHold(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	hold, err := s.repo.GetHoldByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "hold not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get hold"})
+		return
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && hold.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "you are not allowed to release this hold"})
+		return
+	}
+
+	if err := s.repo.ReleaseHold(hold.ID.String()); err != nil {
+		if strings.Contains(err.Error(), "hold is not active") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to release hold"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "hold released successfully"})
+}
+
+// SweepExpiredHolds periodically expires the holds whose time is up and makes their bikes
+// available again.
+//
+// It is meant to be run in its own goroutine for the lifetime of the server.
+func (s *BikeHoldService) SweepExpiredHolds() {
+	ticker := time.NewTicker(holdSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := s.repo.ExpireHolds()
+		if err != nil {
+			slog.Error("failed to expire holds", "error", err)
+			continue
+		}
+
+		if count > 0 {
+			slog.Info("holds expired", "count", count)
+		}
+	}
+}
+
+// holdDuration returns how long a hold lasts, read from the "HOLD_DURATION" environment
+// variable.
+func holdDuration() time.Duration {
+	return durationFromEnv("HOLD_DURATION", 15*time.Minute)
+}
+
+// holdCooldown returns how long a rider must wait after a hold ends without a rental, read
+// from the "HOLD_COOLDOWN" environment variable.
+func holdCooldown() time.Duration {
+	return durationFromEnv("HOLD_COOLDOWN", 10*time.Minute)
+}
+
+// holdMaxDistanceMeters returns how far from a bike a rider may hold it, read from the
+// "HOLD_MAX_DISTANCE_METERS" environment variable.
+func holdMaxDistanceMeters() float64 {
+	if distance, err := strconv.ParseFloat(os.Getenv("HOLD_MAX_DISTANCE_METERS"), 64); err == nil && distance > 0 {
+		return distance
+	}
+
+	return 1000
+}
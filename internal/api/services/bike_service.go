@@ -1,8 +1,12 @@
 package services
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -43,13 +47,29 @@ func (s *BikeService) CreateBike(c *gin.Context) {
 	}
 
 	bike.ID = id
+	bike.Model = nil
+
+	bike.Code, err = utils.GenerateBikeCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to create a new bike"})
+		return
+	}
 
 	if err := utils.ValidateModel(bike); err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
 		return
 	}
 
+	if !s.checkBikeModel(c, bike.ModelID) || !s.checkStation(c, bike.StationID) {
+		return
+	}
+
 	if err := s.repo.CreateBike(bike); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateSerialNumber) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to create a new bike"})
 		return
 	}
@@ -148,6 +168,11 @@ func (s *BikeService) UpdateBike(c *gin.Context) {
 	}
 
 	bike.ID = bikeID
+	bike.Model = nil
+
+	if !s.checkBikeModel(c, bike.ModelID) || !s.checkStation(c, bike.StationID) {
+		return
+	}
 
 	if err := s.repo.UpdateBike(&bike); err != nil {
 		if strings.Contains(err.Error(), "bike not found") {
@@ -155,9 +180,147 @@ func (s *BikeService) UpdateBike(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, repositories.ErrDuplicateSerialNumber) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to update bike"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "bike updated successfully"})
 }
+
+// checkBikeModel makes sure the catalog model referenced by a bike exists. It writes the error
+// response itself and returns false when the model is missing.
+func (s *BikeService) checkBikeModel(c *gin.Context, modelID *uuid.UUID) bool {
+	if modelID == nil {
+		return true
+	}
+
+	if _, err := s.repo.GetBikeModelByID(modelID.String()); err != nil {
+		if strings.Contains(err.Error(), "bike model not found") {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike model"})
+		return false
+	}
+
+	return true
+}
+
+// checkStation makes sure the station referenced by a bike exists. It writes the error
+// response itself and returns false when the station is missing.
+func (s *BikeService) checkStation(c *gin.Context, stationID *uuid.UUID) bool {
+	if stationID == nil {
+		return true
+	}
+
+	if _, err := s.repo.GetStationByID(stationID.String()); err != nil {
+		if strings.Contains(err.Error(), "station not found") {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get station"})
+		return false
+	}
+
+	return true
+}
+
+// GetBikeQRCode issues a signed QR payload for a bike.
+//
+// The payload is meant to be rendered by the bike display or printed by the operations team.
+// It is valid for "QR_PAYLOAD_TTL" (10 minutes by default) and can only be used once.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *BikeService) GetBikeQRCode(c *gin.Context) {
+	bike, err := s.repo.GetBikeByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "bike not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to get bike"})
+		return
+	}
+
+	if bike.Code == "" {
+		if bike.Code, err = s.assignBikeCode(bike.ID.String()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to generate bike code"})
+			return
+		}
+	}
+
+	issuedAt := time.Now()
+	payload, err := utils.SignQRPayload(bike.Code, issuedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to generate qr code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":       bike.Code,
+		"payload":    payload,
+		"issued_at":  issuedAt,
+		"expires_at": issuedAt.Add(QRPayloadTTL()),
+	})
+}
+
+// BackfillBikeCodes assigns a short code to every bike created before codes existed.
+//
+// It is meant to be called once at startup. Failures are logged and retried on the next start.
+func (s *BikeService) BackfillBikeCodes() {
+	bikes, err := s.repo.GetBikesWithoutCode()
+	if err != nil {
+		slog.Error("failed to get bikes without code", "error", err)
+		return
+	}
+
+	for _, bike := range *bikes {
+		if _, err := s.assignBikeCode(bike.ID.String()); err != nil {
+			slog.Error("failed to assign bike code", "bike_id", bike.ID, "error", err)
+		}
+	}
+}
+
+// assignBikeCode generates and stores a code for a bike, retrying on the rare collision.
+func (s *BikeService) assignBikeCode(id string) (string, error) {
+	var err error
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var code string
+		if code, err = utils.GenerateBikeCode(); err != nil {
+			return "", err
+		}
+
+		if err = s.repo.UpdateBikeCode(id, code); err == nil {
+			return code, nil
+		}
+
+		if !strings.Contains(err.Error(), "bike code already in use") {
+			return "", err
+		}
+	}
+
+	return "", err
+}
+
+// QRPayloadTTL returns how long a signed QR payload is accepted, read from the
+// "QR_PAYLOAD_TTL" environment variable.
+func QRPayloadTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("QR_PAYLOAD_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return 10 * time.Minute
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+)
+
+type CorporateAccountService struct {
+	repo repositories.CorporateAccountRepository
+}
+
+// NewCorporateAccountService creates a new instance of the CorporateAccountService struct.
+//
+// It takes a repositories.CorporateAccountRepository as a parameter and returns a pointer
+// to a CorporateAccountService.
+func NewCorporateAccountService(repo repositories.CorporateAccountRepository) *CorporateAccountService {
+	return &CorporateAccountService{repo: repo}
+}
+
+// CreateCorporateAccount creates a new corporate account based on the JSON input in the request
+// body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) CreateCorporateAccount(c *gin.Context) {
+	account := new(models.CorporateAccount)
+
+	if err := c.BindJSON(account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	account.ID = uuid.Must(uuid.NewRandom())
+
+	if err := utils.ValidateModel(account); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreateCorporateAccount(account); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new corporate account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// GetAllCorporateAccounts retrieves the corporate accounts, by name.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) GetAllCorporateAccounts(c *gin.Context) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	accounts, pagination, err := s.repo.GetAllCorporateAccounts(*pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get corporate accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accounts, "pagination": pagination})
+}
+
+// GetCorporateAccountByID retrieves a corporate account.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) GetCorporateAccountByID(c *gin.Context) {
+	account, ok := s.getCorporateAccount(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// UpdateCorporateAccount replaces the details of a corporate account based on the JSON input in
+// the request body. Receipts and invoices already issued keep the details they were issued with.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) UpdateCorporateAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "corporate account not found"})
+		return
+	}
+
+	account := new(models.CorporateAccount)
+	if err := c.BindJSON(account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	account.ID = id
+
+	if err := utils.ValidateModel(account); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.UpdateCorporateAccount(account); err != nil {
+		if strings.Contains(err.Error(), "corporate account not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update corporate account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "corporate account updated successfully"})
+}
+
+// DeleteCorporateAccount deletes a corporate account. The rentals of its members are billed to
+// them from then on, and the receipts already billed to the account are still invoiced to it.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) DeleteCorporateAccount(c *gin.Context) {
+	if err := s.repo.DeleteCorporateAccount(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "corporate account not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete corporate account"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetCorporateAccountMembers retrieves the users whose rentals are billed to a corporate account.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) GetCorporateAccountMembers(c *gin.Context) {
+	account, ok := s.getCorporateAccount(c)
+	if !ok {
+		return
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	users, pagination, err := s.repo.GetCorporateAccountMembers(account.ID.String(), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get corporate account members"})
+		return
+	}
+
+	response := []UserResponseDTO{}
+	for _, user := range *users {
+		response = append(response, UserResponseDTO{
+			ID:                 user.ID,
+			Email:              user.Email,
+			Name:               user.Name,
+			Phone:              user.Phone,
+			Image:              user.Image,
+			Status:             user.Status,
+			Role:               user.Role,
+			Verified:           user.Verified,
+			BirthDate:          user.BirthDate,
+			TermsVersion:       user.TermsVersion,
+			TermsAcceptedAt:    user.TermsAcceptedAt,
+			CorporateAccountID: user.CorporateAccountID,
+			CreatedAt:          user.CreatedAt,
+			UpdatedAt:          user.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response, "pagination": pagination})
+}
+
+// AddCorporateAccountMember bills the rentals of the user in the "userId" path parameter to a
+// corporate account, from their next receipt on. A user belongs to one account at most.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) AddCorporateAccountMember(c *gin.Context) {
+	account, ok := s.getCorporateAccount(c)
+	if !ok {
+		return
+	}
+
+	if _, err := uuid.Parse(c.Param("userId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	}
+
+	if err := s.repo.AddCorporateAccountMember(account.ID.String(), c.Param("userId")); err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to add the member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member added successfully"})
+}
+
+// RemoveCorporateAccountMember bills the rentals of the member in the "userId" path parameter to
+// the user again, from their next receipt on.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *CorporateAccountService) RemoveCorporateAccountMember(c *gin.Context) {
+	account, ok := s.getCorporateAccount(c)
+	if !ok {
+		return
+	}
+
+	if _, err := uuid.Parse(c.Param("userId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user is not a member of the corporate account"})
+		return
+	}
+
+	if err := s.repo.RemoveCorporateAccountMember(account.ID.String(), c.Param("userId")); err != nil {
+		if strings.Contains(err.Error(), "user is not a member of the corporate account") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to remove the member"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// getCorporateAccount retrieves the corporate account in the "id" path parameter, responding with
+// an error when it cannot.
+func (s *CorporateAccountService) getCorporateAccount(c *gin.Context) (*models.CorporateAccount, bool) {
+	account, err := s.repo.GetCorporateAccountByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "corporate account not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get corporate account"})
+		return nil, false
+	}
+
+	return account, true
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+const defaultDisputeWindow = 30 * 24 * time.Hour
+
+type DisputeService struct {
+	repo    repositories.DisputeRepository
+	rentals *RentalService
+	window  time.Duration
+}
+
+// NewDisputeService creates a new instance of the DisputeService struct.
+//
+// Riders can dispute a rental for the duration of "DISPUTE_WINDOW" (a Go duration such as
+// "720h") after it ended, 30 days by default.
+//
+// Parameters:
+// - repo: a repositories.DisputeRepository object representing the dispute repository.
+// - rentals: the RentalService that prices rentals again when their end time is adjusted.
+//
+// Returns:
+// - *DisputeService: a pointer to a DisputeService object.
+func NewDisputeService(repo repositories.DisputeRepository, rentals *RentalService) *DisputeService {
+	return &DisputeService{repo: repo, rentals: rentals, window: durationFromEnv("DISPUTE_WINDOW", defaultDisputeWindow)}
+}
+
+// CreateDispute lets the logged user contest one of their completed rentals. The body carries
+// the "rental_id" and the "reason" of the dispute; photos are added afterwards.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) CreateDispute(c *gin.Context) {
+	var body struct {
+		RentalID *uuid.UUID `json:"rental_id"`
+		Reason   string     `json:"reason"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if body.RentalID == nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "rental_id is required"})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute := &models.Dispute{
+		ID:       uuid.Must(uuid.NewRandom()),
+		RentalID: *body.RentalID,
+		UserID:   loggedUser.ID,
+		Reason:   strings.TrimSpace(body.Reason),
+		Status:   models.DISPUTE_STATUS_OPEN,
+	}
+
+	if err := utils.ValidateModel(dispute); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	rental, err := s.repo.GetRentalByID(dispute.RentalID.String())
+	if err != nil {
+		if strings.Contains(err.Error(), "rental not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve rental"})
+		return
+	}
+
+	if rental.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "you are not allowed to dispute this rental"})
+		return
+	}
+
+	if rental.Status != models.RENTAL_STATUS_COMPLETED {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "only completed rentals can be disputed"})
+		return
+	}
+
+	if time.Since(rental.EndTime) > s.window {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "the rental can no longer be disputed"})
+		return
+	}
+
+	if err := s.repo.CreateDispute(dispute); err != nil {
+		if strings.Contains(err.Error(), "rental already has an open dispute") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new dispute"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// GetMyDisputes retrieves the disputes of the logged user, optionally filtered by the "status"
+// query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) GetMyDisputes(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	s.getDisputes(c, loggedUser.ID.String())
+}
+
+// GetAllDisputes retrieves the disputes of every rider, optionally filtered by the "status"
+// query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) GetAllDisputes(c *gin.Context) {
+	s.getDisputes(c, "")
+}
+
+// GetDisputeByID retrieves a dispute of the logged user, or any dispute for admins, with its
+// photos and the adjustments that resolved it.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) GetDisputeByID(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute, err := s.repo.GetDisputeByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "dispute not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get dispute"})
+		return
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && dispute.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "access to this resource is forbidden"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// RefundDispute resolves the dispute in the "id" path parameter by crediting back to the wallet
+// of the rider what the rental was charged. The body carries the "resolution" sent to the rider
+// and, for a partial refund, the "amount". Card payments are refunded through the payments
+// endpoints.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) RefundDispute(c *gin.Context) {
+	var body struct {
+		Amount     *money.Money `json:"amount" validate:"omitempty,money=positive"`
+		Resolution string       `json:"resolution" validate:"required,max=1000"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Resolution = strings.TrimSpace(body.Resolution)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute, err := s.repo.RefundDispute(c.Param("id"), body.Amount, body.Resolution, loggedUser.ID)
+	if err != nil {
+		respondDisputeError(c, err, "an error occurred when trying to refund rental")
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// AdjustDisputeEndTime resolves the dispute in the "id" path parameter by moving the end of the
+// rental to the "end_time" of the body, for riders who forgot to end their trip. The rental is
+// priced again through the pricing step, and what the rider was charged above its new total is
+// credited back to their wallet. The body also carries the "resolution" sent to the rider.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) AdjustDisputeEndTime(c *gin.Context) {
+	var body struct {
+		EndTime    *time.Time `json:"end_time" validate:"required"`
+		Resolution string     `json:"resolution" validate:"required,max=1000"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Resolution = strings.TrimSpace(body.Resolution)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute, err := s.repo.GetDisputeByID(c.Param("id"))
+	if err != nil {
+		respondDisputeError(c, err, "an error occurred when trying to get dispute")
+		return
+	}
+
+	if dispute.Status != models.DISPUTE_STATUS_OPEN {
+		c.JSON(http.StatusConflict, gin.H{"message": "dispute is not open"})
+		return
+	}
+
+	rental, err := s.repo.GetRentalByID(dispute.RentalID.String())
+	if err != nil {
+		respondDisputeError(c, err, "an error occurred when trying to retrieve rental")
+		return
+	}
+
+	if !body.EndTime.After(rental.StartTime) || !body.EndTime.Before(rental.EndTime) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "the end time must be between the start and the end of the rental"})
+		return
+	}
+
+	repricing, err := s.rentals.repriceRental(rental, *body.EndTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to price the rental"})
+		return
+	}
+
+	if repricing.Rental.TotalCost.Currency == rental.TotalCost.Currency && repricing.Rental.TotalCost.Cmp(rental.TotalCost) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "the adjusted rental would cost more than it was charged"})
+		return
+	}
+
+	adjustment := &models.RentalAdjustment{
+		Reason:            body.Resolution,
+		PreviousEndTime:   rental.EndTime,
+		EndTime:           repricing.Rental.EndTime,
+		PreviousTotal:     rental.TotalCost,
+		Total:             repricing.Rental.TotalCost,
+		PreviousBreakdown: rental.PriceBreakdown,
+		Breakdown:         repricing.Rental.PriceBreakdown,
+		CreatedByID:       loggedUser.ID,
+	}
+
+	dispute, err = s.repo.AdjustDisputedRental(dispute.ID.String(), adjustment, &repricing.Rental, repricing.PassUsage, repricing.Discount)
+	if err != nil {
+		respondDisputeError(c, err, "an error occurred when trying to adjust rental")
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// RejectDispute closes the dispute in the "id" path parameter without changing the rental. The
+// body carries the "resolution" sent to the rider.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *DisputeService) RejectDispute(c *gin.Context) {
+	var body struct {
+		Resolution string `json:"resolution" validate:"required,max=1000"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Resolution = strings.TrimSpace(body.Resolution)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute, err := s.repo.RejectDispute(c.Param("id"), body.Resolution, loggedUser.ID)
+	if err != nil {
+		respondDisputeError(c, err, "an error occurred when trying to reject dispute")
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+func (s *DisputeService) getDisputes(c *gin.Context, userID string) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	disputes, pagination, err := s.repo.GetDisputes(userID, c.Query("status"), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get disputes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": disputes, "pagination": pagination})
+}
+
+// respondDisputeError writes the response of an error of the dispute repository.
+func respondDisputeError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "dispute is not open"), strings.Contains(err.Error(), "rental was changed"):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "refund"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": message})
+	}
+}
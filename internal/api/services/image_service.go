@@ -0,0 +1,588 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/storage"
+)
+
+const (
+	defaultImageMaxUploadBytes = 5 << 20
+	defaultImageURLTTL         = 15 * time.Minute
+	imageMaxPixels             = 40_000_000
+	thumbnailMaxSize           = 320
+	disputeMaxPhotos           = 5
+)
+
+var (
+	errImageMissing     = errors.New("image file is required")
+	errImageTooLarge    = errors.New("image is too large")
+	errImageUnsupported = errors.New("image must be a jpeg, png or gif")
+)
+
+// imageFormats maps the sniffed content type of the accepted images to their file extension.
+var imageFormats = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ImageService struct {
+	repo           repositories.ImageRepository
+	store          storage.BlobStore
+	maxUploadBytes int64
+	urlTTL         time.Duration
+}
+
+type processedImage struct {
+	original      []byte
+	originalType  string
+	originalExt   string
+	thumbnail     []byte
+	thumbnailType string
+	thumbnailExt  string
+}
+
+// NewImageService creates a new instance of the ImageService struct.
+//
+// The upload size limit is read from "IMAGE_MAX_UPLOAD_BYTES" and the lifetime of signed URLs
+// from "IMAGE_URL_TTL" (a Go duration such as "15m").
+//
+// Parameters:
+// - repo: a repositories.ImageRepository object representing the image repository.
+// - store: the storage.BlobStore where images are written.
+//
+// Returns:
+// - *ImageService: a pointer to an ImageService object.
+func NewImageService(repo repositories.ImageRepository, store storage.BlobStore) *ImageService {
+	maxUploadBytes := int64(defaultImageMaxUploadBytes)
+	if value, err := strconv.ParseInt(os.Getenv("IMAGE_MAX_UPLOAD_BYTES"), 10, 64); err == nil && value > 0 {
+		maxUploadBytes = value
+	}
+
+	urlTTL := defaultImageURLTTL
+	if value, err := time.ParseDuration(os.Getenv("IMAGE_URL_TTL")); err == nil && value > 0 {
+		urlTTL = value
+	}
+
+	return &ImageService{repo: repo, store: store, maxUploadBytes: maxUploadBytes, urlTTL: urlTTL}
+}
+
+// UploadBikeImage stores the image sent in the "image" multipart field as the picture of a bike.
+//
+// A thumbnail is generated alongside the original. The bike image field is set to the stable
+// URL of GetBikeImage, which redirects to a freshly signed URL on every request.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) UploadBikeImage(c *gin.Context) {
+	id := c.Param("id")
+
+	bike, err := s.repo.GetBikeByID(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "bike not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike"})
+		return
+	}
+
+	img, ok := s.readUpload(c)
+	if !ok {
+		return
+	}
+
+	imageKey, thumbnailKey, err := s.storeImage(c, "bikes/"+bike.ID.String(), img)
+	if err != nil {
+		slog.Error("failed to store bike image", "bike_id", bike.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to store the image"})
+		return
+	}
+
+	imageURL := publicAPIURL(fmt.Sprintf("/v1/bikes/%s/image", bike.ID))
+	if err := s.repo.UpdateBikeImage(id, imageURL, imageKey, thumbnailKey); err != nil {
+		s.deleteImages(c, imageKey, thumbnailKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update bike"})
+		return
+	}
+
+	s.deleteImages(c, bike.ImageKey, bike.ThumbnailKey)
+
+	s.respondWithSignedURLs(c, imageURL, imageKey, thumbnailKey)
+}
+
+// GetBikeImage redirects to a signed URL of the uploaded image of a bike.
+//
+// The "size" query parameter can be set to "thumbnail" to get the thumbnail instead of the
+// original image.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) GetBikeImage(c *gin.Context) {
+	bike, err := s.repo.GetBikeByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "bike not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike"})
+		return
+	}
+
+	s.redirectToImage(c, bike.ImageKey, bike.ThumbnailKey)
+}
+
+// UploadUserImage stores the image sent in the "image" multipart field as the profile picture
+// of a user. Users can only change their own picture unless they are admins.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) UploadUserImage(c *gin.Context) {
+	id := c.Param("id")
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && loggedUser.ID.String() != id {
+		c.JSON(http.StatusForbidden, gin.H{"message": "access to this resource is forbidden"})
+		return
+	}
+
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	img, ok := s.readUpload(c)
+	if !ok {
+		return
+	}
+
+	imageKey, thumbnailKey, err := s.storeImage(c, "users/"+user.ID.String(), img)
+	if err != nil {
+		slog.Error("failed to store user image", "user_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to store the image"})
+		return
+	}
+
+	imageURL := publicAPIURL(fmt.Sprintf("/v1/users/%s/image", user.ID))
+	if err := s.repo.UpdateUserImage(id, imageURL, imageKey, thumbnailKey); err != nil {
+		s.deleteImages(c, imageKey, thumbnailKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update user"})
+		return
+	}
+
+	s.deleteImages(c, user.ImageKey, user.ThumbnailKey)
+
+	s.respondWithSignedURLs(c, imageURL, imageKey, thumbnailKey)
+}
+
+// GetUserImage redirects to a signed URL of the uploaded profile picture of a user.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) GetUserImage(c *gin.Context) {
+	user, err := s.repo.GetUserByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	s.redirectToImage(c, user.ImageKey, user.ThumbnailKey)
+}
+
+// UploadDisputePhoto adds the image sent in the "image" multipart field to the photos of an
+// open dispute. Riders can add up to five photos to their own disputes.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) UploadDisputePhoto(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute, ok := s.getDispute(c)
+	if !ok {
+		return
+	}
+
+	if dispute.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "access to this resource is forbidden"})
+		return
+	}
+
+	if dispute.Status != models.DISPUTE_STATUS_OPEN {
+		c.JSON(http.StatusConflict, gin.H{"message": "dispute is not open"})
+		return
+	}
+
+	if len(dispute.Photos) >= disputeMaxPhotos {
+		c.JSON(http.StatusConflict, gin.H{"message": fmt.Sprintf("dispute already has %d photos", disputeMaxPhotos)})
+		return
+	}
+
+	img, ok := s.readUpload(c)
+	if !ok {
+		return
+	}
+
+	imageKey, thumbnailKey, err := s.storeImage(c, "disputes/"+dispute.ID.String(), img)
+	if err != nil {
+		slog.Error("failed to store dispute photo", "dispute_id", dispute.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to store the image"})
+		return
+	}
+
+	photo := &models.DisputePhoto{
+		ID:           uuid.Must(uuid.NewRandom()),
+		DisputeID:    dispute.ID,
+		ImageKey:     imageKey,
+		ThumbnailKey: thumbnailKey,
+	}
+	photo.Image = publicAPIURL(fmt.Sprintf("/v1/disputes/%s/photos/%s", dispute.ID, photo.ID))
+
+	if err := s.repo.CreateDisputePhoto(photo, disputeMaxPhotos); err != nil {
+		s.deleteImages(c, imageKey, thumbnailKey)
+
+		if strings.Contains(err.Error(), "dispute not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		if strings.Contains(err.Error(), "dispute") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to add the photo"})
+		return
+	}
+
+	s.respondWithSignedURLs(c, photo.Image, imageKey, thumbnailKey)
+}
+
+// GetDisputePhoto redirects to a signed URL of a photo of a dispute. Riders can only see the
+// photos of their own disputes unless they are admins.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) GetDisputePhoto(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	dispute, ok := s.getDispute(c)
+	if !ok {
+		return
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && dispute.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "access to this resource is forbidden"})
+		return
+	}
+
+	for _, photo := range dispute.Photos {
+		if photo.ID.String() == c.Param("photoId") {
+			s.redirectToImage(c, photo.ImageKey, photo.ThumbnailKey)
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"message": "image not found"})
+}
+
+// ServeImage serves an object of the local blob store after checking the URL signature.
+//
+// It is only meaningful with the local store; S3-compatible stores serve their presigned URLs
+// directly.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *ImageService) ServeImage(c *gin.Context) {
+	local, ok := s.store.(*storage.LocalStore)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"message": "image not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !local.VerifySignature(key, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "invalid or expired signature"})
+		return
+	}
+
+	reader, contentType, err := local.Get(c, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "image not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get image"})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		slog.Error("failed to serve image", "key", key, "error", err)
+	}
+}
+
+// getDispute retrieves the dispute of the "id" path parameter with its photos. It writes the
+// error response itself and returns false when the dispute cannot be retrieved.
+func (s *ImageService) getDispute(c *gin.Context) (*models.Dispute, bool) {
+	dispute, err := s.repo.GetDisputeByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "dispute not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get dispute"})
+		return nil, false
+	}
+
+	return dispute, true
+}
+
+// readUpload reads, sniffs and decodes the "image" multipart field and builds its thumbnail.
+// It writes the error response itself and returns false when the upload is rejected.
+func (s *ImageService) readUpload(c *gin.Context) (*processedImage, bool) {
+	// Leave some room for the multipart envelope around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.maxUploadBytes+1<<20)
+
+	img, err := s.processUpload(c)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, errImageTooLarge), errors.As(err, &maxBytesErr):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": fmt.Sprintf("image must be at most %d bytes", s.maxUploadBytes)})
+		case errors.Is(err, errImageUnsupported):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": err.Error()})
+		case errors.Is(err, errImageMissing):
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid image"})
+		}
+
+		return nil, false
+	}
+
+	return img, true
+}
+
+func (s *ImageService) processUpload(c *gin.Context) (*processedImage, error) {
+	header, err := c.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, err
+		}
+
+		return nil, errImageMissing
+	}
+
+	if header.Size > s.maxUploadBytes {
+		return nil, errImageTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, s.maxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > s.maxUploadBytes {
+		return nil, errImageTooLarge
+	}
+
+	// Never trust the client provided content type, sniff the bytes instead.
+	contentType := http.DetectContentType(data)
+	ext, ok := imageFormats[contentType]
+	if !ok {
+		return nil, errImageUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errImageUnsupported
+	}
+
+	if config.Width*config.Height > imageMaxPixels {
+		return nil, errImageTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errImageUnsupported
+	}
+
+	thumbnail := utils.GenerateThumbnail(decoded, thumbnailMaxSize)
+
+	var buf bytes.Buffer
+	img := &processedImage{original: data, originalType: contentType, originalExt: ext}
+
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80})
+		img.thumbnailType, img.thumbnailExt = "image/jpeg", ".jpg"
+	} else {
+		err = png.Encode(&buf, thumbnail)
+		img.thumbnailType, img.thumbnailExt = "image/png", ".png"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img.thumbnail = buf.Bytes()
+
+	return img, nil
+}
+
+// storeImage writes the original image and its thumbnail under prefix and returns their keys.
+func (s *ImageService) storeImage(c *gin.Context, prefix string, img *processedImage) (string, string, error) {
+	name := uuid.Must(uuid.NewRandom()).String()
+	imageKey := prefix + "/" + name + img.originalExt
+	thumbnailKey := prefix + "/" + name + "_thumb" + img.thumbnailExt
+
+	if err := s.store.Put(c, imageKey, img.originalType, bytes.NewReader(img.original), int64(len(img.original))); err != nil {
+		return "", "", err
+	}
+
+	if err := s.store.Put(c, thumbnailKey, img.thumbnailType, bytes.NewReader(img.thumbnail), int64(len(img.thumbnail))); err != nil {
+		s.deleteImages(c, imageKey)
+		return "", "", err
+	}
+
+	return imageKey, thumbnailKey, nil
+}
+
+// deleteImages removes the given keys from the store. Failures are only logged since an orphan
+// object does not affect the API.
+func (s *ImageService) deleteImages(c *gin.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+
+		if err := s.store.Delete(c, key); err != nil {
+			slog.Error("failed to delete image", "key", key, "error", err)
+		}
+	}
+}
+
+func (s *ImageService) respondWithSignedURLs(c *gin.Context, imageURL string, imageKey string, thumbnailKey string) {
+	signedImageURL, err := s.store.SignedURL(imageKey, s.urlTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to sign the image url"})
+		return
+	}
+
+	signedThumbnailURL, err := s.store.SignedURL(thumbnailKey, s.urlTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to sign the image url"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"image":         imageURL,
+		"image_url":     signedImageURL,
+		"thumbnail_url": signedThumbnailURL,
+		"expires_at":    time.Now().Add(s.urlTTL),
+	})
+}
+
+func (s *ImageService) redirectToImage(c *gin.Context, imageKey string, thumbnailKey string) {
+	key := imageKey
+	if c.Query("size") == "thumbnail" {
+		key = thumbnailKey
+	}
+
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"message": "image not found"})
+		return
+	}
+
+	signedURL, err := s.store.SignedURL(key, s.urlTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to sign the image url"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Redirect(http.StatusFound, signedURL)
+}
+
+// publicAPIURL prefixes path with the "PUBLIC_API_URL" environment variable.
+func publicAPIURL(path string) string {
+	return strings.TrimSuffix(os.Getenv("PUBLIC_API_URL"), "/") + path
+}
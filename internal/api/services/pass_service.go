@@ -0,0 +1,375 @@
+package services
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+)
+
+// passRenewalInterval is how often active passes are checked for expiry and renewal.
+const passRenewalInterval = time.Minute
+
+type PassService struct {
+	repo repositories.PassRepository
+}
+
+// NewPassService creates a new instance of the PassService struct.
+//
+// It takes a repositories.PassRepository as a parameter and returns a pointer
+// to a PassService.
+func NewPassService(repo repositories.PassRepository) *PassService {
+	return &PassService{repo: repo}
+}
+
+// CreatePassProduct creates a new pass product based on the JSON input in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) CreatePassProduct(c *gin.Context) {
+	product := &models.PassProduct{WaiveUnlockFee: true, Active: true}
+
+	if err := c.BindJSON(product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	product.ID = uuid.Must(uuid.NewRandom())
+
+	if err := utils.ValidateModel(product); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreatePassProduct(product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new pass product"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// GetAllPassProducts retrieves every pass product, on sale or not.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) GetAllPassProducts(c *gin.Context) {
+	products, err := s.repo.GetAllPassProducts(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pass products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// GetPassProductsOnSale retrieves the pass products riders can buy.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) GetPassProductsOnSale(c *gin.Context) {
+	products, err := s.repo.GetAllPassProducts(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pass products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// GetPassProductByID retrieves a pass product.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) GetPassProductByID(c *gin.Context) {
+	product, err := s.repo.GetPassProductByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "pass product not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pass product"})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// UpdatePassProduct replaces the settings of a pass product based on the JSON input in the
+// request body. Passes already bought are renewed at the new price.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) UpdatePassProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "pass product not found"})
+		return
+	}
+
+	product := &models.PassProduct{WaiveUnlockFee: true, Active: true}
+	if err := c.BindJSON(product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	product.ID = id
+
+	if err := utils.ValidateModel(product); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.UpdatePassProduct(product); err != nil {
+		if strings.Contains(err.Error(), "pass product not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update pass product"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "pass product updated successfully"})
+}
+
+// DeletePassProduct takes a pass product off sale for good. Passes already bought stay valid
+// until they expire and are not renewed.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) DeletePassProduct(c *gin.Context) {
+	if err := s.repo.DeletePassProduct(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "pass product not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete pass product"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// BuyPass buys a pass for the logged user. The body carries the "product_id" and whether the
+// pass should "auto_renew". The pass starts now and is valid for the validity days of the
+// product.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) BuyPass(c *gin.Context) {
+	var body struct {
+		ProductID *uuid.UUID `json:"product_id" validate:"required"`
+		AutoRenew bool       `json:"auto_renew"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	product, err := s.repo.GetPassProductByID(body.ProductID.String())
+	if err != nil {
+		if strings.Contains(err.Error(), "pass product not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pass product"})
+		return
+	}
+
+	if !product.Active {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "pass product is not on sale"})
+		return
+	}
+
+	now := time.Now()
+	pass := &models.Pass{
+		ID:        uuid.Must(uuid.NewRandom()),
+		UserID:    loggedUser.ID,
+		ProductID: product.ID,
+		Status:    models.PASS_STATUS_ACTIVE,
+		Price:     product.Price,
+		StartsAt:  now,
+		ExpiresAt: now.AddDate(0, 0, product.ValidityDays),
+		AutoRenew: body.AutoRenew,
+	}
+
+	if err := s.repo.CreatePass(pass); err != nil {
+		if strings.Contains(err.Error(), "already have an active pass") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to buy a pass"})
+		return
+	}
+
+	pass.Product = product
+
+	c.JSON(http.StatusCreated, pass)
+}
+
+// GetMyPasses retrieves the passes of the logged user, the most recent first.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) GetMyPasses(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	passes, pagination, err := s.repo.GetPassesByUserID(loggedUser.ID.String(), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get passes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": passes, "pagination": pagination})
+}
+
+// GetPassByID retrieves a pass of the logged user.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) GetPassByID(c *gin.Context) {
+	pass, ok := s.getPassOfLoggedUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, pass)
+}
+
+// CancelPass turns off the auto-renewal of a pass of the logged user. The pass stays usable
+// until it expires.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PassService) CancelPass(c *gin.Context) {
+	pass, ok := s.getPassOfLoggedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repo.CancelPass(pass.ID.String()); err != nil {
+		if strings.Contains(err.Error(), "pass is not active") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to cancel pass"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "pass cancelled successfully"})
+}
+
+// RenewPasses periodically expires the passes that reached their expiry date, and renews the
+// passes that auto-renew. Ride bundles that auto-renew are renewed as soon as their rides are
+// used up.
+//
+// It is meant to be run in its own goroutine for the lifetime of the server.
+func (s *PassService) RenewPasses() {
+	ticker := time.NewTicker(passRenewalInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := s.repo.RenewPasses(time.Now())
+		if err != nil {
+			slog.Error("failed to renew passes", "error", err)
+			continue
+		}
+
+		if count > 0 {
+			slog.Info("passes expired or renewed", "count", count)
+		}
+	}
+}
+
+// getPassOfLoggedUser retrieves the pass in the "id" path parameter if it belongs to the logged
+// user or the user is an admin. It writes the error response and returns false otherwise.
+func (s *PassService) getPassOfLoggedUser(c *gin.Context) (*models.Pass, bool) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return nil, false
+	}
+
+	pass, err := s.repo.GetPassByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "pass not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pass"})
+		return nil, false
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && pass.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "you are not allowed to access this pass"})
+		return nil, false
+	}
+
+	return pass, true
+}
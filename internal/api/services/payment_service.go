@@ -0,0 +1,501 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
+)
+
+// maxWebhookBytes is the largest webhook payload accepted from the payment provider.
+const maxWebhookBytes = 1 << 20
+
+type PaymentService struct {
+	repo    repositories.PaymentRepository
+	gateway payment.PaymentGateway
+}
+
+// NewPaymentService creates a new instance of the PaymentService struct.
+//
+// Parameters:
+// - repo: a repositories.PaymentRepository object representing the payment repository.
+// - gateway: the payment provider, or nil when payments are not enabled.
+//
+// Returns:
+// - *PaymentService: a pointer to a PaymentService object.
+func NewPaymentService(repo repositories.PaymentRepository, gateway payment.PaymentGateway) *PaymentService {
+	return &PaymentService{repo: repo, gateway: gateway}
+}
+
+// AddPaymentMethod stores a card on file for the logged user. The body carries the "token"
+// created for the card by the client SDK of the payment provider. The first card becomes the
+// default payment method.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) AddPaymentMethod(c *gin.Context) {
+	if !s.gatewayEnabled(c) {
+		return
+	}
+
+	var body struct {
+		Token string `json:"token" validate:"required,max=255"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, paymentTimeout())
+	defer cancel()
+
+	card, err := s.gateway.AttachCard(ctx, body.Token)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidCard) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadGateway, gin.H{"message": "an error occurred when trying to reach the payment provider"})
+		return
+	}
+
+	method := &models.PaymentMethod{
+		ID:       uuid.Must(uuid.NewRandom()),
+		UserID:   loggedUser.ID,
+		Token:    card.Token,
+		Brand:    card.Brand,
+		Last4:    card.Last4,
+		ExpMonth: card.ExpMonth,
+		ExpYear:  card.ExpYear,
+	}
+
+	if err := s.repo.CreatePaymentMethod(method); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to add payment method"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, method)
+}
+
+// GetMyPaymentMethods retrieves the cards on file of the logged user, the default one first.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) GetMyPaymentMethods(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	methods, err := s.repo.GetPaymentMethodsByUserID(loggedUser.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get payment methods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+// SetDefaultPaymentMethod makes a card of the logged user the one that pays for their rentals.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) SetDefaultPaymentMethod(c *gin.Context) {
+	method, ok := s.getPaymentMethodOfLoggedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repo.SetDefaultPaymentMethod(method); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update payment method"})
+		return
+	}
+
+	c.JSON(http.StatusOK, method)
+}
+
+// DeletePaymentMethod removes a card of the logged user from the service and from the payment
+// provider.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) DeletePaymentMethod(c *gin.Context) {
+	if !s.gatewayEnabled(c) {
+		return
+	}
+
+	method, ok := s.getPaymentMethodOfLoggedUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, paymentTimeout())
+	defer cancel()
+
+	if err := s.gateway.DetachCard(ctx, method.Token); err != nil && !errors.Is(err, payment.ErrInvalidCard) {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "an error occurred when trying to reach the payment provider"})
+		return
+	}
+
+	if err := s.repo.DeletePaymentMethod(method); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete payment method"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetMyPayments retrieves the payments of the logged user, the most recent first.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) GetMyPayments(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	s.getPayments(c, loggedUser.ID.String(), "")
+}
+
+// GetAllPayments retrieves payments, the most recent first. The "user_id" and "rental_id" query
+// parameters keep the payments of a rider or a rental.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) GetAllPayments(c *gin.Context) {
+	for _, param := range []string{"user_id", "rental_id"} {
+		if value := c.Query(param); value != "" {
+			if _, err := uuid.Parse(value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": param + " must be a valid UUID"})
+				return
+			}
+		}
+	}
+
+	s.getPayments(c, c.Query("user_id"), c.Query("rental_id"))
+}
+
+// GetPaymentByID retrieves a payment.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) GetPaymentByID(c *gin.Context) {
+	p, ok := s.getPayment(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// RefundPayment gives back to the card some or all of a captured payment. The body carries the
+// "reason" of the refund and, for a partial refund, the "amount".
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) RefundPayment(c *gin.Context) {
+	if !s.gatewayEnabled(c) {
+		return
+	}
+
+	var body struct {
+		Amount *money.Money `json:"amount" validate:"omitempty,money=positive"`
+		Reason string       `json:"reason" validate:"required,max=500"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	p, ok := s.getPayment(c)
+	if !ok {
+		return
+	}
+
+	amount := p.CapturedAmount.Sub(p.RefundedAmount)
+	if body.Amount != nil {
+		amount = *body.Amount
+	}
+
+	if err := s.repo.CheckPaymentRefund(p.ID, amount); err != nil {
+		if strings.Contains(err.Error(), "refund") {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to refund payment"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, paymentTimeout())
+	defer cancel()
+
+	result, err := s.gateway.Refund(ctx, *p.GatewayReference, amount, uuid.NewString())
+	if err != nil {
+		if errors.Is(err, payment.ErrDeclined) || errors.Is(err, payment.ErrInvalidOperation) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadGateway, gin.H{"message": "an error occurred when trying to reach the payment provider"})
+		return
+	}
+
+	p, err = s.repo.RecordPaymentRefund(p.ID, result, body.Reason, loggedUser.ID)
+	if err != nil {
+		slog.Error("failed to record payment refund", "payment_id", c.Param("id"), "amount", result.Amount, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "the payment was refunded but the refund could not be recorded"})
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// VoidPayment releases the amount held by an authorized payment that was not captured.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) VoidPayment(c *gin.Context) {
+	if !s.gatewayEnabled(c) {
+		return
+	}
+
+	p, ok := s.getPayment(c)
+	if !ok {
+		return
+	}
+
+	if p.Status != models.PAYMENT_STATUS_AUTHORIZED {
+		c.JSON(http.StatusConflict, gin.H{"message": "only authorized payments can be voided"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c, paymentTimeout())
+	defer cancel()
+
+	result, err := s.gateway.Void(ctx, *p.GatewayReference, p.ID.String()+":void")
+	if err != nil {
+		if errors.Is(err, payment.ErrDeclined) || errors.Is(err, payment.ErrInvalidOperation) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadGateway, gin.H{"message": "an error occurred when trying to reach the payment provider"})
+		return
+	}
+
+	if p, err = s.repo.RecordPaymentResult(p.ID, result); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// HandleWebhook applies an asynchronous result sent by the payment provider. The payload must be
+// signed by the provider in the "Payment-Signature" header. An event delivered again is
+// acknowledged without being applied twice.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PaymentService) HandleWebhook(c *gin.Context) {
+	if !s.gatewayEnabled(c) {
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	event, err := s.gateway.ParseWebhook(payload, c.GetHeader(payment.SignatureHeader))
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if event.ID == "" || event.PaymentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := s.repo.ApplyPaymentEvent(event, payload); err != nil {
+		if strings.Contains(err.Error(), "payment not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		slog.Error("failed to apply payment event", "event_id", event.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to apply payment event"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "event received"})
+}
+
+// gatewayEnabled writes an error response and returns false when no payment gateway is
+// configured.
+func (s *PaymentService) gatewayEnabled(c *gin.Context) bool {
+	if s.gateway == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "payments are not enabled"})
+		return false
+	}
+
+	return true
+}
+
+// getPayments writes a page of payments, optionally only those of a rider or a rental.
+func (s *PaymentService) getPayments(c *gin.Context, userID string, rentalID string) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	payments, pagination, err := s.repo.GetPayments(userID, rentalID, *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": payments, "pagination": pagination})
+}
+
+// getPayment retrieves the payment in the "id" path parameter. It writes the error response and
+// returns false when the payment cannot be retrieved.
+func (s *PaymentService) getPayment(c *gin.Context) (*models.Payment, bool) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "payment not found"})
+		return nil, false
+	}
+
+	p, err := s.repo.GetPaymentByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "payment not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get payment"})
+		return nil, false
+	}
+
+	return p, true
+}
+
+// getPaymentMethodOfLoggedUser retrieves the payment method in the "id" path parameter if it
+// belongs to the logged user. It writes the error response and returns false otherwise.
+func (s *PaymentService) getPaymentMethodOfLoggedUser(c *gin.Context) (*models.PaymentMethod, bool) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return nil, false
+	}
+
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "payment method not found"})
+		return nil, false
+	}
+
+	method, err := s.repo.GetPaymentMethodByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "payment method not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get payment method"})
+		return nil, false
+	}
+
+	if method.UserID != loggedUser.ID {
+		c.JSON(http.StatusNotFound, gin.H{"message": "payment method not found"})
+		return nil, false
+	}
+
+	return method, true
+}
+
+// paymentTimeout returns how long to wait for the payment provider, read from the
+// "PAYMENT_TIMEOUT" environment variable.
+func paymentTimeout() time.Duration {
+	return durationFromEnv("PAYMENT_TIMEOUT", 10*time.Second)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+)
+
+type PenaltyService struct {
+	repo repositories.PenaltyRepository
+}
+
+// NewPenaltyService creates a new instance of the PenaltyService struct.
+//
+// Parameters:
+// - repo: a repositories.PenaltyRepository object representing the penalty repository.
+//
+// Returns:
+// - *PenaltyService: a pointer to a PenaltyService object.
+func NewPenaltyService(repo repositories.PenaltyRepository) *PenaltyService {
+	return &PenaltyService{repo: repo}
+}
+
+// GetMyPenalties retrieves the penalties of the logged user, optionally filtered by the
+// "status" query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PenaltyService) GetMyPenalties(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	s.getPenalties(c, loggedUser.ID.String())
+}
+
+// PayPenalty pays the unpaid penalty in the "id" path parameter from the wallet of the logged
+// user. The wallet is topped up beforehand through the wallet endpoints.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PenaltyService) PayPenalty(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	penalty, err := s.repo.GetPenaltyByID(c.Param("id"))
+	if err != nil {
+		respondPenaltyError(c, err, "an error occurred when trying to get penalty")
+		return
+	}
+
+	if penalty.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "access to this resource is forbidden"})
+		return
+	}
+
+	penalty, err = s.repo.PayPenalty(penalty.ID.String())
+	if err != nil {
+		respondPenaltyError(c, err, "an error occurred when trying to pay penalty")
+		return
+	}
+
+	c.JSON(http.StatusOK, penalty)
+}
+
+// GetAllPenalties retrieves the penalties of every rider, optionally filtered by the "status"
+// and "user_id" query parameters.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PenaltyService) GetAllPenalties(c *gin.Context) {
+	s.getPenalties(c, c.Query("user_id"))
+}
+
+// WaivePenalty cancels the unpaid penalty in the "id" path parameter. The body carries the
+// "reason" it is waived.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PenaltyService) WaivePenalty(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" validate:"required,max=500"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	penalty, err := s.repo.WaivePenalty(c.Param("id"), body.Reason, loggedUser.ID)
+	if err != nil {
+		respondPenaltyError(c, err, "an error occurred when trying to waive penalty")
+		return
+	}
+
+	c.JSON(http.StatusOK, penalty)
+}
+
+func (s *PenaltyService) getPenalties(c *gin.Context, userID string) {
+	status := c.Query("status")
+	switch models.PenaltyStatusEnum(status) {
+	case "", models.PENALTY_STATUS_UNPAID, models.PENALTY_STATUS_PAID, models.PENALTY_STATUS_WAIVED:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "status must be unpaid, paid or waived"})
+		return
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	penalties, pagination, err := s.repo.GetPenalties(userID, status, *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get penalties"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": penalties, "pagination": pagination})
+}
+
+// respondPenaltyError writes the response of an error of the penalty repository.
+func respondPenaltyError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "penalty is not unpaid"):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "does not cover the penalty"):
+		c.JSON(http.StatusPaymentRequired, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": message})
+	}
+}
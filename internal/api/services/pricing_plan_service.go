@@ -0,0 +1,541 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+)
+
+type PricingPlanService struct {
+	repo repositories.PricingPlanRepository
+}
+
+// NewPricingPlanService creates a new instance of the PricingPlanService struct.
+//
+// It takes a repositories.PricingPlanRepository as a parameter and returns a pointer
+// to a PricingPlanService.
+func NewPricingPlanService(repo repositories.PricingPlanRepository) *PricingPlanService {
+	return &PricingPlanService{repo: repo}
+}
+
+// CreatePricingPlan creates a new pricing plan based on the JSON input in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) CreatePricingPlan(c *gin.Context) {
+	plan := &models.PricingPlan{BillingIncrementSeconds: 60, Rounding: string(pricing.RoundUp)}
+
+	if err := c.BindJSON(plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	plan.ID = uuid.Must(uuid.NewRandom())
+	plan.Assignments = nil
+
+	if err := utils.ValidateModel(plan); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := validatePricingPlanCurrency(plan); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreatePricingPlan(plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new pricing plan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// GetAllPricingPlans retrieves the pricing plans with their assignments.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) GetAllPricingPlans(c *gin.Context) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	plans, pagination, err := s.repo.GetAllPricingPlans(*pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pricing plans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plans, "pagination": pagination})
+}
+
+// GetPricingPlanByID retrieves a pricing plan with its assignments.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) GetPricingPlanByID(c *gin.Context) {
+	plan, err := s.repo.GetPricingPlanByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "pricing plan not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pricing plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdatePricingPlan replaces the settings of a pricing plan based on the JSON input in the
+// request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) UpdatePricingPlan(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "pricing plan not found"})
+		return
+	}
+
+	plan := &models.PricingPlan{BillingIncrementSeconds: 60, Rounding: string(pricing.RoundUp)}
+	if err := c.BindJSON(plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	plan.ID = id
+	plan.Assignments = nil
+
+	if err := utils.ValidateModel(plan); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := validatePricingPlanCurrency(plan); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.UpdatePricingPlan(plan); err != nil {
+		if strings.Contains(err.Error(), "pricing plan not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update pricing plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "pricing plan updated successfully"})
+}
+
+// DeletePricingPlan deletes a pricing plan and its assignments. Active rentals of the plan are
+// still priced with it.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) DeletePricingPlan(c *gin.Context) {
+	if err := s.repo.DeletePricingPlan(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "pricing plan not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete pricing plan"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// AssignPricingPlan applies a pricing plan to a bike model, a station, a city, or to every bike.
+//
+// The body carries the "scope" and, depending on it, the "bike_model_id", the "station_id" or
+// the "city". A scope that already has a plan is moved to this one.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) AssignPricingPlan(c *gin.Context) {
+	var body struct {
+		Scope       models.PricingPlanScopeEnum `json:"scope" validate:"required,oneof='bike_model' 'station' 'city' 'default'"`
+		BikeModelID string                      `json:"bike_model_id" validate:"required_if=Scope bike_model,omitempty,uuid4"`
+		StationID   string                      `json:"station_id" validate:"required_if=Scope station,omitempty,uuid4"`
+		City        string                      `json:"city" validate:"required_if=Scope city,max=100"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	plan, err := s.repo.GetPricingPlanByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "pricing plan not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pricing plan"})
+		return
+	}
+
+	assignment := &models.PricingPlanAssignment{
+		ID:            uuid.Must(uuid.NewRandom()),
+		PricingPlanID: plan.ID,
+		Scope:         body.Scope,
+	}
+
+	switch body.Scope {
+	case models.PRICING_PLAN_SCOPE_BIKE_MODEL:
+		_, err = s.repo.GetBikeModelByID(body.BikeModelID)
+		assignment.ScopeKey = body.BikeModelID
+	case models.PRICING_PLAN_SCOPE_STATION:
+		_, err = s.repo.GetStationByID(body.StationID)
+		assignment.ScopeKey = body.StationID
+	case models.PRICING_PLAN_SCOPE_CITY:
+		assignment.ScopeKey = strings.ToLower(strings.TrimSpace(body.City))
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to assign pricing plan"})
+		return
+	}
+
+	if err := s.repo.AssignPricingPlan(assignment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to assign pricing plan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// DeletePricingPlanAssignment removes a pricing plan assignment.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) DeletePricingPlanAssignment(c *gin.Context) {
+	if err := s.repo.DeletePricingPlanAssignment(c.Param("assignmentId")); err != nil {
+		if strings.Contains(err.Error(), "pricing plan assignment not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete pricing plan assignment"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// CreatePricingRule creates a new pricing rule based on the JSON input in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) CreatePricingRule(c *gin.Context) {
+	rule := &models.PricingRule{Active: true}
+
+	if err := c.BindJSON(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	rule.ID = uuid.Must(uuid.NewRandom())
+
+	if err := utils.ValidateModel(rule); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreatePricingRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new pricing rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetAllPricingRules retrieves every pricing rule, active or not.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) GetAllPricingRules(c *gin.Context) {
+	rules, err := s.repo.GetAllPricingRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pricing rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetPricingRuleByID retrieves a pricing rule.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) GetPricingRuleByID(c *gin.Context) {
+	rule, err := s.repo.GetPricingRuleByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "pricing rule not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get pricing rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdatePricingRule replaces the settings of a pricing rule based on the JSON input in the
+// request body. Active rentals are priced with the rules in force when they are returned.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) UpdatePricingRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "pricing rule not found"})
+		return
+	}
+
+	rule := &models.PricingRule{Active: true}
+	if err := c.BindJSON(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	rule.ID = id
+
+	if err := utils.ValidateModel(rule); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.UpdatePricingRule(rule); err != nil {
+		if strings.Contains(err.Error(), "pricing rule not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update pricing rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "pricing rule updated successfully"})
+}
+
+// DeletePricingRule deletes a pricing rule.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) DeletePricingRule(c *gin.Context) {
+	if err := s.repo.DeletePricingRule(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "pricing rule not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete pricing rule"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// CreateHoliday adds a day to the holiday calendar. The "country" is optional; without it the
+// holiday applies everywhere.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) CreateHoliday(c *gin.Context) {
+	holiday := new(models.Holiday)
+
+	if err := c.BindJSON(holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	holiday.ID = uuid.Must(uuid.NewRandom())
+	holiday.Country = strings.ToUpper(holiday.Country)
+
+	if err := utils.ValidateModel(holiday); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreateHoliday(holiday); err != nil {
+		if strings.Contains(err.Error(), "holiday already exists") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new holiday"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// GetHolidays retrieves the holiday calendar, optionally between the "from" and "to" dates
+// given as query parameters (YYYY-MM-DD).
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) GetHolidays(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "dates must be formatted as YYYY-MM-DD"})
+			return
+		}
+	}
+
+	holidays, err := s.repo.GetHolidays(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
+
+// DeleteHoliday removes a day from the holiday calendar.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PricingPlanService) DeleteHoliday(c *gin.Context) {
+	if err := s.repo.DeleteHoliday(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "holiday not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete holiday"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// pricingPlanFor converts a stored pricing plan into the plan of the pricing engine. Bikes
+// without a plan are charged their hourly price pro rata, as before pricing plans, whether they
+// are ridden or paused.
+func pricingPlanFor(plan *models.PricingPlan, bike *models.Bike) pricing.Plan {
+	if plan == nil {
+		price := bike.EffectivePricePerHour()
+
+		return pricing.Plan{
+			Name:          "Standard",
+			Currency:      price.Currency,
+			Rate:          price,
+			RatePeriod:    time.Hour,
+			PausedRate:    price,
+			MoneyRounding: utils.MoneyRounding(),
+		}
+	}
+
+	pausedRate := plan.PausedPerMinuteRate
+	if pausedRate.IsZero() {
+		pausedRate = plan.PerMinuteRate
+	}
+
+	return pricing.Plan{
+		Name:             plan.Name,
+		Currency:         plan.Currency(),
+		UnlockFee:        plan.UnlockFee,
+		Rate:             plan.PerMinuteRate,
+		RatePeriod:       time.Minute,
+		PausedRate:       pausedRate,
+		BillingIncrement: time.Duration(plan.BillingIncrementSeconds) * time.Second,
+		Rounding:         pricing.Rounding(plan.Rounding),
+		FreeMinutes:      plan.FreeMinutes,
+		DailyCap:         plan.DailyCap,
+		MinimumCharge:    plan.MinimumCharge,
+		MoneyRounding:    utils.MoneyRounding(),
+	}
+}
+
+// validatePricingPlanCurrency checks that every amount of a plan is in the same currency.
+func validatePricingPlanCurrency(plan *models.PricingPlan) error {
+	currency := plan.Currency()
+
+	for _, amount := range []money.Money{plan.UnlockFee, plan.PerMinuteRate, plan.DailyCap, plan.MinimumCharge, plan.OvertimeFee, plan.PausedPerMinuteRate} {
+		if amount.Currency != "" && amount.Currency != currency {
+			return errors.New("every amount of the plan must be in the same currency")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+type PromotionService struct {
+	repo repositories.PromotionRepository
+}
+
+// NewPromotionService creates a new instance of the PromotionService struct.
+//
+// It takes a repositories.PromotionRepository as a parameter and returns a pointer
+// to a PromotionService.
+func NewPromotionService(repo repositories.PromotionRepository) *PromotionService {
+	return &PromotionService{repo: repo}
+}
+
+// CreatePromotion creates a new promo code based on the JSON input in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PromotionService) CreatePromotion(c *gin.Context) {
+	promotion := &models.Promotion{MaxRedemptionsPerUser: 1, Active: true}
+
+	if err := c.BindJSON(promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	promotion.ID = uuid.Must(uuid.NewRandom())
+
+	if !s.validatePromotion(c, promotion) {
+		return
+	}
+
+	if err := s.repo.CreatePromotion(promotion); err != nil {
+		if strings.Contains(err.Error(), "promo code already exists") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new promotion"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// GetAllPromotions retrieves the promo codes, the most recent first.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PromotionService) GetAllPromotions(c *gin.Context) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	promotions, pagination, err := s.repo.GetAllPromotions(*pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": promotions, "pagination": pagination})
+}
+
+// GetPromotionByID retrieves a promo code.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PromotionService) GetPromotionByID(c *gin.Context) {
+	promotion, err := s.repo.GetPromotionByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "promotion not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// UpdatePromotion replaces the settings of a promo code based on the JSON input in the request
+// body. Rentals already started with the code keep its previous discount.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PromotionService) UpdatePromotion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "promotion not found"})
+		return
+	}
+
+	promotion := &models.Promotion{MaxRedemptionsPerUser: 1, Active: true}
+	if err := c.BindJSON(promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	promotion.ID = id
+
+	if !s.validatePromotion(c, promotion) {
+		return
+	}
+
+	if err := s.repo.UpdatePromotion(promotion); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "promotion not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "promo code already exists"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update promotion"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "promotion updated successfully"})
+}
+
+// DeletePromotion deletes a promo code. Rentals already started with the code still get its
+// discount.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PromotionService) DeletePromotion(c *gin.Context) {
+	if err := s.repo.DeletePromotion(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "promotion not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete promotion"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetPromotionRedemptions retrieves the redemptions of a promo code, the most recent first.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *PromotionService) GetPromotionRedemptions(c *gin.Context) {
+	promotion, err := s.repo.GetPromotionByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "promotion not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get promotion"})
+		return
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	redemptions, pagination, err := s.repo.GetPromotionRedemptions(promotion.ID.String(), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get promotion redemptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": redemptions, "pagination": pagination})
+}
+
+// validatePromotion normalizes the code of a promotion and validates it, writing the response
+// when it is invalid. It reports whether the promotion is valid.
+func (s *PromotionService) validatePromotion(c *gin.Context, promotion *models.Promotion) bool {
+	promotion.Code = normalizePromoCode(promotion.Code)
+
+	if err := utils.ValidateModel(promotion); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return false
+	}
+
+	if err := validatePromotionSettings(promotion); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return false
+	}
+
+	for _, modelID := range promotion.EligibleBikeModelIDs {
+		if _, err := s.repo.GetBikeModelByID(modelID.String()); err != nil {
+			if strings.Contains(err.Error(), "bike model not found") {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+				return false
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get bike model"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// validatePromotionSettings checks the settings a promotion needs for its type and its validity
+// window. Settings of the other types are cleared.
+func validatePromotionSettings(promotion *models.Promotion) error {
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	switch promotion.Type {
+	case models.PROMOTION_TYPE_PERCENTAGE:
+		promotion.AmountOff, promotion.FreeMinutes = money.Money{}, 0
+	case models.PROMOTION_TYPE_FIXED_AMOUNT:
+		if promotion.AmountOff.IsZero() {
+			return errors.New("amount_off is required when type is fixed_amount")
+		}
+
+		promotion.PercentOff, promotion.FreeMinutes = 0, 0
+	case models.PROMOTION_TYPE_FREE_MINUTES:
+		promotion.PercentOff, promotion.AmountOff = 0, money.Money{}
+	}
+
+	return nil
+}
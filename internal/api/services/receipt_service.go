@@ -0,0 +1,659 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/document"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// documentTimeLayout is how dates and times are written on receipts and invoices, in the time
+// zone of the pricing rules.
+const documentTimeLayout = "2006-01-02 15:04"
+
+type ReceiptService struct {
+	repo repositories.ReceiptRepository
+}
+
+// NewReceiptService creates a new instance of the ReceiptService struct.
+//
+// It takes a repositories.ReceiptRepository as a parameter and returns a pointer
+// to a ReceiptService.
+func NewReceiptService(repo repositories.ReceiptRepository) *ReceiptService {
+	return &ReceiptService{repo: repo}
+}
+
+// GetRentalReceipt downloads the receipt of the completed rental whose ID is in the "userId"
+// path parameter, the wildcard the rentals routes share, as a PDF file by default. The "format" query parameter also accepts "html" and
+// "json". The receipt is issued if the rental does not have one yet.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReceiptService) GetRentalReceipt(c *gin.Context) {
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "html" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "format must be pdf, html or json"})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	rental, err := s.repo.GetRentalByID(c.Param("userId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "rental not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve rental"})
+		return
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && rental.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "you are not allowed to access this rental"})
+		return
+	}
+
+	receipt, err := s.issueRentalReceipt(rental)
+	if err != nil {
+		if strings.Contains(err.Error(), "rental is not completed") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		slog.Error("failed to issue receipt", "rental_id", rental.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the receipt"})
+		return
+	}
+
+	writeDocument(c, format, receipt, receipt.Document)
+}
+
+// SendRentalReceipt issues the receipt of a completed rental and emails it to the rider, with
+// the receipt attached as a PDF file. A receipt is emailed once. Failures are logged: the rider
+// can still download the receipt.
+//
+// Parameters:
+// - rentalID: the ID of the completed rental.
+func (s *ReceiptService) SendRentalReceipt(rentalID uuid.UUID) {
+	rental, err := s.repo.GetRentalByID(rentalID.String())
+	if err != nil {
+		slog.Error("failed to get the rental of the receipt", "rental_id", rentalID, "error", err)
+		return
+	}
+
+	receipt, err := s.issueRentalReceipt(rental)
+	if err != nil {
+		slog.Error("failed to issue receipt", "rental_id", rentalID, "error", err)
+		return
+	}
+
+	if receipt.EmailedAt != nil {
+		return
+	}
+
+	user, err := s.repo.GetUserByID(rental.UserID.String())
+	if err != nil {
+		slog.Error("failed to get the rider of the receipt", "receipt", receipt.Number, "error", err)
+		return
+	}
+
+	if err := emailDocument(user.Email, "Your receipt "+receipt.Number, receipt.Document, rentalReceiptURL(rental.ID)); err != nil {
+		slog.Error("failed to email receipt", "receipt", receipt.Number, "error", err)
+		return
+	}
+
+	if err := s.repo.MarkReceiptEmailed(receipt.ID); err != nil {
+		slog.Error("failed to record the receipt as emailed", "receipt", receipt.Number, "error", err)
+	}
+}
+
+// RunInvoices invoices the corporate accounts for a month, given by the "month" field of the
+// body as "YYYY-MM" (the previous month by default). Each account gets one invoice per currency
+// for the receipts of its riders issued during the month, emailed to its billing address. The
+// run can be repeated: receipts already invoiced are left out.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReceiptService) RunInvoices(c *gin.Context) {
+	var body struct {
+		Month string `json:"month"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	location := pricingLocation()
+	now := time.Now().In(location)
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location).AddDate(0, -1, 0)
+	if body.Month != "" {
+		month, err := time.ParseInLocation("2006-01", body.Month, location)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "month must be formatted as YYYY-MM"})
+			return
+		}
+
+		start = month
+	}
+
+	end := start.AddDate(0, 1, 0)
+	if end.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "the month is not over yet"})
+		return
+	}
+
+	accounts, err := s.repo.GetCorporateAccounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the corporate accounts"})
+		return
+	}
+
+	invoices := []models.Invoice{}
+	for i := range *accounts {
+		created, err := s.invoiceCorporateAccount(&(*accounts)[i], start, end)
+
+		for j := range created {
+			s.sendInvoice(&created[j])
+		}
+		invoices = append(invoices, created...)
+
+		if err != nil {
+			slog.Error("failed to invoice corporate account", "corporate_account_id", (*accounts)[i].ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create the invoices", "invoices": invoices})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period_start": start, "period_end": end, "invoices": invoices})
+}
+
+// GetInvoices retrieves the invoices, the most recent first. The "corporate_account_id" query
+// parameter narrows them to a corporate account.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReceiptService) GetInvoices(c *gin.Context) {
+	accountID := strings.TrimSpace(c.Query("corporate_account_id"))
+	if accountID != "" {
+		if _, err := uuid.Parse(accountID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid corporate_account_id"})
+			return
+		}
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	invoices, pagination, err := s.repo.GetInvoices(accountID, *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invoices, "pagination": pagination})
+}
+
+// GetInvoiceByID retrieves an invoice, as JSON by default. The "format" query parameter also
+// accepts "pdf" and "html" to download it.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReceiptService) GetInvoiceByID(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "pdf" && format != "html" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "format must be pdf, html or json"})
+		return
+	}
+
+	invoice, err := s.repo.GetInvoiceByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invoice not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get invoice"})
+		return
+	}
+
+	writeDocument(c, format, invoice, invoice.Document)
+}
+
+// issueRentalReceipt returns the receipt of a completed rental, issuing it when the rental does
+// not have one yet.
+func (s *ReceiptService) issueRentalReceipt(rental *models.Rental) (*models.Receipt, error) {
+	receipt, err := s.repo.GetReceiptByRentalID(rental.ID.String())
+	if err == nil {
+		return receipt, nil
+	}
+
+	if !strings.Contains(err.Error(), "receipt not found") {
+		return nil, err
+	}
+
+	if rental.Status != models.RENTAL_STATUS_COMPLETED {
+		return nil, fmt.Errorf("rental is not completed")
+	}
+
+	if receipt, err = s.rentalReceipt(rental); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateReceipt(receipt); err != nil {
+		// The receipt was issued concurrently, by the return or a download.
+		if strings.Contains(err.Error(), "receipt already exists") {
+			return s.repo.GetReceiptByRentalID(rental.ID.String())
+		}
+
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// rentalReceipt prepares the receipt of a completed rental: the rider, or the corporate account
+// the rider belongs to, the bike, the times and distance of the ride, the price breakdown, the
+// taxes charged on it and how it was paid. The repository numbers it when it is stored.
+func (s *ReceiptService) rentalReceipt(rental *models.Rental) (*models.Receipt, error) {
+	user, err := s.repo.GetUserByID(rental.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	bike, err := s.repo.GetBikeByID(rental.BikeID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.repo.GetRentalPayments(rental.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	paymentFields, err := s.paymentFields(rental, *payments)
+	if err != nil {
+		return nil, err
+	}
+
+	customer := document.Party{Name: user.Name, Email: user.Email}
+
+	var account *models.CorporateAccount
+	if user.CorporateAccountID != nil {
+		if account, err = s.repo.GetCorporateAccountByID(user.CorporateAccountID.String()); err != nil {
+			return nil, err
+		}
+
+		customer = corporateAccountParty(account)
+	}
+
+	location := pricingLocation()
+	issuedAt := time.Now()
+
+	doc := document.Document{
+		Title:    "Receipt",
+		IssuedAt: issuedAt.In(location).Format(documentTimeLayout),
+		Locale:   utils.DefaultLocale(),
+		Issuer:   billingIssuer(),
+		Customer: customer,
+		Details: []document.Field{
+			{Label: "Rider", Value: user.Name},
+			{Label: "Bike", Value: bikeLabel(bike)},
+			{Label: "Start", Value: rental.StartTime.In(location).Format(documentTimeLayout)},
+			{Label: "End", Value: rental.EndTime.In(location).Format(documentTimeLayout)},
+			{Label: "Duration", Value: formatRideDuration(rental.EndTime.Sub(rental.StartTime))},
+		},
+		Payments: paymentFields,
+	}
+
+	if rental.DistanceMeters > 0 {
+		doc.Details = append(doc.Details, document.Field{Label: "Distance", Value: fmt.Sprintf("%.2f km", rental.DistanceMeters/1000)})
+	}
+
+	if rental.PriceBreakdown != nil {
+		if rental.PriceBreakdown.Plan != "" {
+			doc.Details = append(doc.Details, document.Field{Label: "Pricing plan", Value: rental.PriceBreakdown.Plan})
+		}
+
+		for _, line := range rental.PriceBreakdown.Lines {
+			doc.Lines = append(doc.Lines, document.Line{Description: line.Description, Amount: line.Amount})
+		}
+	} else {
+		doc.Lines = append(doc.Lines, document.Line{Description: "Ride", Amount: rental.TotalCost})
+	}
+
+	doc.Subtotal = rental.TotalCost
+	doc.Total = rental.TotalCost
+
+	for _, line := range rental.TaxLines {
+		doc.Taxes = append(doc.Taxes, document.Line{Description: line.Description(), Amount: line.Amount, Included: line.Inclusive})
+		if !line.Inclusive {
+			doc.Subtotal = doc.Subtotal.Sub(line.Amount)
+		}
+	}
+
+	receipt := &models.Receipt{
+		ID:       uuid.Must(uuid.NewRandom()),
+		RentalID: rental.ID,
+		UserID:   rental.UserID,
+		Subtotal: doc.Subtotal,
+		TaxTotal: doc.TaxTotal(),
+		Total:    doc.Total,
+		Document: doc,
+		IssuedAt: issuedAt,
+	}
+
+	if account != nil {
+		receipt.CorporateAccountID = &account.ID
+	}
+
+	return receipt, nil
+}
+
+// paymentFields tells how a rental was paid: what was captured on each card, what is still being
+// captured, and the rest from the wallet of the rider.
+func (s *ReceiptService) paymentFields(rental *models.Rental, payments []models.Payment) ([]document.Field, error) {
+	locale := utils.DefaultLocale()
+	left := rental.TotalCost
+
+	var fields []document.Field
+	for _, p := range payments {
+		var amount money.Money
+		label := ""
+
+		switch p.Status {
+		case models.PAYMENT_STATUS_CAPTURED, models.PAYMENT_STATUS_REFUNDED:
+			amount = p.CapturedAmount
+		case models.PAYMENT_STATUS_AUTHORIZED:
+			amount, label = p.Amount, " (pending)"
+		default:
+			continue
+		}
+
+		if amount.Currency != left.Currency {
+			continue
+		}
+
+		if amount.Cmp(left) > 0 {
+			amount = left
+		}
+
+		if amount.IsZero() || amount.IsNegative() {
+			continue
+		}
+
+		method, err := s.repo.GetPaymentMethodByID(p.PaymentMethodID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, document.Field{Label: cardLabel(method) + label, Value: amount.Format(locale)})
+		left = left.Sub(amount)
+	}
+
+	if !left.IsZero() && !left.IsNegative() {
+		fields = append(fields, document.Field{Label: "Wallet", Value: left.Format(locale)})
+	}
+
+	return fields, nil
+}
+
+// invoiceCorporateAccount creates the invoices of a corporate account for the receipts of its
+// riders issued in a period, one per currency, and returns them.
+func (s *ReceiptService) invoiceCorporateAccount(account *models.CorporateAccount, start time.Time, end time.Time) ([]models.Invoice, error) {
+	receipts, err := s.repo.GetUninvoicedReceipts(account.ID.String(), start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var currencies []string
+	byCurrency := map[string][]models.Receipt{}
+	for _, receipt := range *receipts {
+		currency := receipt.Total.Currency
+		if _, ok := byCurrency[currency]; !ok {
+			currencies = append(currencies, currency)
+		}
+
+		byCurrency[currency] = append(byCurrency[currency], receipt)
+	}
+
+	var invoices []models.Invoice
+	for _, currency := range currencies {
+		invoice, receiptIDs := corporateInvoice(account, start, end, currency, byCurrency[currency])
+
+		if err := s.repo.CreateInvoice(invoice, receiptIDs); err != nil {
+			return invoices, err
+		}
+
+		invoices = append(invoices, *invoice)
+	}
+
+	return invoices, nil
+}
+
+// sendInvoice emails an invoice to the billing address of its corporate account, with the
+// invoice attached as a PDF file. Failures are logged.
+func (s *ReceiptService) sendInvoice(invoice *models.Invoice) {
+	if err := emailDocument(invoice.Document.Customer.Email, "Invoice "+invoice.Number, invoice.Document, ""); err != nil {
+		slog.Error("failed to email invoice", "invoice", invoice.Number, "error", err)
+		return
+	}
+
+	now := time.Now()
+	invoice.EmailedAt = &now
+
+	if err := s.repo.MarkInvoiceEmailed(invoice.ID); err != nil {
+		slog.Error("failed to record the invoice as emailed", "invoice", invoice.Number, "error", err)
+	}
+}
+
+// corporateInvoice prepares the invoice of a corporate account for receipts in one currency, with
+// a line per receipt and the taxes of the receipts summed up. It returns the invoice with the IDs
+// of the receipts it bills. The repository numbers it when it is stored.
+func corporateInvoice(account *models.CorporateAccount, start time.Time, end time.Time, currency string, receipts []models.Receipt) (*models.Invoice, []uuid.UUID) {
+	issuedAt := time.Now()
+
+	doc := document.Document{
+		Title:    "Invoice",
+		IssuedAt: issuedAt.In(start.Location()).Format(documentTimeLayout),
+		Locale:   utils.DefaultLocale(),
+		Issuer:   billingIssuer(),
+		Customer: corporateAccountParty(account),
+		Details: []document.Field{
+			{Label: "Period", Value: start.Format("2006-01-02") + " to " + end.AddDate(0, 0, -1).Format("2006-01-02")},
+			{Label: "Rides", Value: fmt.Sprint(len(receipts))},
+		},
+		Subtotal: money.New(0, currency),
+		Total:    money.New(0, currency),
+	}
+
+	var taxes []document.Line
+	receiptIDs := make([]uuid.UUID, 0, len(receipts))
+
+	for _, receipt := range receipts {
+		receiptIDs = append(receiptIDs, receipt.ID)
+
+		description := receipt.Number + ", " + receipt.Document.IssuedAt
+		for _, field := range receipt.Document.Details {
+			if field.Label == "Rider" {
+				description += ", " + field.Value
+			}
+		}
+
+		doc.Lines = append(doc.Lines, document.Line{Description: description, Amount: receipt.Subtotal})
+		doc.Subtotal = doc.Subtotal.Add(receipt.Subtotal)
+		doc.Total = doc.Total.Add(receipt.Total)
+		for _, tax := range receipt.Document.Taxes {
+			merged := false
+			for i := range taxes {
+				if taxes[i].Description == tax.Description && taxes[i].Included == tax.Included {
+					taxes[i].Amount = taxes[i].Amount.Add(tax.Amount)
+					merged = true
+				}
+			}
+
+			if !merged {
+				taxes = append(taxes, tax)
+			}
+		}
+	}
+
+	doc.Taxes = taxes
+
+	return &models.Invoice{
+		ID:                 uuid.Must(uuid.NewRandom()),
+		CorporateAccountID: account.ID,
+		PeriodStart:        start,
+		PeriodEnd:          end,
+		Currency:           currency,
+		ReceiptCount:       len(receipts),
+		Subtotal:           doc.Subtotal,
+		TaxTotal:           doc.TaxTotal(),
+		Total:              doc.Total,
+		Document:           doc,
+		IssuedAt:           issuedAt,
+	}, receiptIDs
+}
+
+// writeDocument responds with a receipt or an invoice: the stored record as JSON, or its
+// document rendered as an HTML page or a PDF file download.
+func writeDocument(c *gin.Context, format string, record interface{}, doc document.Document) {
+	switch format {
+	case "json":
+		c.JSON(http.StatusOK, record)
+	case "html":
+		page, err := document.HTML(doc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to render the document"})
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	default:
+		file, err := document.PDF(doc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to render the document"})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Number+".pdf"))
+		c.Data(http.StatusOK, "application/pdf", file)
+	}
+}
+
+// emailDocument sends a document by email, rendered as the body of the email and attached as a
+// PDF file. When downloadURL is not empty, the email links to it.
+func emailDocument(to string, subject string, doc document.Document, downloadURL string) error {
+	doc.DownloadURL = downloadURL
+
+	page, err := document.HTML(doc)
+	if err != nil {
+		return err
+	}
+
+	file, err := document.PDF(doc)
+	if err != nil {
+		return err
+	}
+
+	return pkg.SendEmailWithAttachments([]string{to}, subject, string(page), []pkg.EmailAttachment{
+		{Filename: doc.Number + ".pdf", Content: file},
+	})
+}
+
+// rentalReceiptURL returns the download link of the receipt of a rental, or an empty string when
+// the "PUBLIC_API_URL" environment variable is not set.
+func rentalReceiptURL(rentalID uuid.UUID) string {
+	if os.Getenv("PUBLIC_API_URL") == "" {
+		return ""
+	}
+
+	return publicAPIURL("/v1/rentals/" + rentalID.String() + "/receipt")
+}
+
+// billingIssuer returns the company issuing the receipts and invoices, read from the
+// "BILLING_COMPANY_NAME", "BILLING_COMPANY_TAX_ID" and "BILLING_COMPANY_ADDRESS" environment
+// variables. Lines of the address are separated by semicolons.
+func billingIssuer() document.Party {
+	issuer := document.Party{Name: os.Getenv("BILLING_COMPANY_NAME"), TaxID: os.Getenv("BILLING_COMPANY_TAX_ID")}
+	if issuer.Name == "" {
+		issuer.Name = "Ebike Rental"
+	}
+
+	for _, line := range strings.Split(os.Getenv("BILLING_COMPANY_ADDRESS"), ";") {
+		if line = strings.TrimSpace(line); line != "" {
+			issuer.Address = append(issuer.Address, line)
+		}
+	}
+
+	return issuer
+}
+
+// corporateAccountParty returns a corporate account as the customer of a document.
+func corporateAccountParty(account *models.CorporateAccount) document.Party {
+	return document.Party{Name: account.Name, Email: account.BillingEmail, TaxID: account.TaxID, Address: account.Address}
+}
+
+// bikeLabel names a bike on a receipt, by its model or name and its code.
+func bikeLabel(bike *models.Bike) string {
+	name := bike.Name
+	if bike.Model != nil {
+		name = bike.Model.Name
+	}
+
+	if bike.Code == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, bike.Code)
+}
+
+// cardLabel names a card on a receipt, such as "VISA •••• 4242".
+func cardLabel(method *models.PaymentMethod) string {
+	return strings.ToUpper(method.Brand) + " •••• " + method.Last4
+}
+
+// formatRideDuration writes the duration of a ride in minutes, such as "1h 05min" or "12 min".
+func formatRideDuration(d time.Duration) string {
+	minutes := int(math.Ceil(d.Minutes()))
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+
+	return fmt.Sprintf("%dh %02dmin", minutes/60, minutes%60)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+)
+
+// rentalRepricing is a completed rental priced again for an earlier end time.
+type rentalRepricing struct {
+	// Rental is a copy of the rental with its new end time, price breakdown, taxes and total.
+	Rental models.Rental
+	// PassUsage is what the pass of the rental covers now, or nil when no pass covered it.
+	PassUsage *models.PassUsage
+	// Discount is what the promo code of the rental takes off now, or nil without a promo code.
+	Discount *money.Money
+}
+
+// repriceRental prices a completed rental again as if it had ended at endTime, through the same
+// pricing step as ReturnBike.
+//
+// The plan, promo code and pass of the rental are used again. The pricing rules that applied to
+// the ride keep applying, at the multiplier recorded in its breakdown, since their conditions cannot
+// be evaluated again. The pass covers at most the minutes
+// it covered on the ride, the out of zone fee is kept, and the taxes are those in force when the
+// rental was returned. The pauses of the rental are charged at the paused rate up to endTime.
+func (s *RentalService) repriceRental(rental *models.Rental, endTime time.Time) (*rentalRepricing, error) {
+	bike, err := s.repo.GetBikeByID(rental.BikeID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var plan *models.PricingPlan
+	if rental.PricingPlanID != nil {
+		if plan, err = s.repo.GetPricingPlanByID(rental.PricingPlanID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	enginePlan := pricingPlanFor(plan, bike)
+
+	if rental.PriceBreakdown != nil {
+		for _, applied := range rental.PriceBreakdown.AppliedRules {
+			enginePlan.Rules = append(enginePlan.Rules, applied.Rule())
+		}
+	}
+
+	if rental.PriceBreakdown != nil && len(rental.PriceBreakdown.AppliedRules) == 0 && len(rental.PriceBreakdown.Rules) > 0 {
+		// Breakdowns stored before the multipliers were recorded only name their rules.
+		rules, err := s.repo.GetActivePricingRules()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range *rules {
+			if slices.Contains(rental.PriceBreakdown.Rules, rule.Name) {
+				enginePlan.Rules = append(enginePlan.Rules, pricing.Rule{Name: rule.Name, Type: pricing.RuleSchedule, Multiplier: rule.Multiplier})
+			}
+		}
+	}
+
+	repricing := &rentalRepricing{Rental: *rental}
+
+	if rental.PassID != nil {
+		usage, err := s.repo.GetPassUsage(rental.ID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		pass, err := s.repo.GetPassByID(rental.PassID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if usage != nil && pass.Product != nil {
+			enginePlan.Entitlement = &pricing.Entitlement{
+				Name:            pass.Product.Name,
+				Minutes:         usage.Minutes,
+				WaiveUnlockFee:  usage.Rides > 0 && pass.Product.WaiveUnlockFee,
+				DiscountPercent: pass.Product.MemberDiscountPercent,
+			}
+
+			repricing.PassUsage = usage
+		}
+	}
+
+	if rental.PromotionID != nil {
+		promotion, err := s.repo.GetPromotionByID(rental.PromotionID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		enginePlan.Discount = promotionDiscount(promotion)
+	}
+
+	taxRates, err := s.rentalTaxRates(rental.EndTime, rental.StartStationID, rental.ReturnStationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !rental.ZoneFee.IsZero() && enginePlan.Currency != "" && rental.ZoneFee.Currency != enginePlan.Currency {
+		return nil, fmt.Errorf("zone fee currency does not match the rental currency")
+	}
+
+	riding, paused := rentalRideTimes(rental, endTime)
+	breakdown := enginePlan.PriceRide(riding, paused, pricing.Conditions{StartTime: rental.StartTime.In(pricingLocation())})
+	breakdown.Add(pricing.LineZoneFee, "Out of zone fee", rental.ZoneFee)
+	taxes, taxLines := rentalTaxes(rental.ID, breakdown.Total, taxRates)
+
+	repricing.Rental.EndTime = endTime
+	repricing.Rental.TotalCost = taxes.Total
+	repricing.Rental.TaxTotal = taxes.TaxTotal()
+	repricing.Rental.TaxLines = taxLines
+	repricing.Rental.PriceBreakdown = &breakdown
+
+	if repricing.PassUsage != nil {
+		usage := *repricing.PassUsage
+		usage.Minutes = int(math.Ceil(breakdown.PassMinutes))
+		repricing.PassUsage = &usage
+	}
+
+	if rental.PromotionID != nil {
+		discount := breakdownDiscount(breakdown)
+		repricing.Discount = &discount
+	}
+
+	return repricing, nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+)
+
+// defaultMaxActiveRentals is how many rentals a rider can have in progress at once by default.
+const defaultMaxActiveRentals = 1
+
+// GetRentalEligibility checks whether the logged user can start a rental now, so apps can guide
+// the rider before they scan a bike. The response lists every rule the rider does not meet with
+// its machine-readable code.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) GetRentalEligibility(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	rejections, err := s.rentalRejections(loggedUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to check the rental eligibility"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"eligible": len(rejections) == 0, "rejections": rejections})
+}
+
+// checkRentalEligibility checks the rider against the rental eligibility rules before a bike is
+// looked at. When a rule is not met, it writes a 403 response carrying the code and message of
+// the first rejection and the list of all of them, and returns false.
+func (s *RentalService) checkRentalEligibility(c *gin.Context, user *models.User) bool {
+	rejections, err := s.rentalRejections(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to check the rental eligibility"})
+		return false
+	}
+
+	if len(rejections) > 0 {
+		respondRentalRejections(c, rejections)
+		return false
+	}
+
+	return true
+}
+
+// rentalRejections returns the rental eligibility rules a rider does not meet: the account must
+// be verified and active, the rider must have no unpaid penalties nor negative wallet balance,
+// fewer active rentals than "RENTAL_MAX_ACTIVE_RENTALS" (1 by default), at least
+// "RENTAL_MIN_AGE" years (no minimum by default) and have accepted the terms of service of
+// "TERMS_VERSION" when it is set.
+func (s *RentalService) rentalRejections(user *models.User) ([]models.RentalRejection, error) {
+	rejections := []models.RentalRejection{}
+
+	if !user.Verified {
+		rejections = append(rejections, models.RentalRejection{
+			Code:    models.RENTAL_REJECTION_ACCOUNT_NOT_VERIFIED,
+			Message: "verify your email before renting a bike",
+		})
+	}
+
+	if user.Status != models.UserStatusActive {
+		rejections = append(rejections, models.RentalRejection{
+			Code:    models.RENTAL_REJECTION_ACCOUNT_INACTIVE,
+			Message: "your account is not active",
+		})
+	}
+
+	unpaid, err := s.repo.CountUnpaidPenalties(user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if unpaid > 0 {
+		rejections = append(rejections, models.RentalRejection{
+			Code:    models.RENTAL_REJECTION_UNPAID_PENALTIES,
+			Message: "you have unpaid penalties, pay them before renting a bike",
+		})
+	}
+
+	balances, err := s.repo.GetWalletBalances(user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, balance := range balances {
+		if balance.IsNegative() {
+			rejections = append(rejections, models.RentalRejection{
+				Code:    models.RENTAL_REJECTION_UNPAID_BALANCE,
+				Message: fmt.Sprintf("your wallet balance is %s, top it up before renting a bike", balance),
+			})
+			break
+		}
+	}
+
+	active, err := s.repo.CountActiveRentals(user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if active >= int64(maxActiveRentals()) {
+		rejections = append(rejections, tooManyActiveRentalsRejection())
+	}
+
+	if minAge := rentalMinAge(); minAge > 0 {
+		if user.BirthDate == "" {
+			rejections = append(rejections, models.RentalRejection{
+				Code:    models.RENTAL_REJECTION_BIRTH_DATE_REQUIRED,
+				Message: "add your birth date to your profile before renting a bike",
+			})
+		} else if age, err := riderAge(user.BirthDate, time.Now()); err != nil || age < minAge {
+			rejections = append(rejections, models.RentalRejection{
+				Code:    models.RENTAL_REJECTION_UNDERAGE,
+				Message: fmt.Sprintf("you must be at least %d years old to rent a bike", minAge),
+			})
+		}
+	}
+
+	if version := termsVersion(); version != "" && (user.TermsVersion != version || user.TermsAcceptedAt == nil) {
+		rejections = append(rejections, models.RentalRejection{
+			Code:    models.RENTAL_REJECTION_TERMS_NOT_ACCEPTED,
+			Message: fmt.Sprintf("accept the terms of service version %s before renting a bike", version),
+		})
+	}
+
+	return rejections, nil
+}
+
+// respondRentalRejections writes the response of a rental refused by the eligibility rules.
+func respondRentalRejections(c *gin.Context, rejections []models.RentalRejection) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"message":    rejections[0].Message,
+		"code":       rejections[0].Code,
+		"rejections": rejections,
+	})
+}
+
+// tooManyActiveRentalsRejection returns the rejection of a rider who reached the maximum number
+// of active rentals.
+func tooManyActiveRentalsRejection() models.RentalRejection {
+	return models.RentalRejection{
+		Code:    models.RENTAL_REJECTION_TOO_MANY_ACTIVE_RENTALS,
+		Message: fmt.Sprintf("you can have at most %d active rentals, return a bike before renting another", maxActiveRentals()),
+	}
+}
+
+// maxActiveRentals returns how many rentals a rider can have in progress at once, read from the
+// "RENTAL_MAX_ACTIVE_RENTALS" environment variable.
+func maxActiveRentals() int {
+	if value, err := strconv.Atoi(os.Getenv("RENTAL_MAX_ACTIVE_RENTALS")); err == nil && value > 0 {
+		return value
+	}
+
+	return defaultMaxActiveRentals
+}
+
+// rentalMinAge returns the minimum age, in years, to rent a bike, read from the "RENTAL_MIN_AGE"
+// environment variable. Zero means there is no minimum.
+func rentalMinAge() int {
+	if value, err := strconv.Atoi(os.Getenv("RENTAL_MIN_AGE")); err == nil && value > 0 {
+		return value
+	}
+
+	return 0
+}
+
+// termsVersion returns the current version of the terms of service riders must accept, read
+// from the "TERMS_VERSION" environment variable. An empty version means no acceptance is required.
+func termsVersion() string {
+	return strings.TrimSpace(os.Getenv("TERMS_VERSION"))
+}
+
+// riderAge returns the age in whole years, in the pricing time zone, of a rider born on a date
+// formatted as "2006-01-02".
+func riderAge(birthDate string, now time.Time) (int, error) {
+	born, err := time.Parse("2006-01-02", birthDate)
+	if err != nil {
+		return 0, err
+	}
+
+	today := now.In(pricingLocation())
+
+	age := today.Year() - born.Year()
+	if today.Month() < born.Month() || (today.Month() == born.Month() && today.Day() < born.Day()) {
+		age--
+	}
+
+	return age, nil
+}
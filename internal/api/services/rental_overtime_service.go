@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// overtimeCheckInterval is how often the active rentals are checked against their maximum
+// duration.
+const overtimeCheckInterval = time.Minute
+
+// overtimeStageRank orders the overtime stages a rental goes through.
+var overtimeStageRank = map[models.RentalOvertimeStageEnum]int{
+	models.RENTAL_OVERTIME_NONE:                0,
+	models.RENTAL_OVERTIME_WARNED:              1,
+	models.RENTAL_OVERTIME_OVERDUE:             2,
+	models.RENTAL_OVERTIME_SUSPECTED_ABANDONED: 3,
+}
+
+// WatchOverdueRentals periodically moves the active rentals through the overtime stages and
+// emails their rider at each stage: a warning "RENTAL_OVERTIME_WARNING" (15 minutes by default)
+// before the maximum duration of the rental, a notice once it is overdue, and, when it is still
+// not returned "RENTAL_ABANDONED_AFTER" (24 hours by default) past its maximum duration, a notice
+// that the bike is suspected abandoned and will be recovered. Suspected abandoned rentals are
+// listed for the operations team to review.
+//
+// It is meant to be run in its own goroutine for the lifetime of the server.
+func (s *RentalService) WatchOverdueRentals() {
+	ticker := time.NewTicker(overtimeCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+
+		rentals, err := s.repo.GetRentalsDueForOvertime(now, defaultRentalMaxDuration(), rentalOvertimeWarning(), rentalAbandonedAfter())
+		if err != nil {
+			slog.Error("failed to get the rentals due for overtime", "error", err)
+			continue
+		}
+
+		for i := range *rentals {
+			rental := &(*rentals)[i]
+
+			stage := rentalOvertimeStage(rental, now)
+			if overtimeStageRank[stage] <= overtimeStageRank[rental.OvertimeStage] {
+				continue
+			}
+
+			advanced, err := s.repo.AdvanceOvertimeStage(rental.ID, rental.OvertimeStage, stage, now)
+			if err != nil {
+				slog.Error("failed to update the rental overtime stage", "rental_id", rental.ID, "error", err)
+				continue
+			}
+
+			if !advanced {
+				continue
+			}
+
+			if stage == models.RENTAL_OVERTIME_SUSPECTED_ABANDONED {
+				slog.Warn("rental suspected abandoned", "rental_id", rental.ID, "bike_id", rental.BikeID, "user_id", rental.UserID)
+			}
+
+			s.notifyOvertime(rental, stage)
+		}
+	}
+}
+
+// GetOverdueRentals retrieves the active rentals at the overtime stage of the "stage" query
+// parameter, the suspected abandoned rentals by default, for the operations team to review. An
+// admin ends a rental with ReturnBike once its bike is recovered.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) GetOverdueRentals(c *gin.Context) {
+	stage := models.RentalOvertimeStageEnum(c.DefaultQuery("stage", string(models.RENTAL_OVERTIME_SUSPECTED_ABANDONED)))
+	if rank, ok := overtimeStageRank[stage]; !ok || rank == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "stage must be warned, overdue or suspected_abandoned"})
+		return
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	rentals, pagination, err := s.repo.GetRentalsByOvertimeStage(stage, *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get rentals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rentals, "pagination": pagination})
+}
+
+// rentalPenalties returns the penalties charged for a returned rental: an overtime fee for every
+// started hour past its maximum duration, and an abandonment fee when the operations team ended
+// a suspected abandoned rental.
+func rentalPenalties(rental *models.Rental, plan *models.PricingPlan, currency string, endedByOperations bool) []models.Penalty {
+	if currency == "" {
+		currency = utils.DefaultCurrency()
+	}
+
+	var penalties []models.Penalty
+
+	maxDuration := rentalMaxDuration(rental)
+	if overtime := rental.EndTime.Sub(rental.StartTime) - maxDuration; overtime > 0 {
+		hours := int64(math.Ceil(overtime.Hours()))
+		fee := rentalOvertimeFee(plan, currency).Mul(big.NewRat(hours, 1), utils.MoneyRounding())
+
+		if !fee.IsZero() && !fee.IsNegative() {
+			penalties = append(penalties, models.Penalty{
+				Type:        models.PENALTY_TYPE_OVERTIME,
+				Description: fmt.Sprintf("Overtime fee, %d h past the maximum duration of %s", hours, formatDuration(maxDuration)),
+				Amount:      fee,
+			})
+		}
+	}
+
+	if endedByOperations && rental.OvertimeStage == models.RENTAL_OVERTIME_SUSPECTED_ABANDONED {
+		if fee := feeFromEnv("RENTAL_ABANDONMENT_FEE", currency); !fee.IsZero() {
+			penalties = append(penalties, models.Penalty{
+				Type:        models.PENALTY_TYPE_ABANDONMENT,
+				Description: "Abandoned bike recovery fee",
+				Amount:      fee,
+			})
+		}
+	}
+
+	for i := range penalties {
+		penalties[i].ID = uuid.Must(uuid.NewRandom())
+		penalties[i].UserID = rental.UserID
+		penalties[i].RentalID = rental.ID
+		penalties[i].Status = models.PENALTY_STATUS_UNPAID
+	}
+
+	return penalties
+}
+
+// collectPenalties pays the penalties of a returned rental from the wallet of the rider,
+// charging their default card first when a payment gateway is configured. The penalties that
+// cannot be paid stay unpaid and block the rider until they pay them.
+func (s *RentalService) collectPenalties(ctx context.Context, rental *models.Rental) {
+	for i := range rental.Penalties {
+		penalty := &rental.Penalties[i]
+
+		if s.gateway != nil {
+			chargeCtx, cancel := context.WithTimeout(ctx, paymentTimeout())
+			s.chargeDefaultCard(chargeCtx, rental, penalty.Amount)
+			cancel()
+		}
+
+		paid, err := s.repo.SettlePenalty(penalty.ID.String())
+		if err != nil {
+			slog.Warn("penalty left unpaid", "rental_id", rental.ID, "penalty_id", penalty.ID, "error", err)
+			continue
+		}
+
+		*penalty = *paid
+	}
+}
+
+// notifyOvertime emails the rider of a rental that reached an overtime stage. Failures are only
+// logged.
+func (s *RentalService) notifyOvertime(rental *models.Rental, stage models.RentalOvertimeStageEnum) {
+	user, err := s.repo.GetUserByID(rental.UserID.String())
+	if err != nil {
+		slog.Error("failed to get the rider of an overdue rental", "rental_id", rental.ID, "error", err)
+		return
+	}
+
+	maxEnd := rental.StartTime.Add(rentalMaxDuration(rental)).In(pricingLocation()).Format("2006-01-02 15:04")
+
+	var subject, body string
+	switch stage {
+	case models.RENTAL_OVERTIME_WARNED:
+		subject = "Your rental is about to reach its maximum duration"
+		body = fmt.Sprintf("Your rental reaches its maximum duration at %s. Return the bike before then to avoid an overtime fee.", maxEnd)
+	case models.RENTAL_OVERTIME_OVERDUE:
+		subject = "Your rental is overdue"
+		body = fmt.Sprintf("Your rental passed its maximum duration at %s. An overtime fee is charged for every started hour until the bike is returned.", maxEnd)
+	default:
+		subject = "Your rental is suspected abandoned"
+		body = fmt.Sprintf("Your rental passed its maximum duration at %s and the bike was not returned. Our team will recover it, and a recovery fee may be charged. Return the bike now if you still have it.", maxEnd)
+	}
+
+	if err := pkg.SendEmail([]string{user.Email}, subject, body); err != nil {
+		slog.Error("failed to send the overtime notification", "rental_id", rental.ID, "stage", stage, "error", err)
+	}
+}
+
+// rentalOvertimeStage returns the overtime stage an active rental reached at a given time.
+func rentalOvertimeStage(rental *models.Rental, now time.Time) models.RentalOvertimeStageEnum {
+	maxEnd := rental.StartTime.Add(rentalMaxDuration(rental))
+
+	switch {
+	case !now.Before(maxEnd.Add(rentalAbandonedAfter())):
+		return models.RENTAL_OVERTIME_SUSPECTED_ABANDONED
+	case !now.Before(maxEnd):
+		return models.RENTAL_OVERTIME_OVERDUE
+	case !now.Before(maxEnd.Add(-rentalOvertimeWarning())):
+		return models.RENTAL_OVERTIME_WARNED
+	default:
+		return models.RENTAL_OVERTIME_NONE
+	}
+}
+
+// rentalMaxDuration returns how long a rental can last before it is overdue: the maximum of its
+// pricing plan when it started, or the default of the service.
+func rentalMaxDuration(rental *models.Rental) time.Duration {
+	if rental.MaxDurationMinutes > 0 {
+		return time.Duration(rental.MaxDurationMinutes) * time.Minute
+	}
+
+	return defaultRentalMaxDuration()
+}
+
+// planMaxDurationMinutes returns the maximum duration, in minutes, of the rentals of a pricing
+// plan.
+func planMaxDurationMinutes(plan *models.PricingPlan) int {
+	if plan != nil && plan.MaxDurationMinutes > 0 {
+		return plan.MaxDurationMinutes
+	}
+
+	return int(defaultRentalMaxDuration().Minutes())
+}
+
+// defaultRentalMaxDuration returns the maximum duration of the rentals whose plan does not set
+// one, read from the "RENTAL_MAX_DURATION" environment variable. It defaults to 24 hours.
+func defaultRentalMaxDuration() time.Duration {
+	return durationFromEnv("RENTAL_MAX_DURATION", 24*time.Hour)
+}
+
+// rentalOvertimeWarning returns how long before its maximum duration the rider of a rental is
+// warned, read from the "RENTAL_OVERTIME_WARNING" environment variable.
+func rentalOvertimeWarning() time.Duration {
+	return durationFromEnv("RENTAL_OVERTIME_WARNING", 15*time.Minute)
+}
+
+// rentalAbandonedAfter returns how long past its maximum duration a rental is suspected
+// abandoned, read from the "RENTAL_ABANDONED_AFTER" environment variable.
+func rentalAbandonedAfter() time.Duration {
+	return durationFromEnv("RENTAL_ABANDONED_AFTER", 24*time.Hour)
+}
+
+// rentalOvertimeFee returns the fee charged for every started hour a rental runs past its
+// maximum duration: that of its pricing plan, or the "RENTAL_OVERTIME_FEE" environment variable.
+// A plan fee in another currency than the rental is never charged; the default fee is used instead.
+func rentalOvertimeFee(plan *models.PricingPlan, currency string) money.Money {
+	if plan != nil && !plan.OvertimeFee.IsZero() {
+		if plan.OvertimeFee.Currency == currency {
+			return plan.OvertimeFee
+		}
+
+		slog.Error("overtime fee currency does not match the rental currency", "pricing_plan_id", plan.ID, "fee", plan.OvertimeFee, "currency", currency)
+	}
+
+	return feeFromEnv("RENTAL_OVERTIME_FEE", currency)
+}
+
+// feeFromEnv reads a fee from an environment variable in major units of a currency. It is zero
+// when the variable is missing or invalid.
+func feeFromEnv(name string, currency string) money.Money {
+	value := os.Getenv(name)
+	if value == "" {
+		return money.New(0, currency)
+	}
+
+	fee, err := money.Parse(value, currency)
+	if err != nil || fee.IsNegative() {
+		slog.Warn("invalid fee, no fee is charged", "name", name, "value", value, "error", err)
+		return money.New(0, currency)
+	}
+
+	return fee
+}
+
+// formatDuration writes a duration in hours and minutes, such as "24h" or "1h30m".
+func formatDuration(d time.Duration) string {
+	formatted := strings.TrimSuffix(d.Truncate(time.Minute).String(), "0s")
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+
+	return formatted
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+)
+
+// ridePass finds the pass of a rider that covers a ride started at a given time, and what it
+// covers. A pass with rides left is preferred; a pass without rides left still gives its member
+// discount. It returns nil when no pass of the rider applies to the ride.
+func (s *RentalService) ridePass(userID uuid.UUID, startTime time.Time) (*models.Pass, *pricing.Entitlement, error) {
+	passes, err := s.repo.GetPassesForRide(userID.String(), startTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pass *models.Pass
+	for i := range *passes {
+		candidate := &(*passes)[i]
+		if candidate.Product == nil {
+			continue
+		}
+
+		if candidate.HasRidesLeft() {
+			pass = candidate
+			break
+		}
+
+		if pass == nil && candidate.Product.MemberDiscountPercent > 0 {
+			pass = candidate
+		}
+	}
+
+	if pass == nil {
+		return nil, nil, nil
+	}
+
+	entitlement := &pricing.Entitlement{
+		Name:            pass.Product.Name,
+		DiscountPercent: pass.Product.MemberDiscountPercent,
+	}
+
+	if pass.HasRidesLeft() {
+		entitlement.Minutes = pass.Product.MinutesPerRide
+		if pass.Product.IncludedMinutes > 0 {
+			entitlement.Minutes = max(0, min(entitlement.Minutes, pass.Product.IncludedMinutes-pass.MinutesUsed))
+		}
+
+		entitlement.WaiveUnlockFee = pass.Product.WaiveUnlockFee
+	}
+
+	return pass, entitlement, nil
+}
+
+// passUsage returns what a pass covered on a rental, from the price breakdown of the rental.
+func passUsage(pass *models.Pass, rental *models.Rental, breakdown pricing.Breakdown) *models.PassUsage {
+	usage := &models.PassUsage{
+		ID:       uuid.Must(uuid.NewRandom()),
+		PassID:   pass.ID,
+		RentalID: rental.ID,
+		Minutes:  int(math.Ceil(breakdown.PassMinutes)),
+	}
+
+	if pass.HasRidesLeft() {
+		usage.Rides = 1
+	}
+
+	return usage
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/lock"
+)
+
+// PauseRental locks the bike of an active rental in place without ending the rental, for a rider
+// stopping at a shop. The bike lock is commanded first, when a lock driver is configured, and the
+// rental is paused once it is locked. A rental can be paused several times, for at most its
+// maximum pause duration in total; paused time is charged at the paused rate of its pricing plan.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) PauseRental(c *gin.Context) {
+	rental, ok := s.getRentalOfLoggedUser(c, c.Param("rentalId"))
+	if !ok {
+		return
+	}
+
+	if rental.Status != models.RENTAL_STATUS_ACTIVE {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "rental is not active"})
+		return
+	}
+
+	maxPause := rentalMaxPause(rental)
+	if models.PausedDuration(rental.Segments, time.Now()) >= maxPause {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "the maximum pause duration of the rental is used up"})
+		return
+	}
+
+	if err := s.sendLockCommand(c, rental.BikeID, &rental.ID, lock.ActionLock); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"message": "the bike could not be locked, make sure it is properly parked and try again"})
+		return
+	}
+
+	rental, err := s.repo.PauseRental(rental.ID, time.Now(), maxPause)
+	if err != nil {
+		respondRentalSegmentError(c, err, "an error occurred when trying to pause rental")
+		return
+	}
+
+	respondRentalSegments(c, rental)
+}
+
+// ResumeRental unlocks the bike of a paused rental so the rider can ride on. The bike lock is
+// commanded first, when a lock driver is configured, and the rental is resumed once it is
+// unlocked.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) ResumeRental(c *gin.Context) {
+	rental, ok := s.getRentalOfLoggedUser(c, c.Param("rentalId"))
+	if !ok {
+		return
+	}
+
+	if rental.Status != models.RENTAL_STATUS_PAUSED {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "rental is not paused"})
+		return
+	}
+
+	if err := s.sendLockCommand(c, rental.BikeID, &rental.ID, lock.ActionUnlock); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "the bike could not be unlocked, please try again"})
+		return
+	}
+
+	rental, err := s.repo.ResumeRental(rental.ID, time.Now())
+	if err != nil {
+		respondRentalSegmentError(c, err, "an error occurred when trying to resume rental")
+		return
+	}
+
+	respondRentalSegments(c, rental)
+}
+
+// respondRentalSegments writes the status of a paused or resumed rental with its segments and
+// how long it was paused.
+func respondRentalSegments(c *gin.Context, rental *models.Rental) {
+	c.JSON(http.StatusOK, gin.H{
+		"rental_id":         rental.ID,
+		"status":            rental.Status,
+		"paused_minutes":    models.PausedDuration(rental.Segments, time.Now()).Minutes(),
+		"max_pause_minutes": rentalMaxPause(rental).Minutes(),
+		"segments":          rental.Segments,
+	})
+}
+
+// respondRentalSegmentError writes the response of an error of a pause or resume.
+func respondRentalSegmentError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "rental not found"):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "rental is not"):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "pause duration"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+	default:
+		slog.Error("failed to update the rental segments", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": message})
+	}
+}
+
+// rentalRideTimes splits a rental ended at a given time into riding and paused time. Paused time
+// past the maximum pause duration of the rental is counted as riding time.
+func rentalRideTimes(rental *models.Rental, end time.Time) (riding time.Duration, paused time.Duration) {
+	paused = min(models.PausedDuration(rental.Segments, end), rentalMaxPause(rental))
+
+	return end.Sub(rental.StartTime) - paused, paused
+}
+
+// closeRentalSegments ends the open segment of a returned rental at its end time.
+func closeRentalSegments(rental *models.Rental) {
+	for i := range rental.Segments {
+		if rental.Segments[i].EndTime == nil {
+			rental.Segments[i].EndTime = &rental.EndTime
+		}
+	}
+}
+
+// startRentalSegments returns the riding segment a new rental starts with.
+func startRentalSegments(rental *models.Rental) []models.RentalSegment {
+	return []models.RentalSegment{{
+		ID:        uuid.Must(uuid.NewRandom()),
+		RentalID:  rental.ID,
+		Type:      models.RENTAL_SEGMENT_RIDING,
+		StartTime: rental.StartTime,
+	}}
+}
+
+// rentalMaxPause returns how long a rental can be paused in total: the maximum of its pricing
+// plan when it started, or the default of the service.
+func rentalMaxPause(rental *models.Rental) time.Duration {
+	if rental.MaxPauseMinutes > 0 {
+		return time.Duration(rental.MaxPauseMinutes) * time.Minute
+	}
+
+	return defaultRentalMaxPause()
+}
+
+// planMaxPauseMinutes returns how long, in minutes, the rentals of a pricing plan can be paused
+// in total.
+func planMaxPauseMinutes(plan *models.PricingPlan) int {
+	if plan != nil && plan.MaxPauseMinutes > 0 {
+		return plan.MaxPauseMinutes
+	}
+
+	return int(defaultRentalMaxPause().Minutes())
+}
+
+// defaultRentalMaxPause returns how long the rentals whose plan does not set a maximum can be
+// paused in total, read from the "RENTAL_MAX_PAUSE_DURATION" environment variable. It defaults
+// to 30 minutes.
+func defaultRentalMaxPause() time.Duration {
+	return durationFromEnv("RENTAL_MAX_PAUSE_DURATION", 30*time.Minute)
+}
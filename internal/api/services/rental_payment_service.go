@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
+)
+
+// holdRenewalInterval is how often the pre-authorization holds of active rentals are checked
+// for expiry.
+const holdRenewalInterval = 5 * time.Minute
+
+// holdRenewalMargin is how long before they expire the holds of active rentals are renewed.
+const holdRenewalMargin = time.Hour
+
+// rentalHoldMethod returns the card a pre-authorization hold is placed on when a rider starts a
+// rental, and the amount of the hold. It returns a nil card when no hold is required, that is
+// without a payment gateway or a "PAYMENT_HOLD_AMOUNT", and an error when the rider has no card
+// on file.
+func (s *RentalService) rentalHoldMethod(userID uuid.UUID, currency string) (*models.PaymentMethod, money.Money, error) {
+	amount := rentalHoldAmount(currency)
+	if s.gateway == nil || amount.IsZero() {
+		return nil, money.Money{}, nil
+	}
+
+	method, err := s.repo.GetDefaultPaymentMethod(userID.String())
+	if err != nil {
+		return nil, money.Money{}, err
+	}
+
+	if method == nil {
+		return nil, money.Money{}, fmt.Errorf("a payment method is required to rent a bike")
+	}
+
+	return method, amount, nil
+}
+
+// placeRentalHold authorizes the pre-authorization hold of a new rental on a card and makes it
+// the hold of the rental. It does nothing when method is nil. When the hold cannot be placed,
+// nothing is left held on the card and an error is returned.
+func (s *RentalService) placeRentalHold(ctx context.Context, rental *models.Rental, method *models.PaymentMethod, amount money.Money) (*models.Payment, error) {
+	if method == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, paymentTimeout())
+	defer cancel()
+
+	expiresAt := time.Now().Add(rentalHoldValidity())
+
+	hold := s.authorizePayment(ctx, rental, method, amount, &expiresAt)
+	if hold == nil || hold.Status != models.PAYMENT_STATUS_AUTHORIZED {
+		if hold != nil && hold.Status == models.PAYMENT_STATUS_FAILED {
+			return nil, fmt.Errorf("the pre-authorization hold was declined")
+		}
+
+		return nil, fmt.Errorf("the pre-authorization hold could not be placed, please try again")
+	}
+
+	if err := s.repo.ReplaceRentalHold(rental.ID, nil, hold.ID); err != nil {
+		slog.Error("failed to set the rental hold", "rental_id", rental.ID, "payment_id", hold.ID, "error", err)
+		s.releaseRentalHold(ctx, hold)
+
+		return nil, fmt.Errorf("the pre-authorization hold could not be placed, please try again")
+	}
+
+	rental.HoldPaymentID = &hold.ID
+
+	return hold, nil
+}
+
+// releaseRentalHold voids a pre-authorization hold, releasing the amount held on the card.
+// Failures are logged: an unused hold expires on its own.
+func (s *RentalService) releaseRentalHold(ctx context.Context, hold *models.Payment) *models.Payment {
+	if hold.GatewayReference == nil || hold.Status != models.PAYMENT_STATUS_AUTHORIZED {
+		return hold
+	}
+
+	result, err := s.gateway.Void(ctx, *hold.GatewayReference, hold.ID.String()+":void")
+
+	return s.recordPaymentResult(hold, result, err)
+}
+
+// collectRentalPayment collects the total cost of a completed rental. The pre-authorization hold
+// of the rental is captured up to the total cost and the remainder of the hold is released. What
+// the hold does not cover, or the whole cost when the rental has no valid hold, is authorized and
+// captured on the default card of the rider. What is captured is credited to the wallet the
+// rental was charged to. Without a payment gateway nil is returned.
+//
+// Failures do not undo the return: they are recorded on the payments and logged, and what was not
+// collected stays due in the wallet of the rider.
+func (s *RentalService) collectRentalPayment(ctx context.Context, rental *models.Rental) []*models.Payment {
+	if s.gateway == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, paymentTimeout())
+	defer cancel()
+
+	var payments []*models.Payment
+	due := rental.TotalCost
+
+	// The hold may have been renewed while the rental was being returned; the stored rental has
+	// the hold in force when it was completed.
+	if completed, err := s.repo.GetRentalByID(rental.ID.String()); err == nil {
+		rental.HoldPaymentID = completed.HoldPaymentID
+	}
+
+	if rental.HoldPaymentID != nil {
+		hold, err := s.repo.GetPaymentByID(rental.HoldPaymentID.String())
+		if err != nil {
+			slog.Error("failed to get the rental hold", "rental_id", rental.ID, "error", err)
+		} else if hold.Status == models.PAYMENT_STATUS_AUTHORIZED {
+			var captured money.Money
+			hold, captured = s.captureRentalHold(ctx, hold, due)
+			payments = append(payments, hold)
+			due = due.Sub(captured)
+		}
+	}
+
+	if due.IsZero() || due.IsNegative() {
+		return payments
+	}
+
+	if p := s.chargeDefaultCard(ctx, rental, due); p != nil {
+		payments = append(payments, p)
+	}
+
+	return payments
+}
+
+// chargeDefaultCard authorizes and captures an amount due for a rental on the default card of
+// the rider, which credits it to their wallet. It returns the payment as recorded, or nil when
+// the rider has no card or the payment could not be created.
+func (s *RentalService) chargeDefaultCard(ctx context.Context, rental *models.Rental, amount money.Money) *models.Payment {
+	method, err := s.repo.GetDefaultPaymentMethod(rental.UserID.String())
+	if err != nil {
+		slog.Error("failed to get the default payment method", "rental_id", rental.ID, "error", err)
+		return nil
+	}
+
+	if method == nil {
+		return nil
+	}
+
+	p := s.authorizePayment(ctx, rental, method, amount, nil)
+	if p == nil {
+		return nil
+	}
+
+	if p.Status == models.PAYMENT_STATUS_AUTHORIZED {
+		result, err := s.gateway.Capture(ctx, *p.GatewayReference, amount, p.ID.String()+":capture")
+		p = s.recordPaymentResult(p, result, err)
+	}
+
+	return p
+}
+
+// captureRentalHold captures up to the amount due from a pre-authorization hold, which releases
+// the rest of the hold, and returns the updated hold with the amount captured. A hold in another
+// currency than the amount due, or with nothing due, is released.
+func (s *RentalService) captureRentalHold(ctx context.Context, hold *models.Payment, due money.Money) (*models.Payment, money.Money) {
+	if due.IsZero() || due.IsNegative() || due.Currency != hold.Amount.Currency {
+		return s.releaseRentalHold(ctx, hold), money.Money{}
+	}
+
+	amount := due
+	if amount.Cmp(hold.Amount) > 0 {
+		amount = hold.Amount
+	}
+
+	result, err := s.gateway.Capture(ctx, *hold.GatewayReference, amount, hold.ID.String()+":capture")
+	hold = s.recordPaymentResult(hold, result, err)
+
+	if err != nil {
+		return hold, money.Money{}
+	}
+
+	return hold, amount
+}
+
+// RenewRentalHolds periodically renews the pre-authorization holds of active rentals that are
+// about to expire: a new hold of the same amount is placed on the default card of the rider and
+// the old one is released. A hold that cannot be renewed is retried until the rental is returned;
+// the cost of the rental is then charged to the card directly.
+//
+// It is meant to be run in its own goroutine for the lifetime of the server.
+func (s *RentalService) RenewRentalHolds() {
+	if s.gateway == nil {
+		return
+	}
+
+	ticker := time.NewTicker(holdRenewalInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rentals, err := s.repo.GetRentalsWithExpiringHolds(time.Now().Add(holdRenewalMargin))
+		if err != nil {
+			slog.Error("failed to get the rentals with expiring holds", "error", err)
+			continue
+		}
+
+		count := 0
+		for i := range *rentals {
+			if s.renewRentalHold(&(*rentals)[i]) {
+				count++
+			}
+		}
+
+		if count > 0 {
+			slog.Info("rental holds renewed", "count", count)
+		}
+	}
+}
+
+// renewRentalHold replaces the hold of an active rental with a new one and reports whether it
+// succeeded.
+func (s *RentalService) renewRentalHold(rental *models.Rental) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), paymentTimeout())
+	defer cancel()
+
+	current, err := s.repo.GetPaymentByID(rental.HoldPaymentID.String())
+	if err != nil {
+		slog.Error("failed to get the rental hold", "rental_id", rental.ID, "error", err)
+		return false
+	}
+
+	method, err := s.repo.GetDefaultPaymentMethod(rental.UserID.String())
+	if err != nil || method == nil {
+		slog.Warn("the rental hold cannot be renewed without a payment method", "rental_id", rental.ID, "error", err)
+		return false
+	}
+
+	expiresAt := time.Now().Add(rentalHoldValidity())
+
+	hold := s.authorizePayment(ctx, rental, method, current.Amount, &expiresAt)
+	if hold == nil || hold.Status != models.PAYMENT_STATUS_AUTHORIZED {
+		slog.Warn("failed to renew the rental hold", "rental_id", rental.ID, "payment_id", current.ID)
+		return false
+	}
+
+	if err := s.repo.ReplaceRentalHold(rental.ID, &current.ID, hold.ID); err != nil {
+		slog.Warn("the rental hold changed while it was renewed", "rental_id", rental.ID, "error", err)
+		s.releaseRentalHold(ctx, hold)
+
+		return false
+	}
+
+	s.releaseRentalHold(ctx, current)
+
+	return true
+}
+
+// authorizePayment creates a payment of a rental on a card and authorizes it. It returns the
+// payment as recorded after the authorization, or nil when it could not be created.
+func (s *RentalService) authorizePayment(ctx context.Context, rental *models.Rental, method *models.PaymentMethod, amount money.Money, expiresAt *time.Time) *models.Payment {
+	p := &models.Payment{
+		ID:              uuid.Must(uuid.NewRandom()),
+		UserID:          rental.UserID,
+		RentalID:        &rental.ID,
+		PaymentMethodID: method.ID,
+		Status:          models.PAYMENT_STATUS_PENDING,
+		Amount:          amount,
+		ExpiresAt:       expiresAt,
+	}
+
+	if err := s.repo.CreatePayment(p); err != nil {
+		slog.Error("failed to create payment", "rental_id", rental.ID, "error", err)
+		return nil
+	}
+
+	result, err := s.gateway.Authorize(ctx, payment.AuthorizeRequest{
+		CardToken:      method.Token,
+		Amount:         amount,
+		Reference:      rental.ID.String(),
+		IdempotencyKey: p.ID.String() + ":authorize",
+	})
+
+	return s.recordPaymentResult(p, result, err)
+}
+
+// recordPaymentResult stores the result of an operation of the payment gateway on a payment and
+// returns the updated payment. Errors are logged and leave the payment as it was.
+func (s *RentalService) recordPaymentResult(p *models.Payment, result *payment.Result, err error) *models.Payment {
+	if err != nil {
+		slog.Error("payment operation failed", "payment_id", p.ID, "rental_id", p.RentalID, "error", err)
+	}
+
+	if result == nil {
+		return p
+	}
+
+	updated, err := s.repo.RecordPaymentResult(p.ID, result)
+	if err != nil {
+		slog.Error("failed to record payment result", "payment_id", p.ID, "rental_id", p.RentalID, "error", err)
+		return p
+	}
+
+	return updated
+}
+
+// rentalHoldAmount returns the amount held on the card of a rider when a rental starts, read as
+// a decimal amount in the currency of the rental from the "PAYMENT_HOLD_AMOUNT" environment
+// variable. It is zero, and no hold is placed, when the variable is missing or invalid.
+func rentalHoldAmount(currency string) money.Money {
+	if currency == "" {
+		currency = utils.DefaultCurrency()
+	}
+
+	value := os.Getenv("PAYMENT_HOLD_AMOUNT")
+	if value == "" {
+		return money.Money{}
+	}
+
+	amount, err := money.Parse(value, currency)
+	if err != nil || amount.IsNegative() {
+		slog.Warn("invalid PAYMENT_HOLD_AMOUNT, no hold is placed", "value", value, "error", err)
+		return money.Money{}
+	}
+
+	return amount
+}
+
+// rentalHoldValidity returns how long a pre-authorization hold stays valid at the payment
+// provider, read from the "PAYMENT_HOLD_VALIDITY" environment variable.
+func rentalHoldValidity() time.Duration {
+	return durationFromEnv("PAYMENT_HOLD_VALIDITY", 7*24*time.Hour)
+}
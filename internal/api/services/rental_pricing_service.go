@@ -0,0 +1,111 @@
+package services
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+)
+
+// rentalPricingPlan builds the plan of the pricing engine for a rental, with the pricing rules
+// that are turned on.
+func (s *RentalService) rentalPricingPlan(plan *models.PricingPlan, bike *models.Bike) (pricing.Plan, error) {
+	rules, err := s.repo.GetActivePricingRules()
+	if err != nil {
+		return pricing.Plan{}, err
+	}
+
+	enginePlan := pricingPlanFor(plan, bike)
+	for _, rule := range *rules {
+		enginePlan.Rules = append(enginePlan.Rules, pricingRuleFor(rule))
+	}
+
+	return enginePlan, nil
+}
+
+// rentalPricingConditions describes the circumstances of a ride for the pricing rules. The
+// holiday calendar of the country of the start station is used, or only the global holidays
+// when the ride did not start at a station.
+func (s *RentalService) rentalPricingConditions(startTime time.Time, startStationID *uuid.UUID, startAvailable *int, returnAvailable *int) (pricing.Conditions, error) {
+	conditions := pricing.Conditions{
+		StartTime:                   startTime.In(pricingLocation()),
+		StartStationAvailableBikes:  startAvailable,
+		ReturnStationAvailableBikes: returnAvailable,
+	}
+
+	country := ""
+	if startStationID != nil {
+		station, err := s.repo.GetStationByID(startStationID.String())
+		if err == nil {
+			country = station.Country
+		} else {
+			slog.Error("failed to get start station", "station_id", startStationID, "error", err)
+		}
+	}
+
+	holiday, err := s.repo.IsHoliday(conditions.StartTime.Format(time.DateOnly), country)
+	if err != nil {
+		return conditions, err
+	}
+
+	conditions.Holiday = holiday
+
+	return conditions, nil
+}
+
+// stationAvailability counts the bikes left at the station of a bike, not counting the bike
+// itself. It returns nil when the bike is not parked at a station.
+func (s *RentalService) stationAvailability(bike *models.Bike) (*int, error) {
+	if bike.StationID == nil {
+		return nil, nil
+	}
+
+	available, err := s.repo.CountAvailableBikesAtStation(bike.StationID.String(), bike.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &available, nil
+}
+
+// pricingRuleFor converts a stored pricing rule into a rule of the pricing engine.
+func pricingRuleFor(rule models.PricingRule) pricing.Rule {
+	weekdays := make([]time.Weekday, 0, len(rule.Weekdays))
+	for _, day := range rule.Weekdays {
+		weekdays = append(weekdays, time.Weekday(day))
+	}
+
+	return pricing.Rule{
+		Name:              rule.Name,
+		Type:              pricing.RuleType(rule.Type),
+		Multiplier:        rule.Multiplier,
+		Weekdays:          weekdays,
+		StartHour:         rule.StartHour,
+		EndHour:           rule.EndHour,
+		MaxAvailableBikes: rule.MaxAvailableBikes,
+	}
+}
+
+// pricingLocation returns the time zone the schedule and holiday rules are evaluated in, read
+// from the "PRICING_TIME_ZONE" environment variable. It defaults to the server time zone.
+func pricingLocation() *time.Location {
+	if location, err := time.LoadLocation(os.Getenv("PRICING_TIME_ZONE")); err == nil && os.Getenv("PRICING_TIME_ZONE") != "" {
+		return location
+	}
+
+	return time.Local
+}
+
+// stationReturnRadiusMeters returns how close to a station a bike must be returned to be parked
+// at it, read from the "STATION_RETURN_RADIUS_METERS" environment variable.
+func stationReturnRadiusMeters() float64 {
+	if radius, err := strconv.ParseFloat(os.Getenv("STATION_RETURN_RADIUS_METERS"), 64); err == nil && radius > 0 {
+		return radius
+	}
+
+	return 50
+}
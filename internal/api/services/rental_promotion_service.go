@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+)
+
+// rentalPromotion finds the promotion of a promo code and checks that it can be applied to a
+// ride of a bike starting now, priced in the given currency. The redemption limits are checked
+// when the rental is created.
+func (s *RentalService) rentalPromotion(code string, bike *models.Bike, currency string) (*models.Promotion, error) {
+	promotion, err := s.repo.GetPromotionByCode(normalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPromotion(promotion, bike, currency, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// checkPromotion checks that a promotion is active at a given time and applies to a bike. A
+// fixed amount promotion only applies to rides priced in its currency.
+func checkPromotion(promotion *models.Promotion, bike *models.Bike, currency string, now time.Time) error {
+	if !promotion.Active {
+		return errors.New("promo code is not active")
+	}
+
+	if promotion.StartsAt != nil && now.Before(*promotion.StartsAt) {
+		return errors.New("promo code is not valid yet")
+	}
+
+	if promotion.EndsAt != nil && !now.Before(*promotion.EndsAt) {
+		return errors.New("promo code has expired")
+	}
+
+	if len(promotion.EligibleBikeModelIDs) > 0 && (bike.ModelID == nil || !slices.Contains(promotion.EligibleBikeModelIDs, *bike.ModelID)) {
+		return errors.New("promo code is not valid for this bike")
+	}
+
+	if promotion.Type == models.PROMOTION_TYPE_FIXED_AMOUNT && promotion.AmountOff.Currency != currency {
+		return errors.New("promo code is not valid for this bike")
+	}
+
+	return nil
+}
+
+// promotionDiscount converts a promotion into a discount of the pricing engine.
+func promotionDiscount(promotion *models.Promotion) *pricing.Discount {
+	return &pricing.Discount{
+		Name:        "Promo code " + promotion.Code,
+		Type:        pricing.DiscountType(promotion.Type),
+		Percent:     promotion.PercentOff,
+		Amount:      promotion.AmountOff,
+		FreeMinutes: promotion.FreeMinutes,
+	}
+}
+
+// normalizePromoCode returns a promo code as it is stored, trimmed and in upper case.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// breakdownDiscount returns the amount a promo code took off a price breakdown.
+func breakdownDiscount(breakdown pricing.Breakdown) money.Money {
+	discount := money.New(0, breakdown.Total.Currency)
+	for _, line := range breakdown.Lines {
+		if line.Code == pricing.LineDiscount {
+			discount = discount.Sub(line.Amount)
+		}
+	}
+
+	return discount
+}
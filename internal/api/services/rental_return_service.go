@@ -0,0 +1,150 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/geo"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/pricing"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/tax"
+)
+
+// returnPricingStep is what a returned rental is priced with.
+type returnPricingStep struct {
+	// PricingPlan is the pricing plan the rental started with, or nil for the default pricing.
+	PricingPlan *models.PricingPlan
+	// Plan is the pricing plan of the rental with the pricing rules, the pass and the promo code.
+	Plan pricing.Plan
+	// Pass is the pass of the rider that covers the ride, or nil.
+	Pass *models.Pass
+	// Conditions are the conditions the pricing rules are evaluated against.
+	Conditions pricing.Conditions
+	// TaxRates are the tax rates in force at the start and return stations.
+	TaxRates []models.TaxRate
+}
+
+// returnStation finds the station a bike returned at a position is parked at, that is the
+// nearest one within "STATION_RETURN_RADIUS_METERS", and how many other bikes are available at
+// it. It returns a nil station when the position is unknown or no station is close enough.
+func (s *RentalService) returnStation(bike *models.Bike, latitude *float64, longitude *float64) (*models.Station, *int, error) {
+	if latitude == nil {
+		return nil, nil, nil
+	}
+
+	station, err := s.repo.GetNearestStation(*latitude, *longitude, stationReturnRadiusMeters())
+	if err != nil || station == nil {
+		return nil, nil, err
+	}
+
+	available, err := s.repo.CountAvailableBikesAtStation(station.ID.String(), bike.ID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return station, &available, nil
+}
+
+// returnPricing gathers what a rental returned now is priced with: the pricing plan of the rental
+// and the pricing rules, the pass of the rider that was valid when the ride started, the discount
+// of the promo code the rental was started with, and the taxes of its start and return stations.
+func (s *RentalService) returnPricing(rental *models.Rental, bike *models.Bike, returnStation *models.Station, returnAvailable *int) (*returnPricingStep, error) {
+	step := &returnPricingStep{}
+
+	var err error
+	if rental.PricingPlanID != nil {
+		if step.PricingPlan, err = s.repo.GetPricingPlanByID(rental.PricingPlanID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	if step.Plan, err = s.rentalPricingPlan(step.PricingPlan, bike); err != nil {
+		return nil, err
+	}
+
+	if step.Pass, step.Plan.Entitlement, err = s.ridePass(rental.UserID, rental.StartTime); err != nil {
+		return nil, err
+	}
+
+	if rental.PromotionID != nil {
+		promotion, err := s.repo.GetPromotionByID(rental.PromotionID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		step.Plan.Discount = promotionDiscount(promotion)
+	}
+
+	if step.Conditions, err = s.rentalPricingConditions(rental.StartTime, rental.StartStationID, rental.StartStationAvailableBikes, returnAvailable); err != nil {
+		return nil, err
+	}
+
+	var returnStationID *uuid.UUID
+	if returnStation != nil {
+		returnStationID = &returnStation.ID
+	}
+
+	if step.TaxRates, err = s.rentalTaxRates(time.Now(), rental.StartStationID, returnStationID); err != nil {
+		return nil, err
+	}
+
+	return step, nil
+}
+
+// priceReturnedRental prices a rental ended at its end time and stores the price on it. Its
+// paused time is charged at the paused rate, the pass covers what it includes before any money
+// is charged, then the promo code discount is taken off and the out of zone fee added. The
+// penalties of the rental are set too; endedByOperations tells whether an admin ended the rental
+// of another rider.
+func priceReturnedRental(rental *models.Rental, step *returnPricingStep, endedByOperations bool) (pricing.Breakdown, tax.Assessment) {
+	if step.Pass != nil {
+		rental.PassID = &step.Pass.ID
+	}
+
+	closeRentalSegments(rental)
+
+	riding, paused := rentalRideTimes(rental, rental.EndTime)
+	breakdown := step.Plan.PriceRide(riding, paused, step.Conditions)
+	breakdown.Add(pricing.LineZoneFee, "Out of zone fee", rental.ZoneFee)
+	taxes, taxLines := rentalTaxes(rental.ID, breakdown.Total, step.TaxRates)
+
+	rental.TotalCost = taxes.Total
+	rental.TaxTotal = taxes.TaxTotal()
+	rental.TaxLines = taxLines
+	rental.PriceBreakdown = &breakdown
+	rental.Penalties = rentalPenalties(rental, step.PricingPlan, step.Plan.Currency, endedByOperations)
+
+	return breakdown, taxes
+}
+
+// summarizeRentalTrip stores the distance and speeds of the route of a rental on it. Failures are
+// logged and leave them empty.
+func (s *RentalService) summarizeRentalTrip(rental *models.Rental) {
+	track, err := s.getRentalTrack(rental.ID.String())
+	if err != nil {
+		slog.Error("failed to get trip points", "rental_id", rental.ID, "error", err)
+		return
+	}
+
+	summary := geo.Summarize(track)
+	rental.DistanceMeters = summary.DistanceMeters
+	rental.AverageSpeedKmh = summary.AverageSpeedKmh
+	rental.MaxSpeedKmh = summary.MaxSpeedKmh
+}
+
+// parkReturnedBike moves a bike to the return position of its completed rental, at the return
+// station when there is one. Failures are logged: the rental is completed already.
+func (s *RentalService) parkReturnedBike(bike *models.Bike, rental *models.Rental) {
+	if rental.ReturnLatitude == nil {
+		return
+	}
+
+	if err := s.repo.UpdateBikePosition(bike.ID.String(), *rental.ReturnLatitude, *rental.ReturnLongitude); err != nil {
+		slog.Error("failed to update bike position", "bike_id", bike.ID, "error", err)
+	}
+
+	if err := s.repo.UpdateBikeStation(bike.ID.String(), rental.ReturnStationID); err != nil {
+		slog.Error("failed to update bike station", "bike_id", bike.ID, "error", err)
+	}
+}
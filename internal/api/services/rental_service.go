@@ -1,8 +1,13 @@
 package services
 
 import (
-	"math"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,10 +17,16 @@ import (
 	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
 	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
 	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/geo"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/lock"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/payment"
 )
 
 type RentalService struct {
-	repo repositories.RentalRepository
+	repo     repositories.RentalRepository
+	lock     lock.LockController
+	gateway  payment.PaymentGateway
+	receipts *ReceiptService
 }
 
 // NewRentalService creates a new instance of the RentalService struct.
@@ -25,16 +36,21 @@ type RentalService struct {
 //
 // Parameters:
 // - repo: a repositories.RentalRepository object representing the rental repository.
+// - lockController: the driver used to unlock and lock the bikes, or nil when the locks are not commanded.
+// - gateway: the payment provider charging the cards of the riders, or nil when rentals are only charged to the wallets.
+// - receipts: the service issuing the receipts of the returned rentals, or nil when they are only issued on download.
 //
 // Returns:
 // - *RentalService: a pointer to a RentalService object.
-func NewRentalService(repo repositories.RentalRepository) *RentalService {
-	return &RentalService{repo: repo}
+func NewRentalService(repo repositories.RentalRepository, lockController lock.LockController, gateway payment.PaymentGateway, receipts *ReceiptService) *RentalService {
+	return &RentalService{repo: repo, lock: lockController, gateway: gateway, receipts: receipts}
 }
 
 // CreateRental creates a new rental for a bike.
 //
-// It takes a gin.Context object as a parameter and returns nothing.
+// It takes a gin.Context object as a parameter and returns nothing. The optional body carries a
+// "promo_code" to apply to the rental. The rider is checked against the rental eligibility rules
+// before the bike; a refused rental is answered with a machine-readable rejection code.
 //
 // Parameters:
 // - c: a gin.Context object representing the HTTP request and response.
@@ -44,12 +60,30 @@ func NewRentalService(repo repositories.RentalRepository) *RentalService {
 func (s *RentalService) CreateRental(c *gin.Context) {
 	bikeID := c.Param("bikeId")
 
+	var body struct {
+		PromoCode string `json:"promo_code" validate:"max=32"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
 	loggedUser, err := utils.GetUserFromContext(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
 		return
 	}
 
+	if !s.checkRentalEligibility(c, loggedUser) {
+		return
+	}
+
 	bike, err := s.repo.GetBikeByID(bikeID)
 	if err != nil {
 		if strings.Contains(err.Error(), "bike not found") {
@@ -61,11 +95,117 @@ func (s *RentalService) CreateRental(c *gin.Context) {
 		return
 	}
 
-	if bike.Status != models.BIKE_STATUS_AVAILABLE {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "bike is not available to rent"})
+	s.startRental(c, loggedUser, bike, body.PromoCode, nil)
+}
+
+// ScanRental starts a rental from a scanned QR payload or a typed bike code.
+//
+// The body carries either "payload" (the signed content of the QR code) or "code" (the short
+// code printed on the bike). QR payloads expire after QRPayloadTTL and can only be used once.
+// When the rider sends "latitude" and "longitude" and the bike position is known, the rider
+// must be within "SCAN_MAX_DISTANCE_METERS" (150 meters by default) of the bike. An optional
+// "promo_code" is applied to the rental. As for CreateRental, the rider is checked against the
+// rental eligibility rules first.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) ScanRental(c *gin.Context) {
+	var body struct {
+		Payload   string   `json:"payload" validate:"required_without=Code"`
+		Code      string   `json:"code" validate:"required_without=Payload"`
+		Latitude  *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+		Longitude *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+		PromoCode string   `json:"promo_code" validate:"max=32"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
 		return
 	}
 
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	if !s.checkRentalEligibility(c, loggedUser) {
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(body.Code))
+
+	var payload *utils.QRPayload
+	if body.Payload != "" {
+		if payload, err = utils.ParseQRPayload(body.Payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		if time.Since(payload.IssuedAt) > QRPayloadTTL() || time.Until(payload.IssuedAt) > time.Minute {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "qr code expired"})
+			return
+		}
+
+		code = payload.Code
+	}
+
+	bike, err := s.repo.GetBikeByCode(code)
+	if err != nil {
+		if strings.Contains(err.Error(), "bike not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to rent a bike"})
+		return
+	}
+
+	if body.Latitude != nil && bike.Latitude != nil && bike.Longitude != nil {
+		rider := geo.Point{Latitude: *body.Latitude, Longitude: *body.Longitude}
+		position := geo.Point{Latitude: *bike.Latitude, Longitude: *bike.Longitude}
+
+		if geo.Distance(rider, position) > scanMaxDistanceMeters() {
+			c.JSON(http.StatusForbidden, gin.H{"message": "you must be near the bike to rent it"})
+			return
+		}
+	}
+
+	var nonce *models.ScanNonce
+	if payload != nil {
+		nonce = &models.ScanNonce{
+			Nonce:     payload.Nonce,
+			BikeID:    bike.ID,
+			UserID:    loggedUser.ID,
+			ExpiresAt: payload.IssuedAt.Add(QRPayloadTTL()),
+		}
+	}
+
+	s.startRental(c, loggedUser, bike, body.PromoCode, nonce)
+}
+
+// startRental books an available bike for the logged user, places the pre-authorization hold of
+// the rental and unlocks the bike, then writes the response. The rider must have passed the
+// rental eligibility rules.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+// - loggedUser: the rider renting the bike.
+// - bike: the bike to rent.
+// - promoCode: the promo code to redeem with the rental, or empty.
+// - nonce: the nonce of the scanned QR payload, or nil when the bike was not rented by QR code.
+//
+// Returns:
+// - None.
+func (s *RentalService) startRental(c *gin.Context, loggedUser *models.User, bike *models.Bike, promoCode string, nonce *models.ScanNonce) {
 	rental := &models.Rental{
 		ID:        uuid.Must(uuid.NewRandom()),
 		UserID:    loggedUser.ID,
@@ -79,22 +219,136 @@ func (s *RentalService) CreateRental(c *gin.Context) {
 		return
 	}
 
-	if err := s.repo.CreateRental(rental); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to create a new rental"})
+	plan, err := s.repo.GetPricingPlanForBike(bike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the pricing plan"})
+		return
+	}
+
+	if plan != nil {
+		rental.PricingPlanID = &plan.ID
+	}
+
+	rental.MaxDurationMinutes = planMaxDurationMinutes(plan)
+	rental.MaxPauseMinutes = planMaxPauseMinutes(plan)
+	rental.Segments = startRentalSegments(rental)
+
+	currency := pricingPlanFor(plan, bike).Currency
+
+	if promoCode != "" {
+		promotion, err := s.rentalPromotion(promoCode, bike, currency)
+		if err != nil {
+			if strings.Contains(err.Error(), "promo code") {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the promo code"})
+			return
+		}
+
+		rental.PromotionID = &promotion.ID
+	}
+
+	available, err := s.stationAvailability(bike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the station availability"})
+		return
+	}
+
+	rental.StartStationID = bike.StationID
+	rental.StartStationAvailableBikes = available
+
+	holdMethod, holdAmount, err := s.rentalHoldMethod(loggedUser.ID, currency)
+	if err != nil {
+		if strings.Contains(err.Error(), "payment method is required") {
+			c.JSON(http.StatusPaymentRequired, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the payment method"})
+		return
+	}
+
+	if err := s.repo.CreateRental(rental, nonce, reservationRentalBuffer(), maxActiveRentals()); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "too many active rentals"):
+			respondRentalRejections(c, []models.RentalRejection{tooManyActiveRentalsRejection()})
+		case strings.Contains(err.Error(), "bike not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "bike is not available to rent"):
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "qr code already used"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "held by another rider"),
+			strings.Contains(err.Error(), "hold on this bike has expired"),
+			strings.Contains(err.Error(), "reserved by another rider"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "promo code"):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurrend when trying to create a new rental"})
+		}
+		return
+	}
+
+	hold, err := s.placeRentalHold(c, rental, holdMethod, holdAmount)
+	if err != nil {
+		s.cancelStartedRental(c, rental, nil)
+		c.JSON(http.StatusPaymentRequired, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.sendLockCommand(c, bike.ID, &rental.ID, lock.ActionUnlock); err != nil {
+		s.cancelStartedRental(c, rental, hold)
+		c.JSON(http.StatusBadGateway, gin.H{"message": "the bike could not be unlocked, please try again"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"rental_id": rental.ID})
 }
 
-// ReturnBike handles the process of returning a rented bike.
+// cancelStartedRental rolls back a rental whose pre-authorization hold or unlock failed: the
+// rental is cancelled, its bike is available again, the nonce of its QR payload can be scanned
+// again, and hold, when it is not nil, is released so nothing stays held on the card. Failures
+// are logged.
+func (s *RentalService) cancelStartedRental(ctx context.Context, rental *models.Rental, hold *models.Payment) {
+	if err := s.repo.CancelRental(rental); err != nil {
+		slog.Error("failed to roll back rental", "rental_id", rental.ID, "error", err)
+	}
+
+	if hold != nil {
+		s.releaseRentalHold(ctx, hold)
+	}
+}
+
+// ReturnBike handles the process of returning a rented bike, active or paused. The bike is
+// locked where the optional "latitude" and "longitude" fields of the body say, or at its last
+// known position, then the rental is priced and completed and its price and penalties collected.
 //
 // Parameters:
 // - c: a gin.Context object representing the HTTP request and response.
-// Return type(s): None.
+//
+// Returns:
+// - None.
 func (s *RentalService) ReturnBike(c *gin.Context) {
 	id := c.Param("rentalId")
 
+	var body struct {
+		Latitude  *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+		Longitude *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
 	loggedUser, err := utils.GetUserFromContext(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
@@ -121,33 +375,232 @@ func (s *RentalService) ReturnBike(c *gin.Context) {
 		return
 	}
 
+	if !rental.Status.InProgress() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "rental is not active"})
+		return
+	}
+
 	bike, err := s.repo.GetBikeByID(rental.BikeID.String())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve bike"})
 		return
 	}
 
-	now := time.Now()
-	rental.EndTime = now
+	if body.Latitude == nil && bike.Latitude != nil && bike.Longitude != nil {
+		body.Latitude, body.Longitude = bike.Latitude, bike.Longitude
+	}
+
+	zones, err := s.repo.GetParkingZones()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve zones"})
+		return
+	}
+
+	zoneCheck := returnCheck{Allowed: true}
+	if len(*zones) > 0 {
+		if body.Latitude == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "the return position is required"})
+			return
+		}
+
+		zoneCheck = checkReturnPosition(*zones, geo.Point{Latitude: *body.Latitude, Longitude: *body.Longitude})
+		if !zoneCheck.Allowed {
+			c.JSON(http.StatusForbidden, gin.H{"message": zoneCheck.Reason})
+			return
+		}
+	}
+
+	returnStation, returnAvailable, err := s.returnStation(bike, body.Latitude, body.Longitude)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve stations"})
+		return
+	}
+
+	pricingStep, err := s.returnPricing(rental, bike, returnStation, returnAvailable)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to price the rental"})
+		return
+	}
+
+	if !zoneCheck.Fee.IsZero() && pricingStep.Plan.Currency != "" && zoneCheck.Fee.Currency != pricingStep.Plan.Currency {
+		slog.Error("zone fee currency does not match the rental currency", "rental_id", rental.ID, "fee", zoneCheck.Fee, "currency", pricingStep.Plan.Currency)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to price the rental"})
+		return
+	}
+
+	if err := s.sendLockCommand(c, bike.ID, &rental.ID, lock.ActionLock); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"message": "the bike could not be locked, make sure it is properly parked and try again"})
+		return
+	}
+
+	rental.EndTime = time.Now()
 	rental.Status = models.RENTAL_STATUS_COMPLETED
+	rental.ReturnLatitude = body.Latitude
+	rental.ReturnLongitude = body.Longitude
+	rental.ZoneFee = zoneCheck.Fee
 
-	duration := rental.EndTime.Sub(rental.StartTime).Hours()
-	totalCost := math.Round(duration*bike.PricePerHour*100) / 100
-	rental.TotalCost = totalCost
+	if returnStation != nil {
+		rental.ReturnStationID = &returnStation.ID
+	}
+
+	endedByOperations := loggedUser.Role == models.UserRoleAdmin && rental.UserID != loggedUser.ID
+	breakdown, taxes := priceReturnedRental(rental, pricingStep, endedByOperations)
+	s.summarizeRentalTrip(rental)
+
+	var usage *models.PassUsage
+	if pricingStep.Pass != nil {
+		usage = passUsage(pricingStep.Pass, rental, breakdown)
+	}
+
+	if err := s.repo.CompleteRental(rental, usage, breakdownDiscount(breakdown)); err != nil {
+		if strings.Contains(err.Error(), "rental is not active") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		if strings.Contains(err.Error(), "pass quota is used up") {
+			c.JSON(http.StatusConflict, gin.H{"message": "your pass was used by another ride in the meantime, please return the bike again"})
+			return
+		}
 
-	if err := s.repo.UpdateRental(rental); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update rental"})
 		return
 	}
 
-	if err := s.repo.UpdateBikeStatus(bike.ID.String(), models.BIKE_STATUS_AVAILABLE); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update bike status"})
+	s.parkReturnedBike(bike, rental)
+
+	payments := s.collectRentalPayment(c, rental)
+	s.collectPenalties(c, rental)
+
+	if s.receipts != nil {
+		go s.receipts.SendRentalReceipt(rental.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_time":        rental.EndTime.Sub(rental.StartTime).Hours(),
+		"total_price":       rental.TotalCost,
+		"total_price_text":  rental.TotalCost.Format(utils.GetLocale(c)),
+		"zone_fee":          zoneCheck.Fee,
+		"price_breakdown":   breakdown,
+		"taxes":             taxes.Lines,
+		"distance_meters":   rental.DistanceMeters,
+		"average_speed_kmh": rental.AverageSpeedKmh,
+		"max_speed_kmh":     rental.MaxSpeedKmh,
+		"payments":          payments,
+		"penalties":         rental.Penalties,
+		"segments":          rental.Segments,
+	})
+}
+
+// QuoteRental estimates the price of renting a bike for the number of minutes given by the
+// "minutes" query parameter (30 by default), using the pricing plan that applies to the bike
+// and the pricing rules in force now. What the passes of the rider cover is included, and so is
+// the discount of the "promo_code" query parameter when the rider can redeem it, and the total
+// includes the taxes of the station of the bike. Discounts for the return station are not
+// included.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) QuoteRental(c *gin.Context) {
+	minutes := 30
+	if value := c.Query("minutes"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > 7*24*60 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "minutes must be between 0 and 10080"})
+			return
+		}
+
+		minutes = parsed
+	}
+
+	bike, err := s.repo.GetBikeByID(c.Param("bikeId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "bike not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve bike"})
+		return
+	}
+
+	plan, err := s.repo.GetPricingPlanForBike(bike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the pricing plan"})
 		return
 	}
 
+	enginePlan, err := s.rentalPricingPlan(plan, bike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the pricing rules"})
+		return
+	}
+
+	available, err := s.stationAvailability(bike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the station availability"})
+		return
+	}
+
+	conditions, err := s.rentalPricingConditions(time.Now(), bike.StationID, available, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the holiday calendar"})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	_, entitlement, err := s.ridePass(loggedUser.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the rider passes"})
+		return
+	}
+
+	enginePlan.Entitlement = entitlement
+
+	if code := c.Query("promo_code"); code != "" {
+		promotion, err := s.rentalPromotion(code, bike, enginePlan.Currency)
+		if err == nil {
+			err = s.repo.CheckPromotionRedemption(promotion, loggedUser.ID)
+		}
+
+		if err != nil {
+			if strings.Contains(err.Error(), "promo code") {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the promo code"})
+			return
+		}
+
+		enginePlan.Discount = promotionDiscount(promotion)
+	}
+
+	taxRates, err := s.rentalTaxRates(time.Now(), bike.StationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the tax rates"})
+		return
+	}
+
+	breakdown := enginePlan.Price(time.Duration(minutes)*time.Minute, conditions)
+	taxes, _ := rentalTaxes(uuid.Nil, breakdown.Total, taxRates)
+
 	c.JSON(http.StatusOK, gin.H{
-		"total_time":  duration,
-		"total_price": totalCost,
+		"bike_id":          bike.ID,
+		"minutes":          minutes,
+		"pricing_plan":     plan,
+		"price_breakdown":  breakdown,
+		"taxes":            taxes.Lines,
+		"total_price":      taxes.Total,
+		"total_price_text": taxes.Total.Format(utils.GetLocale(c)),
 	})
 }
 
@@ -194,3 +647,81 @@ func (s *RentalService) GetRentalByUserID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, rentals)
 }
+
+// scanMaxDistanceMeters returns how far from a bike a rider may scan it, read from the
+// "SCAN_MAX_DISTANCE_METERS" environment variable.
+func scanMaxDistanceMeters() float64 {
+	if distance, err := strconv.ParseFloat(os.Getenv("SCAN_MAX_DISTANCE_METERS"), 64); err == nil && distance > 0 {
+		return distance
+	}
+
+	return 150
+}
+
+// sendLockCommand commands the lock of a bike and waits for its acknowledgement for at most
+// "LOCK_ACK_TIMEOUT" (10 seconds by default). Every command is recorded with its outcome.
+// It is a no-op when no lock driver is configured.
+func (s *RentalService) sendLockCommand(ctx context.Context, bikeID uuid.UUID, rentalID *uuid.UUID, action lock.Action) error {
+	if s.lock == nil {
+		return nil
+	}
+
+	command := &models.LockCommand{
+		ID:       uuid.Must(uuid.NewRandom()),
+		BikeID:   bikeID,
+		RentalID: rentalID,
+		Action:   string(action),
+		Status:   models.LOCK_COMMAND_STATUS_PENDING,
+		SentAt:   time.Now(),
+	}
+
+	if err := s.repo.CreateLockCommand(command); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lockAckTimeout())
+	defer cancel()
+
+	ack, err := s.lock.Send(ctx, lock.Command{
+		ID:       command.ID.String(),
+		BikeID:   bikeID.String(),
+		Action:   action,
+		IssuedAt: command.SentAt,
+	})
+
+	switch {
+	case err == nil:
+		command.Status = models.LOCK_COMMAND_STATUS_ACKNOWLEDGED
+	case errors.Is(err, lock.ErrTimeout):
+		command.Status = models.LOCK_COMMAND_STATUS_TIMEOUT
+	case errors.Is(err, lock.ErrRejected):
+		command.Status = models.LOCK_COMMAND_STATUS_REJECTED
+	default:
+		command.Status = models.LOCK_COMMAND_STATUS_FAILED
+	}
+
+	if ack != nil {
+		command.AcknowledgedAt = &ack.ReceivedAt
+	}
+
+	if err != nil {
+		command.Error = err.Error()
+		slog.Error("lock command failed", "command_id", command.ID, "bike_id", bikeID, "action", action, "error", err)
+	}
+
+	if updateErr := s.repo.UpdateLockCommand(command); updateErr != nil {
+		slog.Error("failed to update lock command", "command_id", command.ID, "error", updateErr)
+	}
+
+	return err
+}
+
+// lockAckTimeout returns how long to wait for a lock acknowledgement, read from the
+// "LOCK_ACK_TIMEOUT" environment variable.
+func lockAckTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(os.Getenv("LOCK_ACK_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+
+	return 10 * time.Second
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/tax"
+)
+
+// rentalTaxRates retrieves the tax rates in force at a time in the jurisdiction of a ride.
+func (s *RentalService) rentalTaxRates(at time.Time, stationIDs ...*uuid.UUID) ([]models.TaxRate, error) {
+	country, city, err := s.taxJurisdiction(stationIDs...)
+	if err != nil || country == "" {
+		return nil, err
+	}
+
+	rates, err := s.repo.GetTaxRatesInForce(country, city, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return *rates, nil
+}
+
+// taxJurisdiction returns the country and city whose taxes are charged on a ride, that of the
+// first of the stations that still exists. Rides without a station are taxed in the country of
+// the "TAX_DEFAULT_COUNTRY" environment variable, or are not taxed when it is not set.
+func (s *RentalService) taxJurisdiction(stationIDs ...*uuid.UUID) (string, string, error) {
+	for _, id := range stationIDs {
+		if id == nil {
+			continue
+		}
+
+		station, err := s.repo.GetStationByID(id.String())
+		if err != nil {
+			if strings.Contains(err.Error(), "station not found") {
+				continue
+			}
+
+			return "", "", err
+		}
+
+		return station.Country, station.City, nil
+	}
+
+	return strings.ToUpper(os.Getenv("TAX_DEFAULT_COUNTRY")), "", nil
+}
+
+// rentalTaxes computes the taxes charged on the price of a rental at the given rates. It returns
+// the assessment and one tax line per rate, to be stored with the rental.
+func rentalTaxes(rentalID uuid.UUID, price money.Money, rates []models.TaxRate) (tax.Assessment, []models.RentalTaxLine) {
+	engineRates := make([]tax.Rate, 0, len(rates))
+	for _, rate := range rates {
+		engineRates = append(engineRates, tax.Rate{Name: rate.Name, Percent: rate.Percent, Inclusive: rate.Inclusive})
+	}
+
+	assessment := tax.Assess(price, engineRates, utils.MoneyRounding())
+
+	lines := make([]models.RentalTaxLine, 0, len(assessment.Lines))
+	for i, line := range assessment.Lines {
+		lines = append(lines, models.RentalTaxLine{
+			ID:        uuid.Must(uuid.NewRandom()),
+			RentalID:  rentalID,
+			TaxRateID: rates[i].ID,
+			Name:      rates[i].Name,
+			Country:   rates[i].Country,
+			City:      rates[i].City,
+			Percent:   line.Percent,
+			Inclusive: line.Inclusive,
+			Base:      line.Base,
+			Amount:    line.Amount,
+		})
+	}
+
+	return assessment, lines
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/geo"
+)
+
+// tripPointClockSkew is how far outside the rental window a fix may be timestamped, to
+// tolerate devices whose clocks drift.
+const tripPointClockSkew = time.Minute
+
+// AddTripPoints stores a batch of GPS fixes for an active rental. It is called by the bike or
+// by the rider's app while riding.
+//
+// Fixes timestamped outside the rental are dropped and counted as rejected; fixes already
+// received are ignored, so a batch can be resent after a network failure. The latest fix also
+// becomes the last known position of the bike.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) AddTripPoints(c *gin.Context) {
+	var body struct {
+		Points []struct {
+			Latitude   *float64   `json:"latitude" validate:"required,latitude"`
+			Longitude  *float64   `json:"longitude" validate:"required,longitude"`
+			RecordedAt *time.Time `json:"recorded_at" validate:"required"`
+		} `json:"points" validate:"required,min=1,max=500,dive"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	rental, ok := s.getRentalOfLoggedUser(c, c.Param("rentalId"))
+	if !ok {
+		return
+	}
+
+	if !rental.Status.InProgress() {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "rental is not active"})
+		return
+	}
+
+	earliest := rental.StartTime.Add(-tripPointClockSkew)
+	latest := time.Now().Add(tripPointClockSkew)
+
+	points := make([]models.TripPoint, 0, len(body.Points))
+	var last *models.TripPoint
+
+	for _, p := range body.Points {
+		if p.RecordedAt.Before(earliest) || p.RecordedAt.After(latest) {
+			continue
+		}
+
+		points = append(points, models.TripPoint{
+			ID:         uuid.Must(uuid.NewRandom()),
+			RentalID:   rental.ID,
+			Latitude:   *p.Latitude,
+			Longitude:  *p.Longitude,
+			RecordedAt: p.RecordedAt.UTC(),
+		})
+
+		if last == nil || points[len(points)-1].RecordedAt.After(last.RecordedAt) {
+			last = &points[len(points)-1]
+		}
+	}
+
+	if len(points) > 0 {
+		if err := s.repo.CreateTripPoints(points); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to store trip points"})
+			return
+		}
+
+		if err := s.repo.UpdateBikePosition(rental.BikeID.String(), last.Latitude, last.Longitude); err != nil {
+			slog.Error("failed to update bike position", "bike_id", rental.BikeID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accepted": len(points), "rejected": len(body.Points) - len(points)})
+}
+
+// GetRentalRoute returns the route of a rental with its distance and speeds.
+//
+// The "format" query parameter selects the representation: "geojson" (the default) returns a
+// GeoJSON Feature with a LineString geometry, and "polyline" returns an encoded polyline.
+//
+// Parameters:
+// - c: a gin.Context object representing the HTTP request and response.
+//
+// Returns:
+// - None.
+func (s *RentalService) GetRentalRoute(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "geojson"))
+	if format != "geojson" && format != "polyline" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "format must be geojson or polyline"})
+		return
+	}
+
+	rental, ok := s.getRentalOfLoggedUser(c, c.Param("rentalId"))
+	if !ok {
+		return
+	}
+
+	track, err := s.getRentalTrack(rental.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get trip points"})
+		return
+	}
+
+	summary := geo.Summarize(track)
+
+	route := make([]geo.Point, len(track))
+	for i, p := range track {
+		route[i] = p.Point
+	}
+
+	if format == "polyline" {
+		c.JSON(http.StatusOK, gin.H{
+			"rental_id":         rental.ID,
+			"polyline":          geo.EncodePolyline(route),
+			"points":            len(route),
+			"distance_meters":   summary.DistanceMeters,
+			"average_speed_kmh": summary.AverageSpeedKmh,
+			"max_speed_kmh":     summary.MaxSpeedKmh,
+		})
+		return
+	}
+
+	timestamps := make([]time.Time, len(track))
+	for i, p := range track {
+		timestamps[i] = p.RecordedAt
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":     "Feature",
+		"id":       rental.ID,
+		"geometry": geo.EncodeLineString(route),
+		"properties": gin.H{
+			"status":            rental.Status,
+			"start_time":        rental.StartTime,
+			"distance_meters":   summary.DistanceMeters,
+			"average_speed_kmh": summary.AverageSpeedKmh,
+			"max_speed_kmh":     summary.MaxSpeedKmh,
+			"timestamps":        timestamps,
+		},
+	})
+}
+
+// getRentalTrack loads the route of a rental as track points.
+func (s *RentalService) getRentalTrack(rentalID string) ([]geo.TrackPoint, error) {
+	points, err := s.repo.GetTripPoints(rentalID)
+	if err != nil {
+		return nil, err
+	}
+
+	track := make([]geo.TrackPoint, len(*points))
+	for i, p := range *points {
+		track[i] = geo.TrackPoint{Point: geo.Point{Latitude: p.Latitude, Longitude: p.Longitude}, RecordedAt: p.RecordedAt}
+	}
+
+	return track, nil
+}
+
+// getRentalOfLoggedUser retrieves a rental that belongs to the logged user, or any rental for
+// admins. It writes the error response and returns false when the rental cannot be used.
+func (s *RentalService) getRentalOfLoggedUser(c *gin.Context, id string) (*models.Rental, bool) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return nil, false
+	}
+
+	rental, err := s.repo.GetRentalByID(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "rental not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to retrieve rental"})
+		return nil, false
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && rental.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "you are not allowed to access this rental"})
+		return nil, false
+	}
+
+	return rental, true
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+)
+
+// reservationSweepInterval is how often pending reservations are checked for no-shows.
+const reservationSweepInterval = time.Minute
+
+type ReservationService struct {
+	repo repositories.ReservationRepository
+}
+
+// NewReservationService creates a new instance of the ReservationService struct.
+//
+// It takes a repositories.ReservationRepository as a parameter and returns a pointer
+// to a ReservationService.
+func NewReservationService(repo repositories.ReservationRepository) *ReservationService {
+	return &ReservationService{repo: repo}
+}
+
+// CreateReservation books a bike, or any bike of a station, for a future time window.
+//
+// The window must start in the future, within "RESERVATION_MAX_ADVANCE" (7 days by default),
+// and last at most "RESERVATION_MAX_DURATION" (4 hours by default). It may not overlap another
+// reservation of the bike or of the rider, nor start within "RESERVATION_RENTAL_BUFFER" while
+// the bike is rented.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReservationService) CreateReservation(c *gin.Context) {
+	var body struct {
+		BikeID    *uuid.UUID `json:"bike_id" validate:"required_without=StationID"`
+		StationID *uuid.UUID `json:"station_id" validate:"required_without=BikeID"`
+		StartTime *time.Time `json:"start_time" validate:"required"`
+		EndTime   *time.Time `json:"end_time" validate:"required"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	now := time.Now()
+
+	switch {
+	case body.StartTime.Before(now):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "start_time must be in the future"})
+		return
+	case body.StartTime.After(now.Add(reservationMaxAdvance())):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "start_time is too far in the future"})
+		return
+	case !body.EndTime.After(*body.StartTime):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "end_time must be after start_time"})
+		return
+	case body.EndTime.Sub(*body.StartTime) > reservationMaxDuration():
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "reservation is longer than " + reservationMaxDuration().String()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	reservation := &models.Reservation{
+		ID:        uuid.Must(uuid.NewRandom()),
+		UserID:    loggedUser.ID,
+		StationID: body.StationID,
+		StartTime: body.StartTime.UTC(),
+		EndTime:   body.EndTime.UTC(),
+		Status:    models.RESERVATION_STATUS_PENDING,
+	}
+
+	if body.BikeID != nil {
+		reservation.BikeID = *body.BikeID
+		reservation.StationID = nil
+		err = s.repo.CreateReservation(reservation, reservationRentalBuffer())
+	} else {
+		err = s.repo.CreateStationReservation(reservation, reservationRentalBuffer())
+	}
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "bike not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "already reserved"),
+			strings.Contains(err.Error(), "already have a reservation"),
+			strings.Contains(err.Error(), "not available"),
+			strings.Contains(err.Error(), "no bike is available"),
+			strings.Contains(err.Error(), "may not be back in time"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a reservation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// GetMyReservations retrieves the reservations of the logged user, optionally filtered by the
+// "status" query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReservationService) GetMyReservations(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	reservations, pagination, err := s.repo.GetReservationsByUserID(loggedUser.ID.String(), c.Query("status"), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": reservations, "pagination": pagination})
+}
+
+// GetAllReservations retrieves the reservations of every rider, optionally filtered by the
+// "status" query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReservationService) GetAllReservations(c *gin.Context) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	reservations, pagination, err := s.repo.GetAllReservations(c.Query("status"), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": reservations, "pagination": pagination})
+}
+
+// GetReservationByID retrieves a reservation of the logged user, or any reservation for admins.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReservationService) GetReservationByID(c *gin.Context) {
+	reservation, ok := s.getReservationOfLoggedUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CancelReservation cancels a pending reservation of the logged user, or any pending
+// reservation for admins.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ReservationService) CancelReservation(c *gin.Context) {
+	reservation, ok := s.getReservationOfLoggedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := s.repo.CancelReservation(reservation.ID.String()); err != nil {
+		if strings.Contains(err.Error(), "reservation is not pending") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to cancel reservation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reservation cancelled successfully"})
+}
+
+// SweepNoShows periodically marks as no-shows the reservations whose rider did not start the
+// rental within "RESERVATION_GRACE_PERIOD" (15 minutes by default) of the reservation start,
+// releasing their bikes.
+//
+// It is meant to be run in its own goroutine for the lifetime of the server.
+func (s *ReservationService) SweepNoShows() {
+	ticker := time.NewTicker(reservationSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := s.repo.MarkNoShows(time.Now().Add(-reservationGracePeriod()))
+		if err != nil {
+			slog.Error("failed to mark reservation no-shows", "error", err)
+			continue
+		}
+
+		if count > 0 {
+			slog.Info("reservations marked as no-show", "count", count)
+		}
+	}
+}
+
+// getReservationOfLoggedUser retrieves the reservation in the "id" path parameter if it belongs
+// to the logged user or the user is an admin. It writes the error response and returns false
+// otherwise.
+func (s *ReservationService) getReservationOfLoggedUser(c *gin.Context) (*models.Reservation, bool) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return nil, false
+	}
+
+	reservation, err := s.repo.GetReservationByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "reservation not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get reservation"})
+		return nil, false
+	}
+
+	if loggedUser.Role != models.UserRoleAdmin && reservation.UserID != loggedUser.ID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "you are not allowed to access this reservation"})
+		return nil, false
+	}
+
+	return reservation, true
+}
+
+// reservationGracePeriod returns how long after its start a reservation waits for the rider,
+// read from the "RESERVATION_GRACE_PERIOD" environment variable.
+func reservationGracePeriod() time.Duration {
+	return durationFromEnv("RESERVATION_GRACE_PERIOD", 15*time.Minute)
+}
+
+// reservationMaxDuration returns the longest window a reservation may cover, read from the
+// "RESERVATION_MAX_DURATION" environment variable.
+func reservationMaxDuration() time.Duration {
+	return durationFromEnv("RESERVATION_MAX_DURATION", 4*time.Hour)
+}
+
+// reservationMaxAdvance returns how far ahead a reservation may start, read from the
+// "RESERVATION_MAX_ADVANCE" environment variable.
+func reservationMaxAdvance() time.Duration {
+	return durationFromEnv("RESERVATION_MAX_ADVANCE", 7*24*time.Hour)
+}
+
+// reservationRentalBuffer returns how long before its start a reservation keeps other riders
+// from renting the bike, and how long an active rental is assumed to last when checking new
+// reservations. It is read from the "RESERVATION_RENTAL_BUFFER" environment variable.
+func reservationRentalBuffer() time.Duration {
+	return durationFromEnv("RESERVATION_RENTAL_BUFFER", 30*time.Minute)
+}
+
+// durationFromEnv reads a positive duration from an environment variable, falling back to a
+// default when it is missing or invalid.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(os.Getenv(name)); err == nil && duration > 0 {
+		return duration
+	}
+
+	return fallback
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+)
+
+type StationService struct {
+	repo repositories.StationRepository
+}
+
+// NewStationService creates a new instance of the StationService struct.
+//
+// It takes a repositories.StationRepository as a parameter and returns a pointer
+// to a StationService.
+func NewStationService(repo repositories.StationRepository) *StationService {
+	return &StationService{repo: repo}
+}
+
+// CreateStation creates a new station based on the JSON input in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *StationService) CreateStation(c *gin.Context) {
+	station := new(models.Station)
+
+	if err := c.BindJSON(station); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	station.ID = uuid.Must(uuid.NewRandom())
+	station.Country = strings.ToUpper(station.Country)
+
+	if err := utils.ValidateModel(station); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreateStation(station); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new station"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, station)
+}
+
+// GetAllStations retrieves the stations, optionally filtered by the "city" query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *StationService) GetAllStations(c *gin.Context) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	stations, pagination, err := s.repo.GetAllStations(c.Query("city"), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get stations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stations, "pagination": pagination})
+}
+
+// GetStationByID retrieves a station with the bikes parked at it.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *StationService) GetStationByID(c *gin.Context) {
+	station, err := s.repo.GetStationByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "station not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get station"})
+		return
+	}
+
+	bikes, err := s.repo.GetStationBikes(station.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get station bikes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"station": station, "bikes": bikes})
+}
+
+// UpdateStation updates a station based on the input JSON data in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *StationService) UpdateStation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "station not found"})
+		return
+	}
+
+	var station models.Station
+	if err := c.BindJSON(&station); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	station.ID = id
+	station.Country = strings.ToUpper(station.Country)
+
+	if err := utils.ValidateModel(&station); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.UpdateStation(&station); err != nil {
+		if strings.Contains(err.Error(), "station not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update station"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "station updated successfully"})
+}
+
+// DeleteStation deletes a station that no longer has bikes.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *StationService) DeleteStation(c *gin.Context) {
+	if err := s.repo.DeleteStation(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "station not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		if strings.Contains(err.Error(), "station still has") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete station"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+)
+
+type TaxService struct {
+	repo repositories.TaxRepository
+}
+
+// NewTaxService creates a new instance of the TaxService struct.
+//
+// It takes a repositories.TaxRepository as a parameter and returns a pointer to a TaxService.
+func NewTaxService(repo repositories.TaxRepository) *TaxService {
+	return &TaxService{repo: repo}
+}
+
+// CreateTaxRate adds a tax rate based on the JSON input in the request body. A rate with the
+// name and jurisdiction of an existing rate is a new version of it, which replaces it from its
+// "effective_from" time on. Rates take effect now by default and cannot take effect in the past,
+// so the rentals already charged keep the rates they were charged with.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *TaxService) CreateTaxRate(c *gin.Context) {
+	rate := new(models.TaxRate)
+
+	if err := c.BindJSON(rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	now := time.Now()
+
+	rate.ID = uuid.Must(uuid.NewRandom())
+	rate.Country = strings.ToUpper(rate.Country)
+	rate.City = strings.ToLower(strings.TrimSpace(rate.City))
+
+	if rate.EffectiveFrom.IsZero() {
+		rate.EffectiveFrom = now
+	}
+
+	if err := utils.ValidateModel(rate); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if rate.EffectiveFrom.Before(now.Add(-time.Minute)) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "a tax rate cannot take effect in the past"})
+		return
+	}
+
+	if err := s.repo.CreateTaxRate(rate); err != nil {
+		if strings.Contains(err.Error(), "tax rate already exists") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new tax rate"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// GetTaxRates retrieves every version of the tax rates, optionally of the country given by the
+// "country" query parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *TaxService) GetTaxRates(c *gin.Context) {
+	rates, err := s.repo.GetTaxRates(strings.ToUpper(c.Query("country")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get tax rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// GetTaxRateByID retrieves a tax rate.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *TaxService) GetTaxRateByID(c *gin.Context) {
+	rate, err := s.repo.GetTaxRateByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "tax rate not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get tax rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteTaxRate deletes a tax rate that is not in force yet. A rate in force is ended by a new
+// version with a rate of zero.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *TaxService) DeleteTaxRate(c *gin.Context) {
+	if err := s.repo.DeleteTaxRate(c.Param("id"), time.Now()); err != nil {
+		if strings.Contains(err.Error(), "tax rate not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		if strings.Contains(err.Error(), "tax rate already in force") {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete tax rate"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetRevenueReport sums the revenue of the rentals completed between the "from" and "to" dates
+// given as query parameters (YYYY-MM-DD, both included, in the pricing time zone), and the taxes
+// charged on them. The period defaults to the current month.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *TaxService) GetRevenueReport(c *gin.Context) {
+	location := pricingLocation()
+	now := time.Now().In(location)
+
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	to := from.AddDate(0, 1, -1)
+
+	for _, bound := range []struct {
+		value string
+		date  *time.Time
+	}{{c.Query("from"), &from}, {c.Query("to"), &to}} {
+		if bound.value == "" {
+			continue
+		}
+
+		parsed, err := time.ParseInLocation(time.DateOnly, bound.value, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "dates must be formatted as YYYY-MM-DD"})
+			return
+		}
+
+		*bound.date = parsed
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "the end of the period must not be before its start"})
+		return
+	}
+
+	revenue, taxes, err := s.repo.GetRevenue(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get the revenue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevenueReport{
+		From:    from.Format(time.DateOnly),
+		To:      to.Format(time.DateOnly),
+		Revenue: *revenue,
+		Taxes:   *taxes,
+	})
+}
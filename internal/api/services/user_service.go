@@ -20,16 +20,20 @@ type UserService struct {
 }
 
 type UserResponseDTO struct {
-	ID        uuid.UUID             `json:"id"`
-	Email     string                `json:"email"`
-	Name      string                `json:"name"`
-	Phone     string                `json:"phone"`
-	Image     string                `json:"image"`
-	Status    models.UserStatusEnum `json:"status"`
-	Role      models.UserRoleEnum   `json:"role"`
-	Verified  bool                  `json:"verified"`
-	CreatedAt time.Time             `json:"created_at"`
-	UpdatedAt time.Time             `json:"updated_at"`
+	ID                 uuid.UUID             `json:"id"`
+	Email              string                `json:"email"`
+	Name               string                `json:"name"`
+	Phone              string                `json:"phone"`
+	Image              string                `json:"image"`
+	Status             models.UserStatusEnum `json:"status"`
+	Role               models.UserRoleEnum   `json:"role"`
+	Verified           bool                  `json:"verified"`
+	BirthDate          string                `json:"birth_date"`
+	TermsVersion       string                `json:"terms_version"`
+	TermsAcceptedAt    *time.Time            `json:"terms_accepted_at"`
+	CorporateAccountID *uuid.UUID            `json:"corporate_account_id"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
 }
 
 // NewUserService creates a new instance of the UserService struct.
@@ -93,16 +97,20 @@ func (s *UserService) GetAllUsers(c *gin.Context) {
 	response := []UserResponseDTO{}
 	for _, user := range *users {
 		response = append(response, UserResponseDTO{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			Phone:     user.Phone,
-			Image:     user.Image,
-			Status:    user.Status,
-			Role:      user.Role,
-			Verified:  user.Verified,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
+			ID:                 user.ID,
+			Email:              user.Email,
+			Name:               user.Name,
+			Phone:              user.Phone,
+			Image:              user.Image,
+			Status:             user.Status,
+			Role:               user.Role,
+			Verified:           user.Verified,
+			BirthDate:          user.BirthDate,
+			TermsVersion:       user.TermsVersion,
+			TermsAcceptedAt:    user.TermsAcceptedAt,
+			CorporateAccountID: user.CorporateAccountID,
+			CreatedAt:          user.CreatedAt,
+			UpdatedAt:          user.UpdatedAt,
 		})
 	}
 
@@ -137,16 +145,20 @@ func (s *UserService) GetUserByID(c *gin.Context) {
 	}
 
 	response := UserResponseDTO{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Phone:     user.Phone,
-		Image:     user.Image,
-		Status:    user.Status,
-		Role:      user.Role,
-		Verified:  user.Verified,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		Name:               user.Name,
+		Phone:              user.Phone,
+		Image:              user.Image,
+		Status:             user.Status,
+		Role:               user.Role,
+		Verified:           user.Verified,
+		BirthDate:          user.BirthDate,
+		TermsVersion:       user.TermsVersion,
+		TermsAcceptedAt:    user.TermsAcceptedAt,
+		CorporateAccountID: user.CorporateAccountID,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -172,10 +184,11 @@ func (s *UserService) UpdateUser(c *gin.Context) {
 	}
 
 	var body struct {
-		Email string `json:"email"`
-		Name  string `json:"name"`
-		Phone string `json:"phone"`
-		Image string `json:"image"`
+		Email     string `json:"email"`
+		Name      string `json:"name"`
+		Phone     string `json:"phone"`
+		Image     string `json:"image"`
+		BirthDate string `json:"birth_date" validate:"omitempty,datetime=2006-01-02"`
 	}
 
 	if err := c.BindJSON(&body); err != nil {
@@ -189,11 +202,12 @@ func (s *UserService) UpdateUser(c *gin.Context) {
 	}
 
 	err = s.repo.UpdateUser(&models.User{
-		ID:    uuid.MustParse(id),
-		Email: body.Email,
-		Name:  body.Name,
-		Phone: body.Phone,
-		Image: body.Image,
+		ID:        uuid.MustParse(id),
+		Email:     body.Email,
+		Name:      body.Name,
+		Phone:     body.Phone,
+		Image:     body.Image,
+		BirthDate: body.BirthDate,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
@@ -203,6 +217,59 @@ func (s *UserService) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "user updated successfully"})
 }
 
+// AcceptTerms records that the user accepted the current terms of service. The body carries the
+// "version" the user accepted, which must be the current one.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return type: None.
+func (s *UserService) AcceptTerms(c *gin.Context) {
+	id := c.Params.ByName("id")
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	if loggedUser.ID.String() != id {
+		c.JSON(http.StatusForbidden, gin.H{"message": "access to this resource is forbidden"})
+		return
+	}
+
+	var body struct {
+		Version string `json:"version" validate:"required,max=50"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if version := termsVersion(); body.Version != version {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "the current terms of service version is " + version, "current_version": version})
+		return
+	}
+
+	acceptedAt := time.Now()
+	err = s.repo.UpdateUser(&models.User{
+		ID:              loggedUser.ID,
+		TermsVersion:    body.Version,
+		TermsAcceptedAt: &acceptedAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to accept the terms of service"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "terms of service accepted successfully"})
+}
+
 // UpdatePassword updates the user's password.
 //
 // Parameters:
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+type WalletService struct {
+	repo repositories.WalletRepository
+}
+
+// NewWalletService creates a new instance of the WalletService struct.
+//
+// It takes a repositories.WalletRepository as a parameter and returns a pointer
+// to a WalletService.
+func NewWalletService(repo repositories.WalletRepository) *WalletService {
+	return &WalletService{repo: repo}
+}
+
+// GetMyWallet retrieves the balances and the latest entries of the wallet of the logged user.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) GetMyWallet(c *gin.Context) {
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	s.getWallet(c, loggedUser.ID.String())
+}
+
+// GetWalletByUserID retrieves the balances and the latest entries of the wallet of the user in
+// the "userId" path parameter.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) GetWalletByUserID(c *gin.Context) {
+	user, ok := s.getUser(c)
+	if !ok {
+		return
+	}
+
+	s.getWallet(c, user.ID.String())
+}
+
+// TopUpWallet adds money to the wallet of the logged user. The body carries the "amount" paid
+// in.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) TopUpWallet(c *gin.Context) {
+	var body struct {
+		Amount money.Money `json:"amount" validate:"money=positive"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	transaction, err := s.repo.TopUpWallet(loggedUser.ID, body.Amount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to top up wallet"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// AdjustWallet credits or debits the wallet of the user in the "userId" path parameter. The body
+// carries the "amount", negative to debit the wallet, and the "reason" of the adjustment.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) AdjustWallet(c *gin.Context) {
+	var body struct {
+		Amount money.Money `json:"amount"`
+		Reason string      `json:"reason" validate:"required,max=500"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if body.Amount.IsZero() || !money.IsSupported(body.Amount.Currency) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "amount must be a non-zero amount in a supported currency"})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	user, ok := s.getUser(c)
+	if !ok {
+		return
+	}
+
+	transaction, err := s.repo.AdjustWallet(user.ID, body.Amount, body.Reason, loggedUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to adjust wallet"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// RefundRental credits back to the wallet of its rider what a rental was charged. The body
+// carries the "rental_id", the "reason" of the refund and, for a partial refund, the "amount".
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) RefundRental(c *gin.Context) {
+	var body struct {
+		RentalID *uuid.UUID   `json:"rental_id" validate:"required"`
+		Amount   *money.Money `json:"amount" validate:"omitempty,money=positive"`
+		Reason   string       `json:"reason" validate:"required,max=500"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	transaction, err := s.repo.RefundRental(body.RentalID.String(), body.Amount, body.Reason, loggedUser.ID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "rental not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "refund"):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to refund rental"})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// GetLedgerTransactions retrieves ledger transactions with their entries, the most recent first.
+// The "user_id" and "rental_id" query parameters keep the transactions of a wallet or a rental.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) GetLedgerTransactions(c *gin.Context) {
+	for _, param := range []string{"user_id", "rental_id"} {
+		if value := c.Query(param); value != "" {
+			if _, err := uuid.Parse(value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": param + " must be a valid UUID"})
+				return
+			}
+		}
+	}
+
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	transactions, pagination, err := s.repo.GetLedgerTransactions(c.Query("user_id"), c.Query("rental_id"), *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get ledger transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": transactions, "pagination": pagination})
+}
+
+// ReverseLedgerTransaction reverses the ledger transaction in the "id" path parameter. The body
+// carries the "reason" of the reversal.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *WalletService) ReverseLedgerTransaction(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" validate:"required,max=500"`
+	}
+
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	if err := utils.ValidateModel(&body); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "ledger transaction not found"})
+		return
+	}
+
+	loggedUser, err := utils.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return
+	}
+
+	reversal, err := s.repo.ReverseLedgerTransaction(c.Param("id"), body.Reason, loggedUser.ID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "ledger transaction not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.Contains(err.Error(), "reversed"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to reverse ledger transaction"})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, reversal)
+}
+
+// getWallet writes the wallet snapshot of a user, with the pagination of its entries.
+func (s *WalletService) getWallet(c *gin.Context, userID string) {
+	limit, page := utils.GetPaginationParams(c)
+
+	pagination := new(pkg.Pagination)
+	pagination.Limit = limit
+	pagination.Page = page
+
+	snapshot, pagination, err := s.repo.GetWalletSnapshot(userID, *pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get wallet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": snapshot, "pagination": pagination})
+}
+
+// getUser retrieves the user in the "userId" path parameter. It writes the error response and
+// returns false when the user cannot be retrieved.
+func (s *WalletService) getUser(c *gin.Context) (*models.User, bool) {
+	if _, err := uuid.Parse(c.Param("userId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return nil, false
+	}
+
+	user, err := s.repo.GetUserByID(c.Param("userId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get user"})
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,432 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/models"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/repositories"
+	"github.com/vinniciusgomes/ebike-rental-service/internal/api/utils"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/geo"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+const (
+	zoneOutOfAreaPolicyReject = "reject"
+	zoneOutOfAreaPolicyFee    = "fee"
+)
+
+type ZoneService struct {
+	repo repositories.ZoneRepository
+}
+
+// NewZoneService creates a new instance of the ZoneService struct.
+//
+// It takes a repositories.ZoneRepository as a parameter and returns a pointer
+// to a ZoneService.
+func NewZoneService(repo repositories.ZoneRepository) *ZoneService {
+	return &ZoneService{repo: repo}
+}
+
+// returnCheck is the outcome of checking a return position against the parking zones.
+type returnCheck struct {
+	Allowed bool        `json:"allowed"`
+	Fee     money.Money `json:"fee"`
+	Reason  string      `json:"reason,omitempty"`
+}
+
+// CreateZone creates a new zone. The "geometry" field of the body must be a GeoJSON Polygon or
+// MultiPolygon, or a Feature wrapping one.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) CreateZone(c *gin.Context) {
+	zone := new(models.Zone)
+
+	if err := c.BindJSON(zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	zone.ID = uuid.Must(uuid.NewRandom())
+
+	if err := prepareZone(zone); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.CreateZone(zone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to create a new zone"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, zone)
+}
+
+// ImportZones creates every zone of a GeoJSON FeatureCollection sent as the request body.
+//
+// The "name", "type", "fee" and "speed_limit_kmh" properties of each feature fill the zone.
+// The import is atomic: if any feature is invalid nothing is created and every problem is
+// reported with the index of its feature.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) ImportZones(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	features, err := geo.ParseFeatureCollection(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if len(features) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "feature collection has no features"})
+		return
+	}
+
+	zones := make([]models.Zone, 0, len(features))
+	problems := []gin.H{}
+
+	for i, feature := range features {
+		zone, err := zoneFromFeature(feature)
+		if err != nil {
+			problems = append(problems, gin.H{"feature": i, "error": err.Error()})
+			continue
+		}
+
+		if err := utils.ValidateModel(&zone); err != nil {
+			problems = append(problems, gin.H{"feature": i, "error": err.Error()})
+			continue
+		}
+
+		zones = append(zones, zone)
+	}
+
+	if len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "invalid features", "errors": problems})
+		return
+	}
+
+	if err := s.repo.CreateZones(zones); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to import zones"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"created": len(zones), "data": zones})
+}
+
+// GetAllZones returns the zones as a GeoJSON FeatureCollection, ready to be drawn on a map.
+// The "type" query parameter filters the zones by type.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) GetAllZones(c *gin.Context) {
+	zones, err := s.repo.GetAllZones(c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get zones"})
+		return
+	}
+
+	features := make([]gin.H, 0, len(*zones))
+	for _, zone := range *zones {
+		features = append(features, gin.H{
+			"type":     "Feature",
+			"id":       zone.ID,
+			"geometry": zone.Geometry,
+			"properties": gin.H{
+				"name":            zone.Name,
+				"type":            zone.Type,
+				"fee":             zone.Fee,
+				"speed_limit_kmh": zone.SpeedLimitKmh,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "features": features})
+}
+
+// GetZoneByID retrieves a zone by its ID and returns it in the response.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) GetZoneByID(c *gin.Context) {
+	zone, err := s.repo.GetZoneByID(c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "zone not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get zone"})
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
+
+// UpdateZone replaces a zone with the one in the request body.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) UpdateZone(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "zone not found"})
+		return
+	}
+
+	var zone models.Zone
+	if err := c.BindJSON(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body"})
+		return
+	}
+
+	zone.ID = id
+
+	if err := prepareZone(&zone); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := s.repo.UpdateZone(&zone); err != nil {
+		if strings.Contains(err.Error(), "zone not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to update zone"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "zone updated successfully"})
+}
+
+// DeleteZone deletes a zone by its ID.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) DeleteZone(c *gin.Context) {
+	if err := s.repo.DeleteZone(c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "zone not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to delete zone"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// CheckPosition lists the zones containing the position given by the "latitude" and
+// "longitude" query parameters and tells whether a bike may be returned there, and for what
+// fee. Apps use it to warn riders before they end a rental.
+//
+// Parameters:
+// - c: The gin.Context object representing the HTTP request and response.
+//
+// Return:
+// - None.
+func (s *ZoneService) CheckPosition(c *gin.Context) {
+	latitude, latErr := strconv.ParseFloat(c.Query("latitude"), 64)
+	longitude, lngErr := strconv.ParseFloat(c.Query("longitude"), 64)
+
+	if latErr != nil || lngErr != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "latitude and longitude must be valid coordinates"})
+		return
+	}
+
+	zones, err := s.repo.GetAllZones("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "an error occurred when trying to get zones"})
+		return
+	}
+
+	point := geo.Point{Latitude: latitude, Longitude: longitude}
+
+	containing := []gin.H{}
+	for _, zone := range *zones {
+		area, err := zoneArea(zone)
+		if err != nil || !area.Contains(point) {
+			continue
+		}
+
+		containing = append(containing, gin.H{"id": zone.ID, "name": zone.Name, "type": zone.Type, "speed_limit_kmh": zone.SpeedLimitKmh})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"zones": containing, "return": checkReturnPosition(*zones, point)})
+}
+
+// checkReturnPosition decides whether a bike may be returned at a position.
+//
+// When operating zones exist, a position outside all of them is refused or charged
+// "ZONE_OUT_OF_AREA_FEE", depending on "ZONE_OUT_OF_AREA_POLICY". A position inside a no-parking
+// zone is refused, unless the zone has a fee, in which case the highest such fee is charged.
+// Zones of other types are ignored.
+//
+// Parameters:
+// - zones: the zones to check against.
+// - point: the return position.
+//
+// Returns:
+// - returnCheck: whether the return is allowed and the fee it incurs.
+func checkReturnPosition(zones []models.Zone, point geo.Point) returnCheck {
+	hasOperatingZones, insideOperatingZone := false, false
+	noParkingFee := money.Money{}
+
+	for _, zone := range zones {
+		if zone.Type != models.ZONE_TYPE_OPERATING && zone.Type != models.ZONE_TYPE_NO_PARKING {
+			continue
+		}
+
+		area, err := zoneArea(zone)
+		if err != nil {
+			slog.Error("invalid zone geometry", "zone_id", zone.ID, "error", err)
+			continue
+		}
+
+		if zone.Type == models.ZONE_TYPE_OPERATING {
+			hasOperatingZones = true
+			insideOperatingZone = insideOperatingZone || area.Contains(point)
+			continue
+		}
+
+		if !area.Contains(point) {
+			continue
+		}
+
+		if zone.Fee.IsZero() {
+			return returnCheck{Reason: fmt.Sprintf("bikes cannot be parked in %s", zone.Name)}
+		}
+
+		if noParkingFee.Cmp(zone.Fee) < 0 {
+			noParkingFee = zone.Fee
+		}
+	}
+
+	check := returnCheck{Allowed: true, Fee: noParkingFee}
+	if !noParkingFee.IsZero() {
+		check.Reason = "returned in a no-parking zone"
+	}
+
+	if hasOperatingZones && !insideOperatingZone {
+		if zoneOutOfAreaPolicy() != zoneOutOfAreaPolicyFee {
+			return returnCheck{Reason: "bikes must be returned inside the operating area"}
+		}
+
+		check.Fee = check.Fee.Add(zoneOutOfAreaFee())
+		check.Reason = "returned outside the operating area"
+	}
+
+	return check
+}
+
+// prepareZone normalizes the geometry of a zone and validates it.
+func prepareZone(zone *models.Zone) error {
+	if len(zone.Geometry) == 0 {
+		return fmt.Errorf("geometry is required")
+	}
+
+	_, geometry, err := geo.ParseGeometry(zone.Geometry)
+	if err != nil {
+		return err
+	}
+
+	zone.Geometry = geometry
+
+	return utils.ValidateModel(zone)
+}
+
+// zoneFromFeature builds a zone from a GeoJSON feature and its properties. The "fee" property
+// is in major units of the "currency" property, or of the default currency.
+func zoneFromFeature(feature geo.Feature) (models.Zone, error) {
+	zone := models.Zone{
+		ID:       uuid.Must(uuid.NewRandom()),
+		Geometry: feature.RawGeometry,
+	}
+
+	zone.Name, _ = feature.Properties["name"].(string)
+
+	if zoneType, ok := feature.Properties["type"].(string); ok {
+		zone.Type = models.ZoneTypeEnum(zoneType)
+	}
+
+	if fee, ok := feature.Properties["fee"].(float64); ok {
+		currency, _ := feature.Properties["currency"].(string)
+		if currency == "" {
+			currency = utils.DefaultCurrency()
+		}
+
+		amount, err := money.Parse(strconv.FormatFloat(fee, 'f', -1, 64), strings.ToUpper(currency))
+		if err != nil {
+			return zone, fmt.Errorf("fee: %w", err)
+		}
+
+		zone.Fee = amount
+	}
+
+	if speedLimit, ok := feature.Properties["speed_limit_kmh"].(float64); ok {
+		limit := int(speedLimit)
+		zone.SpeedLimitKmh = &limit
+	}
+
+	return zone, nil
+}
+
+// zoneArea decodes the geometry stored with a zone.
+func zoneArea(zone models.Zone) (geo.MultiPolygon, error) {
+	area, _, err := geo.ParseGeometry(zone.Geometry)
+	return area, err
+}
+
+// zoneOutOfAreaPolicy returns what to do with returns outside the operating area, read from
+// the "ZONE_OUT_OF_AREA_POLICY" environment variable: "reject" (the default) or "fee".
+func zoneOutOfAreaPolicy() string {
+	if strings.EqualFold(os.Getenv("ZONE_OUT_OF_AREA_POLICY"), zoneOutOfAreaPolicyFee) {
+		return zoneOutOfAreaPolicyFee
+	}
+
+	return zoneOutOfAreaPolicyReject
+}
+
+// zoneOutOfAreaFee returns the fee charged for returns outside the operating area when the
+// policy is "fee", read from the "ZONE_OUT_OF_AREA_FEE" environment variable in major units of
+// the default currency.
+func zoneOutOfAreaFee() money.Money {
+	if fee, err := money.Parse(os.Getenv("ZONE_OUT_OF_AREA_FEE"), utils.DefaultCurrency()); err == nil && !fee.IsNegative() {
+		return fee
+	}
+
+	fee, _ := money.Parse("20", utils.DefaultCurrency())
+	return fee
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// bikeCodeAlphabet leaves out characters that are easily confused when read from a sticker
+// (0/O, 1/I/L).
+const bikeCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// BikeCodeLength is the number of characters of a bike code.
+const BikeCodeLength = 6
+
+// GenerateBikeCode generates a short, human-readable code that identifies a bike.
+//
+// Returns the generated code and any error encountered while reading random data.
+func GenerateBikeCode() (string, error) {
+	code := make([]byte, BikeCodeLength)
+	max := big.NewInt(int64(len(bikeCodeAlphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		code[i] = bikeCodeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
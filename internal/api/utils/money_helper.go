@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// DefaultCurrency returns the currency of amounts that do not carry one, such as environment
+// settings and rows created before amounts had a currency. It is read from the
+// "DEFAULT_CURRENCY" environment variable and defaults to BRL.
+func DefaultCurrency() string {
+	if currency := strings.ToUpper(os.Getenv("DEFAULT_CURRENCY")); money.IsSupported(currency) {
+		return currency
+	}
+
+	return "BRL"
+}
+
+// MoneyRounding returns how computed amounts are rounded to minor units, read from the
+// "MONEY_ROUNDING" environment variable (half_even, half_up, up or down). It defaults to
+// banker's rounding.
+func MoneyRounding() money.RoundingMode {
+	if mode, err := money.ParseRoundingMode(os.Getenv("MONEY_ROUNDING")); err == nil {
+		return mode
+	}
+
+	return money.RoundHalfEven
+}
+
+// GetLocale returns the locale amounts are displayed in for a request: the first language of
+// the "Accept-Language" header, or the default locale.
+func GetLocale(c *gin.Context) string {
+	header := c.GetHeader("Accept-Language")
+	if tag, _, _ := strings.Cut(strings.Split(header, ",")[0], ";"); strings.TrimSpace(tag) != "" && tag != "*" {
+		return strings.TrimSpace(tag)
+	}
+
+	return DefaultLocale()
+}
+
+// DefaultLocale returns the locale amounts are displayed in outside of a request, such as in
+// emails, read from the "DEFAULT_LOCALE" environment variable (pt-BR by default).
+func DefaultLocale() string {
+	if locale := os.Getenv("DEFAULT_LOCALE"); locale != "" {
+		return locale
+	}
+
+	return "pt-BR"
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
 )
 
 // ValidateModel validates the given struct object using the validator package.
@@ -17,6 +18,9 @@ func ValidateModel(obj interface{}) error {
 	// Create a new instance of the validator.
 	validate := validator.New()
 
+	// Register the validation of money.Money fields.
+	validate.RegisterValidation("money", validateMoney)
+
 	// Validate the provided struct.
 	err := validate.Struct(obj)
 
@@ -39,6 +43,16 @@ func ValidateModel(obj interface{}) error {
 	case "required":
 		// Case when the field is required but not provided.
 		return errors.New(field + " is required")
+	case "required_without":
+		// Case when the field is required unless another field is provided.
+		return errors.New(field + " is required when " + strings.ToLower(validationError.Param()) + " is not provided")
+	case "required_with":
+		// Case when the field is required together with another field.
+		return errors.New(field + " is required when " + strings.ToLower(validationError.Param()) + " is provided")
+	case "required_if":
+		// Case when the field is required for a specific value of another field.
+		params := strings.Fields(validationError.Param())
+		return errors.New(field + " is required when " + strings.ToLower(params[0]) + " is " + strings.Join(params[1:], " "))
 	case "url":
 		// Case when the field should be a valid URL but is not.
 		return errors.New(field + " is an invalid URL")
@@ -48,6 +62,9 @@ func ValidateModel(obj interface{}) error {
 	case "min":
 		// Case when the field value should be greater than or equal to a specific value.
 		return errors.New(field + " must be greater than or equal to " + validationError.Param())
+	case "gt":
+		// Case when the field value should be greater than a specific value.
+		return errors.New(field + " must be greater than " + validationError.Param())
 	case "email":
 		// Case when the field should be a valid email address but is not.
 		return errors.New(field + " is an invalid email")
@@ -75,8 +92,35 @@ func ValidateModel(obj interface{}) error {
 	case "datetime":
 		// Case when the field should be a valid datetime in a specific format.
 		return errors.New(field + " must be a valid datetime in the format " + validationError.Param())
+	case "money":
+		// Case when the field should be a non-negative amount in a supported currency.
+		if validationError.Param() == "positive" {
+			return errors.New(field + " must be a positive amount in a supported currency")
+		}
+		return errors.New(field + " must be a non-negative amount in a supported currency")
+	case "latitude":
+		// Case when the field should be a valid latitude.
+		return errors.New(field + " must be a valid latitude")
+	case "longitude":
+		// Case when the field should be a valid longitude.
+		return errors.New(field + " must be a valid longitude")
 	default:
 		// Generic case for any other validation errors.
 		return errors.New("Validation error for field: " + field)
 	}
 }
+
+// validateMoney validates a money.Money field. The amount must not be negative, or must be
+// positive with the "positive" parameter, and non-zero amounts need a supported currency.
+func validateMoney(fl validator.FieldLevel) bool {
+	amount, ok := fl.Field().Interface().(money.Money)
+	if !ok {
+		return false
+	}
+
+	if amount.IsNegative() || (fl.Param() == "positive" && amount.IsZero()) {
+		return false
+	}
+
+	return amount.Currency == "" && amount.IsZero() || money.IsSupported(amount.Currency)
+}
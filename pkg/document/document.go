@@ -0,0 +1,63 @@
+package document
+
+import (
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// Document is a billing document, such as a receipt or an invoice, as it was issued. Every text
+// is final: dates and quantities are written by the issuer, and amounts are formatted in Locale
+// when the document is rendered. A Document is stored with what it describes, so it renders the
+// same way however the data changes afterwards.
+type Document struct {
+	Title    string `json:"title"`
+	Number   string `json:"number"`
+	IssuedAt string `json:"issued_at"`
+	Locale   string `json:"locale"`
+	Issuer   Party  `json:"issuer"`
+	Customer Party  `json:"customer"`
+	// Details describe what is billed, such as the bike and the times of a ride.
+	Details []Field `json:"details,omitempty"`
+	Lines   []Line  `json:"lines"`
+	// Subtotal is the sum of the lines. Taxes are added on top of it, except the included
+	// ones, which are part of it.
+	Subtotal money.Money `json:"subtotal"`
+	Taxes    []Line      `json:"taxes,omitempty"`
+	Total    money.Money `json:"total"`
+	// Payments tell how the total was paid.
+	Payments []Field  `json:"payments,omitempty"`
+	Notes    []string `json:"notes,omitempty"`
+	// DownloadURL links the HTML rendering to where the document can be downloaded. It is set
+	// when the document is sent and not stored with it.
+	DownloadURL string `json:"-"`
+}
+
+// Party is the issuer or the customer of a document.
+type Party struct {
+	Name    string   `json:"name"`
+	Email   string   `json:"email,omitempty"`
+	TaxID   string   `json:"tax_id,omitempty"`
+	Address []string `json:"address,omitempty"`
+}
+
+// Field is a labelled value.
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Line is an amount billed, or a tax, with its description. Included only applies to taxes.
+type Line struct {
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+	Included    bool        `json:"included,omitempty"`
+}
+
+// TaxTotal returns the sum of the taxes of the document.
+func (d Document) TaxTotal() money.Money {
+	total := money.New(0, d.Total.Currency)
+	for _, tax := range d.Taxes {
+		total = total.Add(tax.Amount)
+	}
+
+	return total
+}
@@ -0,0 +1,84 @@
+package document
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+var htmlTemplate = template.Must(template.New("document").Funcs(template.FuncMap{
+	"amount": func(m money.Money, locale string) string { return m.Format(locale) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} {{.Number}}</title>
+</head>
+<body style="margin:0;padding:24px;background:#f4f4f5;font-family:Helvetica,Arial,sans-serif;color:#18181b;">
+<div style="max-width:640px;margin:0 auto;padding:32px;background:#ffffff;border-radius:8px;">
+<table style="width:100%;border-collapse:collapse;">
+<tr>
+<td style="vertical-align:top;">
+<div style="font-size:22px;font-weight:bold;">{{.Title}}</div>
+<div style="color:#71717a;">{{.Number}}</div>
+<div style="color:#71717a;">{{.IssuedAt}}</div>
+</td>
+<td style="vertical-align:top;text-align:right;">
+<div style="font-weight:bold;">{{.Issuer.Name}}</div>
+{{if .Issuer.TaxID}}<div>{{.Issuer.TaxID}}</div>{{end}}
+{{range .Issuer.Address}}<div>{{.}}</div>{{end}}
+</td>
+</tr>
+</table>
+<div style="margin-top:24px;">
+<div style="color:#71717a;font-size:12px;text-transform:uppercase;">Billed to</div>
+<div style="font-weight:bold;">{{.Customer.Name}}</div>
+{{if .Customer.TaxID}}<div>{{.Customer.TaxID}}</div>{{end}}
+{{range .Customer.Address}}<div>{{.}}</div>{{end}}
+{{if .Customer.Email}}<div>{{.Customer.Email}}</div>{{end}}
+</div>
+{{if .Details}}
+<table style="width:100%;margin-top:24px;border-collapse:collapse;">
+{{range .Details}}<tr><td style="padding:4px 0;color:#71717a;">{{.Label}}</td><td style="padding:4px 0;text-align:right;">{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}
+<table style="width:100%;margin-top:24px;border-collapse:collapse;">
+{{range .Lines}}<tr><td style="padding:6px 0;border-bottom:1px solid #e4e4e7;">{{.Description}}</td><td style="padding:6px 0;border-bottom:1px solid #e4e4e7;text-align:right;white-space:nowrap;">{{amount .Amount $.Locale}}</td></tr>
+{{end}}<tr><td style="padding:6px 0;">Subtotal</td><td style="padding:6px 0;text-align:right;white-space:nowrap;">{{amount .Subtotal .Locale}}</td></tr>
+{{range .Taxes}}<tr><td style="padding:6px 0;color:#71717a;">{{.Description}}{{if .Included}} (included){{end}}</td><td style="padding:6px 0;text-align:right;white-space:nowrap;color:#71717a;">{{amount .Amount $.Locale}}</td></tr>
+{{else}}<tr><td style="padding:6px 0;color:#71717a;">Taxes</td><td style="padding:6px 0;text-align:right;white-space:nowrap;color:#71717a;">{{amount .TaxTotal .Locale}}</td></tr>
+{{end}}<tr><td style="padding:6px 0;font-weight:bold;border-top:2px solid #18181b;">Total</td><td style="padding:6px 0;font-weight:bold;border-top:2px solid #18181b;text-align:right;white-space:nowrap;">{{amount .Total .Locale}}</td></tr>
+</table>
+{{if .Payments}}
+<table style="width:100%;margin-top:24px;border-collapse:collapse;">
+{{range .Payments}}<tr><td style="padding:4px 0;color:#71717a;">{{.Label}}</td><td style="padding:4px 0;text-align:right;">{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}
+{{range .Notes}}<p style="margin:16px 0 0;color:#71717a;font-size:12px;">{{.}}</p>
+{{end}}
+{{if .DownloadURL}}<p style="margin:16px 0 0;font-size:12px;"><a href="{{.DownloadURL}}">Download this document</a></p>
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+// HTML renders a document as a standalone HTML page. Styles are inline, so the page can also be
+// sent as the body of an email.
+//
+// Parameters:
+// - doc: the document to render.
+//
+// Returns:
+// - []byte: the HTML page.
+// - error: an error if the document could not be rendered.
+func HTML(doc Document) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := htmlTemplate.Execute(&buf, doc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
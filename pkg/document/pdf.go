@@ -0,0 +1,328 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDF pages are A4, measured in points from the bottom left corner.
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 50.0
+)
+
+type pdfFont int
+
+const (
+	pdfRegular pdfFont = iota
+	pdfBold
+)
+
+// Names of the standard fonts, which every PDF reader has, so no font is embedded.
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// Widths of the printable ASCII characters of the standard fonts, in thousandths of the font
+// size, from the Adobe font metrics. Other characters are measured as a digit.
+var pdfFontWidths = [][95]int{
+	{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// Characters of the Windows-1252 code page, the encoding of the text, outside of Latin-1.
+var winAnsiCharacters = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f, ' ': 0xa0,
+}
+
+// pdfWriter lays out text on the pages of a PDF, from the top of the first page down. A new
+// page starts when the current one is full.
+type pdfWriter struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// PDF renders a document as a PDF file with A4 pages. It only uses the standard Helvetica fonts,
+// so text outside of the Windows-1252 character set is replaced by question marks.
+//
+// Parameters:
+// - doc: the document to render.
+//
+// Returns:
+// - []byte: the PDF file.
+// - error: an error if the document could not be rendered.
+func PDF(doc Document) ([]byte, error) {
+	w := &pdfWriter{}
+	w.newPage()
+
+	right := pdfPageWidth - pdfMargin
+	top := w.y
+
+	w.text(pdfMargin, pdfBold, 20, doc.Title)
+	w.text(pdfMargin, pdfRegular, 10, doc.Number)
+	w.text(pdfMargin, pdfRegular, 10, doc.IssuedAt)
+	bottom := w.y
+
+	w.y = top
+	w.textRight(right, pdfBold, 10, doc.Issuer.Name)
+	if doc.Issuer.TaxID != "" {
+		w.textRight(right, pdfRegular, 10, doc.Issuer.TaxID)
+	}
+	for _, line := range doc.Issuer.Address {
+		w.textRight(right, pdfRegular, 10, line)
+	}
+	w.y = min(w.y, bottom) - 20
+
+	w.text(pdfMargin, pdfRegular, 8, "BILLED TO")
+	w.text(pdfMargin, pdfBold, 10, doc.Customer.Name)
+	if doc.Customer.TaxID != "" {
+		w.text(pdfMargin, pdfRegular, 10, doc.Customer.TaxID)
+	}
+	for _, line := range doc.Customer.Address {
+		w.text(pdfMargin, pdfRegular, 10, line)
+	}
+	if doc.Customer.Email != "" {
+		w.text(pdfMargin, pdfRegular, 10, doc.Customer.Email)
+	}
+
+	if len(doc.Details) > 0 {
+		w.y -= 12
+		w.fields(doc.Details)
+	}
+
+	w.y -= 12
+	for _, line := range doc.Lines {
+		w.amountRow(pdfRegular, line.Description, line.Amount.Format(doc.Locale))
+		w.rule(0.5)
+	}
+
+	w.amountRow(pdfRegular, "Subtotal", doc.Subtotal.Format(doc.Locale))
+	if len(doc.Taxes) == 0 {
+		w.amountRow(pdfRegular, "Taxes", doc.TaxTotal().Format(doc.Locale))
+	}
+	for _, tax := range doc.Taxes {
+		description := tax.Description
+		if tax.Included {
+			description += " (included)"
+		}
+		w.amountRow(pdfRegular, description, tax.Amount.Format(doc.Locale))
+	}
+	w.rule(1.5)
+	w.amountRow(pdfBold, "Total", doc.Total.Format(doc.Locale))
+
+	if len(doc.Payments) > 0 {
+		w.y -= 12
+		w.fields(doc.Payments)
+	}
+
+	for _, note := range doc.Notes {
+		w.y -= 8
+		for _, line := range wrapText(note, pdfRegular, 8, right-pdfMargin) {
+			w.text(pdfMargin, pdfRegular, 8, line)
+		}
+	}
+
+	return w.bytes(), nil
+}
+
+// fields writes labelled values, the label on the left and the value on the right.
+func (w *pdfWriter) fields(fields []Field) {
+	for _, field := range fields {
+		w.ensure(14)
+		w.y -= 14
+		w.show(pdfMargin, w.y, pdfRegular, 10, field.Label)
+		w.show(pdfPageWidth-pdfMargin-textWidth(field.Value, pdfRegular, 10), w.y, pdfRegular, 10, field.Value)
+	}
+}
+
+// amountRow writes a description, wrapped when it is long, with an amount on the right of its
+// first line.
+func (w *pdfWriter) amountRow(font pdfFont, description string, amount string) {
+	const size = 10
+
+	amountWidth := textWidth(amount, font, size)
+	lines := wrapText(description, font, size, pdfPageWidth-2*pdfMargin-amountWidth-24)
+
+	w.ensure(float64(len(lines))*14 + 4)
+	w.y -= 4
+	for i, line := range lines {
+		w.y -= 14
+		w.show(pdfMargin, w.y, font, size, line)
+		if i == 0 {
+			w.show(pdfPageWidth-pdfMargin-amountWidth, w.y, font, size, amount)
+		}
+	}
+}
+
+// text writes a line of text at the left position x, below the previous one.
+func (w *pdfWriter) text(x float64, font pdfFont, size float64, text string) {
+	w.ensure(size * 1.4)
+	w.y -= size * 1.4
+	w.show(x, w.y, font, size, text)
+}
+
+// textRight writes a line of text ending at the right position x, below the previous one.
+func (w *pdfWriter) textRight(x float64, font pdfFont, size float64, text string) {
+	w.ensure(size * 1.4)
+	w.y -= size * 1.4
+	w.show(x-textWidth(text, font, size), w.y, font, size, text)
+}
+
+// rule draws a horizontal line across the page, below the previous line.
+func (w *pdfWriter) rule(width float64) {
+	w.y -= 4
+	fmt.Fprintf(w.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, pdfMargin, w.y, pdfPageWidth-pdfMargin, w.y)
+}
+
+func (w *pdfWriter) show(x, y float64, font pdfFont, size float64, text string) {
+	fmt.Fprintf(w.page(), "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font+1, size, x, y, escapePDFText(text))
+}
+
+// ensure starts a new page when the current one has less than height left.
+func (w *pdfWriter) ensure(height float64) {
+	if w.y-height < pdfMargin {
+		w.newPage()
+	}
+}
+
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, &bytes.Buffer{})
+	w.y = pdfPageHeight - pdfMargin
+}
+
+func (w *pdfWriter) page() *bytes.Buffer {
+	return w.pages[len(w.pages)-1]
+}
+
+// bytes assembles the PDF file: the catalog, the page tree, the fonts, then a page object and
+// its content stream for each page, followed by the cross-reference table.
+func (w *pdfWriter) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	const firstPage = 5
+
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	for _, name := range pdfFontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+
+	for i, content := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// encodeWinAnsi converts text to the Windows-1252 encoding of the fonts.
+func encodeWinAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+
+	for _, r := range text {
+		switch b, ok := winAnsiCharacters[r]; {
+		case ok:
+			encoded = append(encoded, b)
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+
+	return encoded
+}
+
+// escapePDFText encodes text as the content of a PDF string literal.
+func escapePDFText(text string) string {
+	var escaped strings.Builder
+
+	for _, b := range encodeWinAnsi(text) {
+		switch {
+		case b == '(' || b == ')' || b == '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteByte(b)
+		case b < 0x20:
+			escaped.WriteByte(' ')
+		default:
+			escaped.WriteByte(b)
+		}
+	}
+
+	return escaped.String()
+}
+
+// textWidth measures text in points.
+func textWidth(text string, font pdfFont, size float64) float64 {
+	width := 0
+
+	for _, b := range encodeWinAnsi(text) {
+		if b >= 32 && b <= 126 {
+			width += pdfFontWidths[font][b-32]
+		} else {
+			width += 556
+		}
+	}
+
+	return float64(width) * size / 1000
+}
+
+// wrapText breaks text into lines no wider than maxWidth, between words. A word wider than a line
+// is left whole.
+func wrapText(text string, font pdfFont, size float64, maxWidth float64) []string {
+	var lines []string
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if line != "" && textWidth(candidate, font, size) > maxWidth {
+			lines = append(lines, line)
+			candidate = word
+		}
+
+		line = candidate
+	}
+
+	return append(lines, line)
+}
@@ -0,0 +1,58 @@
+package geo
+
+import "math"
+
+// EarthRadiusMeters is the mean radius of the Earth used by distance calculations.
+const EarthRadiusMeters = 6371008.8
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Distance returns the great-circle distance between two points in meters, using the
+// haversine formula.
+//
+// Parameters:
+// - a: the first point.
+// - b: the second point.
+//
+// Returns:
+// - float64: the distance in meters.
+func Distance(a Point, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	deltaLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	deltaLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLng/2)*math.Sin(deltaLng/2)
+
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBoxAround returns a bounding box containing every point within a radius of a center.
+// It is meant to narrow down candidates before measuring exact distances.
+//
+// Parameters:
+// - center: the center of the area.
+// - radiusMeters: the radius of the area in meters.
+//
+// Returns:
+// - BoundingBox: the box around the area.
+func BoundingBoxAround(center Point, radiusMeters float64) BoundingBox {
+	deltaLat := radiusMeters / EarthRadiusMeters * 180 / math.Pi
+
+	deltaLng := 180.0
+	if cos := math.Cos(center.Latitude * math.Pi / 180); cos > 1e-9 {
+		deltaLng = math.Min(180, deltaLat/cos)
+	}
+
+	return BoundingBox{
+		MinLatitude:  math.Max(-90, center.Latitude-deltaLat),
+		MinLongitude: center.Longitude - deltaLng,
+		MaxLatitude:  math.Min(90, center.Latitude+deltaLat),
+		MaxLongitude: center.Longitude + deltaLng,
+	}
+}
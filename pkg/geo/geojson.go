@@ -0,0 +1,178 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Feature is a GeoJSON feature whose geometry is a polygon or a multipolygon.
+type Feature struct {
+	Geometry    MultiPolygon
+	RawGeometry json.RawMessage
+	Properties  map[string]interface{}
+}
+
+type geoJSONObject struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometry    json.RawMessage   `json:"geometry"`
+	Properties  json.RawMessage   `json:"properties"`
+	Features    []json.RawMessage `json:"features"`
+}
+
+// ParseGeometry decodes a GeoJSON Polygon or MultiPolygon geometry. A Feature wrapping such a
+// geometry is accepted as well.
+//
+// Parameters:
+// - data: the GeoJSON document.
+//
+// Returns:
+// - MultiPolygon: the decoded area.
+// - json.RawMessage: the geometry object alone, suitable for storage.
+// - error: an error if the document is not a valid polygon or multipolygon.
+func ParseGeometry(data []byte) (MultiPolygon, json.RawMessage, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, nil, errors.New("invalid geojson")
+	}
+
+	if obj.Type == "Feature" {
+		return ParseGeometry(obj.Geometry)
+	}
+
+	var area MultiPolygon
+
+	switch obj.Type {
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &coordinates); err != nil {
+			return nil, nil, errors.New("invalid polygon coordinates")
+		}
+
+		poly, err := parsePolygon(coordinates)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		area = MultiPolygon{poly}
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &coordinates); err != nil {
+			return nil, nil, errors.New("invalid multipolygon coordinates")
+		}
+
+		if len(coordinates) == 0 {
+			return nil, nil, errors.New("multipolygon must have at least one polygon")
+		}
+
+		for _, polyCoordinates := range coordinates {
+			poly, err := parsePolygon(polyCoordinates)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			area = append(area, poly)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported geometry type %q, expected Polygon or MultiPolygon", obj.Type)
+	}
+
+	return area, EncodeGeometry(area), nil
+}
+
+// ParseFeatureCollection decodes a GeoJSON FeatureCollection whose features are polygons or
+// multipolygons.
+//
+// Parameters:
+// - data: the GeoJSON document.
+//
+// Returns:
+// - []Feature: the decoded features, in document order.
+// - error: an error naming the first invalid feature.
+func ParseFeatureCollection(data []byte) ([]Feature, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil || obj.Type != "FeatureCollection" {
+		return nil, errors.New("invalid geojson: expected a FeatureCollection")
+	}
+
+	features := make([]Feature, 0, len(obj.Features))
+	for i, raw := range obj.Features {
+		var featureObj geoJSONObject
+		if err := json.Unmarshal(raw, &featureObj); err != nil || featureObj.Type != "Feature" {
+			return nil, fmt.Errorf("feature %d: invalid feature", i)
+		}
+
+		area, geometry, err := ParseGeometry(featureObj.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		properties := map[string]interface{}{}
+		if len(featureObj.Properties) > 0 && string(featureObj.Properties) != "null" {
+			if err := json.Unmarshal(featureObj.Properties, &properties); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid properties", i)
+			}
+		}
+
+		features = append(features, Feature{Geometry: area, RawGeometry: geometry, Properties: properties})
+	}
+
+	return features, nil
+}
+
+// EncodeGeometry encodes an area as a GeoJSON MultiPolygon geometry.
+func EncodeGeometry(area MultiPolygon) json.RawMessage {
+	coordinates := make([][][][]float64, len(area))
+	for i, poly := range area {
+		coordinates[i] = make([][][]float64, len(poly))
+		for j, ring := range poly {
+			coordinates[i][j] = make([][]float64, len(ring))
+			for k, p := range ring {
+				coordinates[i][j][k] = []float64{p.Longitude, p.Latitude}
+			}
+		}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"type": "MultiPolygon", "coordinates": coordinates})
+
+	return data
+}
+
+// parsePolygon converts GeoJSON polygon coordinates ([longitude, latitude] positions) into a
+// Polygon, closing rings that are left open.
+func parsePolygon(coordinates [][][]float64) (Polygon, error) {
+	if len(coordinates) == 0 {
+		return nil, errors.New("polygon must have an outer ring")
+	}
+
+	poly := make(Polygon, 0, len(coordinates))
+	for _, ringCoordinates := range coordinates {
+		ring := make(Ring, 0, len(ringCoordinates)+1)
+
+		for _, position := range ringCoordinates {
+			if len(position) < 2 {
+				return nil, errors.New("positions must have a longitude and a latitude")
+			}
+
+			p := Point{Longitude: position[0], Latitude: position[1]}
+			if p.Longitude < -180 || p.Longitude > 180 || p.Latitude < -90 || p.Latitude > 90 {
+				return nil, errors.New("positions must be valid longitude and latitude pairs")
+			}
+
+			ring = append(ring, p)
+		}
+
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+
+		if len(ring) < 4 {
+			return nil, errors.New("rings must have at least three distinct positions")
+		}
+
+		poly = append(poly, ring)
+	}
+
+	return poly, nil
+}
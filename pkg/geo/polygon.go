@@ -0,0 +1,91 @@
+package geo
+
+// Ring is a closed sequence of points. The last point repeats the first one.
+type Ring []Point
+
+// Polygon is an outer ring followed by zero or more holes.
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons treated as a single area.
+type MultiPolygon []Polygon
+
+// BoundingBox is the smallest latitude/longitude rectangle containing a shape.
+type BoundingBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// Contains reports whether p lies within the bounding box.
+func (b BoundingBox) Contains(p Point) bool {
+	return p.Latitude >= b.MinLatitude && p.Latitude <= b.MaxLatitude &&
+		p.Longitude >= b.MinLongitude && p.Longitude <= b.MaxLongitude
+}
+
+// Contains reports whether p lies inside the ring, using the even-odd ray casting rule.
+//
+// Coordinates are treated as planar, which is accurate enough for city-sized areas away from
+// the poles and the antimeridian.
+func (r Ring) Contains(p Point) bool {
+	inside := false
+
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) {
+			crossing := (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude) + a.Longitude
+			if p.Longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+// Contains reports whether p lies inside the outer ring and outside every hole.
+func (poly Polygon) Contains(p Point) bool {
+	if len(poly) == 0 || !poly[0].Contains(p) {
+		return false
+	}
+
+	for _, hole := range poly[1:] {
+		if hole.Contains(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Contains reports whether p lies inside any of the polygons.
+func (m MultiPolygon) Contains(p Point) bool {
+	for _, poly := range m {
+		if poly.Contains(p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BoundingBox returns the bounding box of the outer rings of the polygons.
+func (m MultiPolygon) BoundingBox() BoundingBox {
+	box := BoundingBox{MinLatitude: 90, MinLongitude: 180, MaxLatitude: -90, MaxLongitude: -180}
+
+	for _, poly := range m {
+		if len(poly) == 0 {
+			continue
+		}
+
+		for _, p := range poly[0] {
+			box.MinLatitude = min(box.MinLatitude, p.Latitude)
+			box.MinLongitude = min(box.MinLongitude, p.Longitude)
+			box.MaxLatitude = max(box.MaxLatitude, p.Latitude)
+			box.MaxLongitude = max(box.MaxLongitude, p.Longitude)
+		}
+	}
+
+	return box
+}
@@ -0,0 +1,112 @@
+package geo
+
+import "testing"
+
+// square returns the closed ring of a square from (minLat, minLng) to (maxLat, maxLng).
+func square(minLat, minLng, maxLat, maxLng float64) Ring {
+	return Ring{
+		{Latitude: minLat, Longitude: minLng},
+		{Latitude: minLat, Longitude: maxLng},
+		{Latitude: maxLat, Longitude: maxLng},
+		{Latitude: maxLat, Longitude: minLng},
+		{Latitude: minLat, Longitude: minLng},
+	}
+}
+
+func TestRingContains(t *testing.T) {
+	// A concave "L": the square from (0,0) to (2,2) without its top right quarter.
+	concave := Ring{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 2},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 2, Longitude: 1},
+		{Latitude: 2, Longitude: 0},
+		{Latitude: 0, Longitude: 0},
+	}
+
+	tests := []struct {
+		name  string
+		ring  Ring
+		point Point
+		want  bool
+	}{
+		{"inside a square", square(0, 0, 1, 1), Point{Latitude: 0.5, Longitude: 0.5}, true},
+		{"left of a square", square(0, 0, 1, 1), Point{Latitude: 0.5, Longitude: -0.5}, false},
+		{"right of a square", square(0, 0, 1, 1), Point{Latitude: 0.5, Longitude: 1.5}, false},
+		{"above a square", square(0, 0, 1, 1), Point{Latitude: 1.5, Longitude: 0.5}, false},
+		{"below a square", square(0, 0, 1, 1), Point{Latitude: -0.5, Longitude: 0.5}, false},
+		{"level with a vertex", square(0, 0, 1, 1), Point{Latitude: 1, Longitude: -0.5}, false},
+		{"negative coordinates", square(-23.6, -46.7, -23.5, -46.6), Point{Latitude: -23.55, Longitude: -46.65}, true},
+		{"inside the arm of a concave ring", concave, Point{Latitude: 1.5, Longitude: 0.5}, true},
+		{"inside the notch of a concave ring", concave, Point{Latitude: 1.5, Longitude: 1.5}, false},
+		{"empty ring", Ring{}, Point{Latitude: 0, Longitude: 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ring.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	withHole := Polygon{square(0, 0, 4, 4), square(1, 1, 2, 2)}
+
+	tests := []struct {
+		name    string
+		polygon Polygon
+		point   Point
+		want    bool
+	}{
+		{"inside the outer ring", withHole, Point{Latitude: 3, Longitude: 3}, true},
+		{"inside a hole", withHole, Point{Latitude: 1.5, Longitude: 1.5}, false},
+		{"outside the outer ring", withHole, Point{Latitude: 5, Longitude: 5}, false},
+		{"empty polygon", Polygon{}, Point{Latitude: 0, Longitude: 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.polygon.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonContains(t *testing.T) {
+	areas := MultiPolygon{{square(0, 0, 1, 1)}, {square(2, 2, 3, 3)}}
+
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{"inside the first polygon", Point{Latitude: 0.5, Longitude: 0.5}, true},
+		{"inside the second polygon", Point{Latitude: 2.5, Longitude: 2.5}, true},
+		{"between the polygons", Point{Latitude: 1.5, Longitude: 1.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := areas.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonBoundingBox(t *testing.T) {
+	areas := MultiPolygon{{square(0, 0, 1, 1)}, {square(2, -3, 3, 3)}, {}}
+
+	want := BoundingBox{MinLatitude: 0, MinLongitude: -3, MaxLatitude: 3, MaxLongitude: 3}
+	if got := areas.BoundingBox(); got != want {
+		t.Errorf("BoundingBox() = %+v, want %+v", got, want)
+	}
+
+	if !want.Contains(Point{Latitude: 1.5, Longitude: -2}) {
+		t.Errorf("expected the bounding box to contain a point between the polygons")
+	}
+}
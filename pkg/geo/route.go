@@ -0,0 +1,112 @@
+package geo
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TrackPoint is a position recorded at a given time.
+type TrackPoint struct {
+	Point
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// RouteSummary holds the figures of a recorded route.
+type RouteSummary struct {
+	DistanceMeters  float64 `json:"distance_meters"`
+	AverageSpeedKmh float64 `json:"average_speed_kmh"`
+	MaxSpeedKmh     float64 `json:"max_speed_kmh"`
+}
+
+// Summarize computes the length of a route and its average and maximum speeds. The points
+// must be sorted by time.
+//
+// Segments shorter than a second are counted in the distance but not in the maximum speed, as
+// GPS jitter between close fixes would otherwise produce absurd speeds.
+//
+// Parameters:
+// - points: the route, sorted by time.
+//
+// Returns:
+// - RouteSummary: the figures of the route.
+func Summarize(points []TrackPoint) RouteSummary {
+	var summary RouteSummary
+
+	for i := 1; i < len(points); i++ {
+		distance := Distance(points[i-1].Point, points[i].Point)
+		summary.DistanceMeters += distance
+
+		elapsed := points[i].RecordedAt.Sub(points[i-1].RecordedAt).Seconds()
+		if elapsed >= 1 {
+			summary.MaxSpeedKmh = max(summary.MaxSpeedKmh, distance/elapsed*3.6)
+		}
+	}
+
+	if len(points) > 1 {
+		if elapsed := points[len(points)-1].RecordedAt.Sub(points[0].RecordedAt).Seconds(); elapsed >= 1 {
+			summary.AverageSpeedKmh = summary.DistanceMeters / elapsed * 3.6
+		}
+	}
+
+	return summary
+}
+
+// EncodeLineString encodes a route as a GeoJSON LineString geometry.
+func EncodeLineString(points []Point) json.RawMessage {
+	coordinates := make([][]float64, len(points))
+	for i, p := range points {
+		coordinates[i] = []float64{p.Longitude, p.Latitude}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"type": "LineString", "coordinates": coordinates})
+
+	return data
+}
+
+// EncodePolyline encodes a route with the encoded polyline algorithm format used by most map
+// SDKs, with a precision of five decimal places.
+//
+// Parameters:
+// - points: the route.
+//
+// Returns:
+// - string: the encoded polyline.
+func EncodePolyline(points []Point) string {
+	var sb strings.Builder
+
+	var prevLat, prevLng int64
+	for _, p := range points {
+		lat := roundE5(p.Latitude)
+		lng := roundE5(p.Longitude)
+
+		encodePolylineValue(&sb, lat-prevLat)
+		encodePolylineValue(&sb, lng-prevLng)
+
+		prevLat, prevLng = lat, lng
+	}
+
+	return sb.String()
+}
+
+func roundE5(value float64) int64 {
+	if value < 0 {
+		return int64(value*1e5 - 0.5)
+	}
+
+	return int64(value*1e5 + 0.5)
+}
+
+func encodePolylineValue(sb *strings.Builder, value int64) {
+	shifted := value << 1
+	if value < 0 {
+		shifted = ^shifted
+	}
+
+	for shifted >= 0x20 {
+		sb.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+
+	sb.WriteByte(byte(shifted + 63))
+}
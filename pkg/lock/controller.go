@@ -0,0 +1,48 @@
+// Package lock sends commands to the smart locks of the bikes and tracks their
+// acknowledgements.
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Action is the operation a lock is asked to perform.
+type Action string
+
+const (
+	ActionUnlock Action = "unlock"
+	ActionLock   Action = "lock"
+)
+
+var (
+	// ErrTimeout is returned when the lock does not acknowledge a command in time.
+	ErrTimeout = errors.New("lock command was not acknowledged in time")
+	// ErrRejected is returned when the lock acknowledges a command but reports a failure.
+	ErrRejected = errors.New("lock command was rejected")
+)
+
+// Command is an instruction sent to the lock of a bike.
+type Command struct {
+	ID       string    `json:"id"`
+	BikeID   string    `json:"bike_id"`
+	Action   Action    `json:"action"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// Ack is the acknowledgement sent back by a lock once a command has been carried out.
+type Ack struct {
+	CommandID  string    `json:"id"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	ReceivedAt time.Time `json:"-"`
+}
+
+// LockController delivers commands to the bike locks.
+type LockController interface {
+	// Send delivers cmd and blocks until the lock acknowledges it or ctx is done. It returns
+	// ErrTimeout when ctx expires first and an error wrapping ErrRejected when the lock reports
+	// that the command failed.
+	Send(ctx context.Context, cmd Command) (*Ack, error)
+}
@@ -0,0 +1,91 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeController is an in-memory LockController for tests and local development. By default
+// every command is acknowledged immediately; individual bikes can be made to reject commands
+// or to never answer.
+type FakeController struct {
+	mu           sync.Mutex
+	commands     []Command
+	failures     map[string]string
+	unresponsive map[string]bool
+	locked       map[string]bool
+}
+
+// NewFakeController creates a new FakeController.
+func NewFakeController() *FakeController {
+	return &FakeController{
+		failures:     map[string]string{},
+		unresponsive: map[string]bool{},
+		locked:       map[string]bool{},
+	}
+}
+
+// Send records cmd and acknowledges it according to the configured behaviour of the bike.
+func (f *FakeController) Send(ctx context.Context, cmd Command) (*Ack, error) {
+	f.mu.Lock()
+	f.commands = append(f.commands, cmd)
+	reason, fails := f.failures[cmd.BikeID]
+	silent := f.unresponsive[cmd.BikeID]
+	f.mu.Unlock()
+
+	if silent {
+		<-ctx.Done()
+		return nil, ErrTimeout
+	}
+
+	ack := &Ack{CommandID: cmd.ID, Success: !fails, Error: reason, ReceivedAt: time.Now()}
+	if fails {
+		return ack, fmt.Errorf("%w: %s", ErrRejected, reason)
+	}
+
+	f.mu.Lock()
+	f.locked[cmd.BikeID] = cmd.Action == ActionLock
+	f.mu.Unlock()
+
+	return ack, nil
+}
+
+// FailCommands makes the lock of a bike reject every command with reason. An empty reason
+// restores the default behaviour.
+func (f *FakeController) FailCommands(bikeID string, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if reason == "" {
+		delete(f.failures, bikeID)
+		return
+	}
+
+	f.failures[bikeID] = reason
+}
+
+// SetUnresponsive makes the lock of a bike ignore commands, so they time out.
+func (f *FakeController) SetUnresponsive(bikeID string, unresponsive bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.unresponsive[bikeID] = unresponsive
+}
+
+// IsLocked reports whether the last acknowledged command for a bike was a lock command.
+func (f *FakeController) IsLocked(bikeID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.locked[bikeID]
+}
+
+// Commands returns a copy of every command received so far.
+func (f *FakeController) Commands() []Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Command(nil), f.commands...)
+}
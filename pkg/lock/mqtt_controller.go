@@ -0,0 +1,118 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/mqtt"
+)
+
+// MQTTController is a LockController that talks to the locks through an MQTT broker.
+//
+// Commands are published as JSON on "<prefix>/<bike id>/commands" with QoS 1. Locks answer
+// with an Ack encoded as JSON on "<prefix>/<bike id>/acks", echoing the command id.
+type MQTTController struct {
+	client  *mqtt.Client
+	prefix  string
+	mu      sync.Mutex
+	pending map[string]chan Ack
+}
+
+// NewMQTTController subscribes to the acknowledgement topics and returns a controller that
+// publishes commands through client.
+//
+// Parameters:
+// - ctx: bounds the subscription.
+// - client: a connected MQTT client.
+// - prefix: the topic prefix, "bikes" when empty.
+//
+// Returns:
+// - *MQTTController: a pointer to the new controller.
+// - error: an error if the subscription failed.
+func NewMQTTController(ctx context.Context, client *mqtt.Client, prefix string) (*MQTTController, error) {
+	if prefix == "" {
+		prefix = "bikes"
+	}
+
+	m := &MQTTController{
+		client:  client,
+		prefix:  strings.TrimSuffix(prefix, "/"),
+		pending: map[string]chan Ack{},
+	}
+
+	if err := client.Subscribe(ctx, m.prefix+"/+/acks", 1, m.handleAck); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Send publishes cmd and waits for the matching acknowledgement.
+func (m *MQTTController) Send(ctx context.Context, cmd Command) (*Ack, error) {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	acks := make(chan Ack, 1)
+	m.mu.Lock()
+	m.pending[cmd.ID] = acks
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, cmd.ID)
+		m.mu.Unlock()
+	}()
+
+	topic := fmt.Sprintf("%s/%s/commands", m.prefix, cmd.BikeID)
+	if err := m.client.Publish(ctx, topic, payload, 1); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrTimeout
+		}
+
+		return nil, err
+	}
+
+	select {
+	case ack := <-acks:
+		if !ack.Success {
+			return &ack, fmt.Errorf("%w: %s", ErrRejected, ack.Error)
+		}
+
+		return &ack, nil
+	case <-ctx.Done():
+		return nil, ErrTimeout
+	}
+}
+
+func (m *MQTTController) handleAck(topic string, payload []byte) {
+	var ack Ack
+	if err := json.Unmarshal(payload, &ack); err != nil {
+		slog.Warn("invalid lock acknowledgement", "topic", topic, "error", err)
+		return
+	}
+
+	ack.ReceivedAt = time.Now()
+
+	m.mu.Lock()
+	acks, ok := m.pending[ack.CommandID]
+	m.mu.Unlock()
+
+	if !ok {
+		// Late acknowledgement of a command that already timed out.
+		slog.Warn("unexpected lock acknowledgement", "topic", topic, "command_id", ack.CommandID)
+		return
+	}
+
+	select {
+	case acks <- ack:
+	default:
+	}
+}
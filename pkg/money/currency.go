@@ -0,0 +1,46 @@
+package money
+
+// currencyInfo describes an ISO 4217 currency.
+type currencyInfo struct {
+	// minorUnits is the number of decimal places of the currency.
+	minorUnits int
+	// symbol is the symbol used in the countries of the currency.
+	symbol string
+	// region is the country where the symbol is unambiguous, or an empty string when it is
+	// unambiguous everywhere.
+	region string
+}
+
+var currencies = map[string]currencyInfo{
+	"ARS": {2, "$", "AR"},
+	"AUD": {2, "$", "AU"},
+	"BRL": {2, "R$", ""},
+	"CAD": {2, "$", "CA"},
+	"CHF": {2, "CHF", ""},
+	"CLP": {0, "$", "CL"},
+	"COP": {2, "$", "CO"},
+	"EUR": {2, "€", ""},
+	"GBP": {2, "£", ""},
+	"JPY": {0, "¥", ""},
+	"KWD": {3, "KD", ""},
+	"MXN": {2, "$", "MX"},
+	"PEN": {2, "S/", ""},
+	"PYG": {0, "₲", ""},
+	"USD": {2, "$", "US"},
+	"UYU": {2, "$", "UY"},
+}
+
+// IsSupported reports whether a currency code is known.
+func IsSupported(currency string) bool {
+	_, ok := currencies[currency]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places of a currency, 2 for unknown currencies.
+func MinorUnits(currency string) int {
+	if info, ok := currencies[currency]; ok {
+		return info.minorUnits
+	}
+
+	return 2
+}
@@ -0,0 +1,110 @@
+package money
+
+import (
+	"strings"
+)
+
+// localeFormat describes how a locale writes amounts of money. Spaces are non-breaking so the
+// symbol never wraps away from the number.
+type localeFormat struct {
+	decimal     string
+	group       string
+	symbolAfter bool
+	space       bool
+}
+
+var localeFormats = map[string]localeFormat{
+	"de": {decimal: ",", group: ".", symbolAfter: true, space: true},
+	"en": {decimal: ".", group: ",", symbolAfter: false, space: false},
+	"es": {decimal: ",", group: ".", symbolAfter: true, space: true},
+	"fr": {decimal: ",", group: "\u202f", symbolAfter: true, space: true},
+	"it": {decimal: ",", group: ".", symbolAfter: true, space: true},
+	"nl": {decimal: ",", group: ".", symbolAfter: false, space: true},
+	"pt": {decimal: ",", group: ".", symbolAfter: false, space: true},
+}
+
+// Regional variants that write amounts differently from their language.
+var regionFormats = map[string]localeFormat{
+	"de-CH": {decimal: ".", group: "’", symbolAfter: false, space: true},
+	"es-AR": {decimal: ",", group: ".", symbolAfter: false, space: true},
+	"es-CL": {decimal: ",", group: ".", symbolAfter: false, space: false},
+	"es-CO": {decimal: ",", group: ".", symbolAfter: false, space: true},
+	"es-MX": {decimal: ".", group: ",", symbolAfter: false, space: false},
+	"es-US": {decimal: ".", group: ",", symbolAfter: false, space: false},
+	"pt-PT": {decimal: ",", group: "\u00a0", symbolAfter: true, space: true},
+}
+
+// Format writes the amount the way a locale does, such as "R$ 1.234,50" for "pt-BR" or
+// "$1,234.50" for "en-US". Unknown locales are written as in English.
+//
+// Parameters:
+// - locale: a BCP 47 language tag, such as "pt-BR".
+//
+// Returns:
+// - string: the formatted amount.
+func (m Money) Format(locale string) string {
+	language, region, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	language = strings.ToLower(language)
+	region = strings.ToUpper(region)
+
+	format, ok := regionFormats[language+"-"+region]
+	if !ok {
+		if format, ok = localeFormats[language]; !ok {
+			format = localeFormats["en"]
+		}
+	}
+
+	symbol := m.Currency
+	if info, ok := currencies[m.Currency]; ok {
+		symbol = info.symbol
+		// Dollars and pesos are told apart outside their own country by the country prefix.
+		if info.region != "" && info.region != region {
+			symbol = info.region + symbol
+		}
+	}
+
+	whole, fraction, _ := strings.Cut(m.abs().Decimal(), ".")
+	number := groupDigits(whole, format.group)
+	if fraction != "" {
+		number += format.decimal + fraction
+	}
+
+	separator := ""
+	if format.space {
+		separator = "\u00a0"
+	}
+
+	formatted := symbol + separator + number
+	if format.symbolAfter {
+		formatted = number + separator + symbol
+	}
+
+	if m.IsNegative() {
+		return "-" + formatted
+	}
+
+	return formatted
+}
+
+func (m Money) abs() Money {
+	if m.IsNegative() {
+		return m.Neg()
+	}
+
+	return m
+}
+
+// groupDigits separates the thousands of a whole number.
+func groupDigits(digits string, separator string) string {
+	var sb strings.Builder
+
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteString(separator)
+		}
+
+		sb.WriteRune(digit)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,135 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount in the minor units of a currency, such as cents. The zero value has no
+// currency and can be combined with an amount of any currency.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0"`
+	Currency string `json:"currency" gorm:"not null;size:3;default:''"`
+}
+
+// New creates an amount from minor units.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse reads a decimal amount in major units, such as "12.50", into an amount of a currency.
+// The value may not have more decimal places than the currency.
+//
+// Parameters:
+// - value: the decimal amount. A comma is accepted as the decimal separator.
+// - currency: the ISO 4217 code of the currency.
+//
+// Returns:
+// - Money: the amount.
+// - error: an error if the value is not a valid amount of the currency.
+func Parse(value string, currency string) (Money, error) {
+	if !IsSupported(currency) {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", ".")
+
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok || strings.ContainsAny(value, "/eE") {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	amount.Mul(amount, scale(currency))
+	if !amount.IsInt() || !amount.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places", value, MinorUnits(currency))
+	}
+
+	return Money{Amount: amount.Num().Int64(), Currency: currency}, nil
+}
+
+// FromMinor rounds an exact amount of minor units into an amount of a currency.
+func FromMinor(amount *big.Rat, currency string, mode RoundingMode) Money {
+	return Money{Amount: Round(amount, mode), Currency: currency}
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of two amounts. It panics when they are in different currencies.
+func (m Money) Add(other Money) Money {
+	currency := m.commonCurrency(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+// Sub returns the difference of two amounts. It panics when they are in different currencies.
+func (m Money) Sub(other Money) Money {
+	return m.Add(other.Neg())
+}
+
+// Neg returns the amount with the opposite sign.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1. It panics when they are in different
+// currencies.
+func (m Money) Cmp(other Money) int {
+	m.commonCurrency(other)
+
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Mul multiplies the amount by an exact factor and rounds the result.
+func (m Money) Mul(factor *big.Rat, mode RoundingMode) Money {
+	amount := new(big.Rat).Mul(m.Minor(), factor)
+	return FromMinor(amount, m.Currency, mode)
+}
+
+// Minor returns the amount in minor units as an exact number.
+func (m Money) Minor() *big.Rat {
+	return new(big.Rat).SetInt64(m.Amount)
+}
+
+// Decimal returns the amount in major units, such as "12.50".
+func (m Money) Decimal() string {
+	return new(big.Rat).Quo(m.Minor(), scale(m.Currency)).FloatString(MinorUnits(m.Currency))
+}
+
+// String returns the amount with its currency code, such as "12.50 BRL".
+func (m Money) String() string {
+	return strings.TrimSpace(m.Decimal() + " " + m.Currency)
+}
+
+// commonCurrency returns the currency two amounts can be combined in.
+func (m Money) commonCurrency(other Money) string {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency
+	default:
+		panic(fmt.Sprintf("money: mismatched currencies %s and %s", m.Currency, other.Currency))
+	}
+}
+
+// scale returns the number of minor units in a major unit of a currency.
+func scale(currency string) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(MinorUnits(currency))), nil))
+}
@@ -0,0 +1,107 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"12.50", "BRL", New(1250, "BRL"), false},
+		{"12,50", "BRL", New(1250, "BRL"), false},
+		{" 12.5 ", "USD", New(1250, "USD"), false},
+		{"12", "USD", New(1200, "USD"), false},
+		{"0", "EUR", New(0, "EUR"), false},
+		{"-3.25", "EUR", New(-325, "EUR"), false},
+		{"1500", "JPY", New(1500, "JPY"), false},
+		{"1.234", "KWD", New(1234, "KWD"), false},
+		{"12.345", "USD", Money{}, true},
+		{"1.5", "JPY", Money{}, true},
+		{"1/2", "USD", Money{}, true},
+		{"1e2", "USD", Money{}, true},
+		{"abc", "USD", Money{}, true},
+		{"", "USD", Money{}, true},
+		{"10", "XYZ", Money{}, true},
+		{"99999999999999999999", "USD", Money{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			got, err := Parse(tt.value, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q, %q) error = %v, wantErr %v", tt.value, tt.currency, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.value, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		factor *big.Rat
+		mode   RoundingMode
+		want   Money
+	}{
+		{"exact", New(1000, "USD"), big.NewRat(3, 2), RoundHalfEven, New(1500, "USD")},
+		{"tie to even", New(5, "USD"), big.NewRat(1, 2), RoundHalfEven, New(2, "USD")},
+		{"tie up", New(5, "USD"), big.NewRat(1, 2), RoundHalfUp, New(3, "USD")},
+		{"up", New(100, "USD"), big.NewRat(1, 3), RoundUp, New(34, "USD")},
+		{"down", New(100, "USD"), big.NewRat(2, 3), RoundDown, New(66, "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.Mul(tt.factor, tt.mode); got != tt.want {
+				t.Errorf("Mul() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddCurrencies(t *testing.T) {
+	if got := New(0, "").Add(New(150, "BRL")); got != New(150, "BRL") {
+		t.Errorf("zero without currency + 1.50 BRL = %+v, want 1.50 BRL", got)
+	}
+
+	if got := New(150, "BRL").Sub(New(50, "BRL")); got != New(100, "BRL") {
+		t.Errorf("1.50 BRL - 0.50 BRL = %+v, want 1.00 BRL", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected adding BRL and USD to panic")
+		}
+	}()
+
+	New(100, "BRL").Add(New(100, "USD"))
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{New(1250, "BRL"), "12.50"},
+		{New(-5, "USD"), "-0.05"},
+		{New(1500, "JPY"), "1500"},
+		{New(1234, "KWD"), "1.234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.amount.Decimal(); got != tt.want {
+				t.Errorf("Decimal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
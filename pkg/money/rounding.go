@@ -0,0 +1,62 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RoundingMode tells how an exact amount is rounded to a whole number of minor units.
+type RoundingMode string
+
+const (
+	// RoundHalfEven rounds to the nearest unit and ties to the even one (banker's rounding).
+	RoundHalfEven RoundingMode = "half_even"
+	// RoundHalfUp rounds to the nearest unit and ties away from zero.
+	RoundHalfUp RoundingMode = "half_up"
+	// RoundUp rounds away from zero.
+	RoundUp RoundingMode = "up"
+	// RoundDown rounds towards zero.
+	RoundDown RoundingMode = "down"
+)
+
+// ParseRoundingMode reads a rounding mode from its name.
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	switch mode := RoundingMode(name); mode {
+	case RoundHalfEven, RoundHalfUp, RoundUp, RoundDown:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode %q", name)
+	}
+}
+
+// Round rounds an exact number to an integer using the given mode. Unknown modes round half
+// to even.
+func Round(value *big.Rat, mode RoundingMode) int64 {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient.Int64()
+	}
+
+	// Compare twice the remainder with the denominator to find where the fraction stands
+	// against one half. Both remainder and value have the same sign.
+	sign := int64(value.Sign())
+	half := new(big.Int).Abs(new(big.Int).Mul(remainder, big.NewInt(2))).Cmp(value.Denom())
+
+	awayFromZero := false
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundDown:
+		awayFromZero = false
+	case RoundHalfUp:
+		awayFromZero = half >= 0
+	default:
+		awayFromZero = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+	}
+
+	if awayFromZero {
+		return quotient.Int64() + sign
+	}
+
+	return quotient.Int64()
+}
@@ -0,0 +1,75 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value string
+		mode  RoundingMode
+		want  int64
+	}{
+		{"5/2", RoundHalfEven, 2},
+		{"7/2", RoundHalfEven, 4},
+		{"-5/2", RoundHalfEven, -2},
+		{"-7/2", RoundHalfEven, -4},
+		{"5/2", RoundHalfUp, 3},
+		{"7/2", RoundHalfUp, 4},
+		{"-5/2", RoundHalfUp, -3},
+		{"21/10", RoundHalfUp, 2},
+		{"29/10", RoundHalfEven, 3},
+		{"21/10", RoundUp, 3},
+		{"-21/10", RoundUp, -3},
+		{"29/10", RoundDown, 2},
+		{"-29/10", RoundDown, -2},
+		{"4", RoundUp, 4},
+		{"-4", RoundDown, -4},
+		{"0", RoundHalfUp, 0},
+		{"1/3", RoundHalfUp, 0},
+		{"2/3", RoundHalfUp, 1},
+		{"5/2", RoundingMode("unknown"), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+" "+tt.value, func(t *testing.T) {
+			value, ok := new(big.Rat).SetString(tt.value)
+			if !ok {
+				t.Fatalf("invalid value %q", tt.value)
+			}
+
+			if got := Round(value, tt.mode); got != tt.want {
+				t.Errorf("Round(%s, %s) = %d, want %d", tt.value, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    RoundingMode
+		wantErr bool
+	}{
+		{"half_even", RoundHalfEven, false},
+		{"half_up", RoundHalfUp, false},
+		{"up", RoundUp, false},
+		{"down", RoundDown, false},
+		{"nearest", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoundingMode(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRoundingMode(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseRoundingMode(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+// Package mqtt implements the small subset of an MQTT 3.1.1 client needed to talk to the bike
+// locks: connecting with credentials, publishing and subscribing with QoS 0 or 1, keep-alive
+// and automatic reconnection.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotConnected is returned when a packet is sent while the client is disconnected.
+var ErrNotConnected = errors.New("mqtt: not connected")
+
+// Options configures a Client.
+type Options struct {
+	// BrokerURL is the address of the broker, e.g. "tcp://localhost:1883" or
+	// "tls://broker.example.com:8883".
+	BrokerURL      string
+	ClientID       string
+	Username       string
+	Password       string
+	KeepAlive      time.Duration
+	ConnectTimeout time.Duration
+}
+
+// MessageHandler is called for every message received on a subscribed topic.
+type MessageHandler func(topic string, payload []byte)
+
+type subscription struct {
+	qos     byte
+	handler MessageHandler
+}
+
+// Client is an MQTT 3.1.1 client. It is safe for concurrent use.
+type Client struct {
+	opts Options
+
+	mu            sync.Mutex
+	conn          net.Conn
+	nextPacketID  uint16
+	pending       map[uint16]chan []byte
+	subscriptions map[string]subscription
+
+	writeMu sync.Mutex
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// Connect dials the broker and performs the MQTT handshake. The returned client reconnects
+// on its own, restoring its subscriptions, until Close is called.
+//
+// Parameters:
+// - ctx: bounds the initial connection attempt.
+// - opts: the connection options.
+//
+// Returns:
+// - *Client: a pointer to the connected client.
+// - error: an error if the broker could not be reached or refused the connection.
+func Connect(ctx context.Context, opts Options) (*Client, error) {
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+
+	if opts.ConnectTimeout == 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+
+	c := &Client{
+		opts:          opts,
+		pending:       map[uint16]chan []byte{},
+		subscriptions: map[string]subscription{},
+		closed:        make(chan struct{}),
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setConn(conn)
+	go c.run(conn)
+
+	return c, nil
+}
+
+// Publish sends payload to topic. With QoS 1 it waits until the broker acknowledges the
+// message or ctx is done.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, qos byte) error {
+	if qos > 1 {
+		return errors.New("mqtt: only qos 0 and 1 are supported")
+	}
+
+	body := appendString(nil, topic)
+
+	var id uint16
+	var ack chan []byte
+	if qos == 1 {
+		id, ack = c.reservePacketID()
+		defer c.releasePacketID(id)
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+
+	body = append(body, payload...)
+
+	if err := c.write(encodePacket(packetPublish<<4|qos<<1, body)); err != nil {
+		return err
+	}
+
+	if qos == 0 {
+		return nil
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.closed:
+		return ErrNotConnected
+	}
+}
+
+// Subscribe registers handler for the messages published on filter, which may contain the
+// "+" and "#" wildcards. The subscription is restored after a reconnection.
+func (c *Client) Subscribe(ctx context.Context, filter string, qos byte, handler MessageHandler) error {
+	if qos > 1 {
+		qos = 1
+	}
+
+	c.mu.Lock()
+	c.subscriptions[filter] = subscription{qos: qos, handler: handler}
+	c.mu.Unlock()
+
+	return c.subscribe(ctx, filter, qos)
+}
+
+// Close disconnects from the broker and stops reconnecting.
+func (c *Client) Close() error {
+	c.once.Do(func() { close(c.closed) })
+
+	c.mu.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	_, _ = conn.Write(encodePacket(packetDisconnect<<4, nil))
+	c.writeMu.Unlock()
+
+	return conn.Close()
+}
+
+func (c *Client) subscribe(ctx context.Context, filter string, qos byte) error {
+	id, ack := c.reservePacketID()
+	defer c.releasePacketID(id)
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = appendString(body, filter)
+	body = append(body, qos)
+
+	if err := c.write(encodePacket(packetSubscribe<<4|0x02, body)); err != nil {
+		return err
+	}
+
+	select {
+	case codes := <-ack:
+		if len(codes) == 0 || codes[0] == 0x80 {
+			return fmt.Errorf("mqtt: subscription to %q refused", filter)
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.closed:
+		return ErrNotConnected
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(c.opts.BrokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: invalid broker url: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.ConnectTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", hostWithPort(u.Host, "1883"))
+	case "tls", "ssl", "mqtts":
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", hostWithPort(u.Host, "8883"))
+	default:
+		return nil, fmt.Errorf("mqtt: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(c.connectPacket()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := readPacket(bufio.NewReader(conn))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if reply.kind() != packetConnack || len(reply.body) < 2 {
+		conn.Close()
+		return nil, errMalformedPacket
+	}
+
+	if code := reply.body[1]; code != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: connection refused with code %d", code)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+func (c *Client) connectPacket() []byte {
+	flags := byte(0x02) // clean session
+	if c.opts.Username != "" {
+		flags |= 0x80
+	}
+	if c.opts.Password != "" {
+		flags |= 0x40
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(c.opts.KeepAlive/time.Second))
+	body = appendString(body, c.opts.ClientID)
+
+	if c.opts.Username != "" {
+		body = appendString(body, c.opts.Username)
+	}
+	if c.opts.Password != "" {
+		body = appendString(body, c.opts.Password)
+	}
+
+	return encodePacket(packetConnect<<4, body)
+}
+
+// run reads packets until the connection drops, then reconnects with an exponential backoff.
+func (c *Client) run(conn net.Conn) {
+	for {
+		stopPing := make(chan struct{})
+		go c.keepAlive(stopPing)
+
+		err := c.readLoop(conn)
+		close(stopPing)
+		conn.Close()
+
+		select {
+		case <-c.closed:
+			return
+		default:
+		}
+
+		slog.Error("mqtt connection lost", "error", err)
+		c.setConn(nil)
+
+		conn = c.reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+func (c *Client) reconnect() net.Conn {
+	backoff := time.Second
+
+	for {
+		select {
+		case <-c.closed:
+			return nil
+		case <-time.After(backoff):
+		}
+
+		conn, err := c.dial(context.Background())
+		if err == nil {
+			c.setConn(conn)
+			go c.restoreSubscriptions()
+			return conn
+		}
+
+		slog.Error("mqtt reconnection failed", "error", err)
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (c *Client) restoreSubscriptions() {
+	c.mu.Lock()
+	subscriptions := make(map[string]subscription, len(c.subscriptions))
+	for filter, sub := range c.subscriptions {
+		subscriptions[filter] = sub
+	}
+	c.mu.Unlock()
+
+	for filter, sub := range subscriptions {
+		ctx, cancel := context.WithTimeout(context.Background(), c.opts.ConnectTimeout)
+		if err := c.subscribe(ctx, filter, sub.qos); err != nil {
+			slog.Error("mqtt resubscription failed", "filter", filter, "error", err)
+		}
+		cancel()
+	}
+}
+
+func (c *Client) readLoop(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+
+	for {
+		// The broker answers pings, so silence for 1.5 keep-alive periods means the link is dead.
+		_ = conn.SetReadDeadline(time.Now().Add(c.opts.KeepAlive * 3 / 2))
+
+		p, err := readPacket(reader)
+		if err != nil {
+			return err
+		}
+
+		switch p.kind() {
+		case packetPublish:
+			if err := c.handlePublish(p); err != nil {
+				return err
+			}
+		case packetPuback:
+			if len(p.body) >= 2 {
+				c.resolve(binary.BigEndian.Uint16(p.body), nil)
+			}
+		case packetSuback:
+			if len(p.body) >= 2 {
+				c.resolve(binary.BigEndian.Uint16(p.body), p.body[2:])
+			}
+		case packetPingresp:
+		default:
+			slog.Warn("mqtt unexpected packet", "type", p.kind())
+		}
+	}
+}
+
+func (c *Client) handlePublish(p packet) error {
+	qos := (p.flags() >> 1) & 0x03
+
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return err
+	}
+
+	if qos > 0 {
+		if len(rest) < 2 {
+			return errMalformedPacket
+		}
+
+		id := rest[:2]
+		rest = rest[2:]
+
+		if err := c.write(encodePacket(packetPuback<<4, id)); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	handlers := []MessageHandler{}
+	for filter, sub := range c.subscriptions {
+		if topicMatches(filter, topic) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(topic, rest)
+	}
+
+	return nil
+}
+
+func (c *Client) keepAlive(stop chan struct{}) {
+	ticker := time.NewTicker(c.opts.KeepAlive / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.write(encodePacket(packetPingreq<<4, nil)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) write(data []byte) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return ErrNotConnected
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(c.opts.ConnectTimeout))
+	_, err := conn.Write(data)
+
+	return err
+}
+
+func (c *Client) setConn(conn net.Conn) {
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+}
+
+func (c *Client) reservePacketID() (uint16, chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		c.nextPacketID++
+		if c.nextPacketID == 0 {
+			continue
+		}
+
+		if _, used := c.pending[c.nextPacketID]; !used {
+			ack := make(chan []byte, 1)
+			c.pending[c.nextPacketID] = ack
+			return c.nextPacketID, ack
+		}
+	}
+}
+
+func (c *Client) releasePacketID(id uint16) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *Client) resolve(id uint16, payload []byte) {
+	c.mu.Lock()
+	ack, ok := c.pending[id]
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case ack <- payload:
+		default:
+		}
+	}
+}
+
+// topicMatches reports whether topic matches the subscription filter, honouring the
+// single-level ("+") and multi-level ("#") wildcards.
+func topicMatches(filter string, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+
+		if i >= len(topicLevels) {
+			return false
+		}
+
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
+
+func hostWithPort(host string, defaultPort string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	return net.JoinHostPort(host, defaultPort)
+}
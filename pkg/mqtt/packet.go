@@ -0,0 +1,114 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Control packet types of MQTT 3.1.1.
+const (
+	packetConnect     byte = 1
+	packetConnack     byte = 2
+	packetPublish     byte = 3
+	packetPuback      byte = 4
+	packetSubscribe   byte = 8
+	packetSuback      byte = 9
+	packetPingreq     byte = 12
+	packetPingresp    byte = 13
+	packetDisconnect  byte = 14
+	maxRemainingBytes      = 268435455
+)
+
+var errMalformedPacket = errors.New("mqtt: malformed packet")
+
+// packet is a decoded control packet: the first byte of the fixed header and the rest of
+// the packet.
+type packet struct {
+	header byte
+	body   []byte
+}
+
+func (p packet) kind() byte {
+	return p.header >> 4
+}
+
+func (p packet) flags() byte {
+	return p.header & 0x0f
+}
+
+func encodePacket(header byte, body []byte) []byte {
+	buf := []byte{header}
+
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+
+		buf = append(buf, digit)
+		if length == 0 {
+			break
+		}
+	}
+
+	return append(buf, body...)
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errMalformedPacket
+		}
+
+		digit, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	if length > maxRemainingBytes {
+		return packet{}, errMalformedPacket
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+
+	return packet{header: header, body: body}, nil
+}
+
+func appendString(buf []byte, value string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(value)))
+	return append(buf, value...)
+}
+
+func readString(body []byte) (string, []byte, error) {
+	if len(body) < 2 {
+		return "", nil, errMalformedPacket
+	}
+
+	length := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+length {
+		return "", nil, errMalformedPacket
+	}
+
+	return string(body[2 : 2+length]), body[2+length:], nil
+}
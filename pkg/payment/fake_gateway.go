@@ -0,0 +1,284 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// fakeCards are the card tokens the FakeGateway knows. Like the test cards of real providers,
+// each token triggers a fixed behaviour.
+var fakeCards = map[string]fakeCard{
+	"tok_visa":               {card: Card{Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2034}},
+	"tok_mastercard":         {card: Card{Brand: "mastercard", Last4: "4444", ExpMonth: 12, ExpYear: 2034}},
+	"tok_declined":           {card: Card{Brand: "visa", Last4: "0002", ExpMonth: 12, ExpYear: 2034}, failureCode: "card_declined", failureMessage: "the card was declined"},
+	"tok_insufficient_funds": {card: Card{Brand: "visa", Last4: "9995", ExpMonth: 12, ExpYear: 2034}, failureCode: "insufficient_funds", failureMessage: "the card has insufficient funds"},
+	"tok_async":              {card: Card{Brand: "visa", Last4: "3220", ExpMonth: 12, ExpYear: 2034}, async: true},
+}
+
+type fakeCard struct {
+	card           Card
+	failureCode    string
+	failureMessage string
+	// async cards answer captures with StatusPending and report the result by webhook.
+	async bool
+}
+
+type fakePayment struct {
+	id         string
+	status     Status
+	async      bool
+	authorized money.Money
+	captured   money.Money
+	refunded   money.Money
+}
+
+type fakeOutcome struct {
+	result Result
+	err    error
+}
+
+// FakeGateway is an in-memory PaymentGateway for tests and local development. It is
+// deterministic: the behaviour depends only on the card token, and payment and event IDs are
+// derived from the idempotency keys. The known tokens are "tok_visa" and "tok_mastercard",
+// which always succeed, "tok_declined" and "tok_insufficient_funds", which are declined, and
+// "tok_async", whose captures are confirmed by webhook.
+type FakeGateway struct {
+	mu       sync.Mutex
+	secret   []byte
+	payments map[string]*fakePayment
+	outcomes map[string]fakeOutcome
+	deliver  func(payload []byte, signature string)
+	delay    time.Duration
+}
+
+// NewFakeGateway creates a new FakeGateway that signs its webhooks with secret.
+func NewFakeGateway(secret string) *FakeGateway {
+	return &FakeGateway{
+		secret:   []byte(secret),
+		payments: map[string]*fakePayment{},
+		outcomes: map[string]fakeOutcome{},
+	}
+}
+
+// OnWebhook sets how the asynchronous results are delivered: deliver is called with the signed
+// payload of each event, delay after the operation. Without it the events are dropped.
+func (f *FakeGateway) OnWebhook(deliver func(payload []byte, signature string), delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deliver = deliver
+	f.delay = delay
+}
+
+// AttachCard returns the details of a known test card.
+func (f *FakeGateway) AttachCard(ctx context.Context, token string) (*Card, error) {
+	known, ok := fakeCards[token]
+	if !ok {
+		return nil, ErrInvalidCard
+	}
+
+	card := known.card
+	card.Token = token
+
+	return &card, nil
+}
+
+// DetachCard accepts any known test card.
+func (f *FakeGateway) DetachCard(ctx context.Context, token string) error {
+	if _, ok := fakeCards[token]; !ok {
+		return ErrInvalidCard
+	}
+
+	return nil
+}
+
+// Authorize holds an amount on a test card, or declines it as the card dictates.
+func (f *FakeGateway) Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if outcome, ok := f.outcomes[req.IdempotencyKey]; ok {
+		return outcome.copy()
+	}
+
+	known, ok := fakeCards[req.CardToken]
+	if !ok {
+		return nil, ErrInvalidCard
+	}
+
+	if req.Amount.IsZero() || req.Amount.IsNegative() {
+		return nil, fmt.Errorf("%w: the amount must be positive", ErrInvalidOperation)
+	}
+
+	payment := &fakePayment{id: "pay_" + fakeID(req.IdempotencyKey), async: known.async, authorized: req.Amount}
+	f.payments[payment.id] = payment
+
+	result := Result{PaymentID: payment.id, Amount: req.Amount}
+	var err error
+
+	switch {
+	case known.failureCode != "":
+		payment.status = StatusFailed
+		result.Status = StatusFailed
+		result.FailureCode = known.failureCode
+		result.FailureMessage = known.failureMessage
+		err = fmt.Errorf("%w: %s", ErrDeclined, known.failureMessage)
+	default:
+		payment.status = StatusAuthorized
+		result.Status = StatusAuthorized
+	}
+
+	return f.remember(req.IdempotencyKey, result, err)
+}
+
+// Capture collects up to the authorized amount of a payment.
+func (f *FakeGateway) Capture(ctx context.Context, paymentID string, amount money.Money, idempotencyKey string) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if outcome, ok := f.outcomes[idempotencyKey]; ok {
+		return outcome.copy()
+	}
+
+	payment, ok := f.payments[paymentID]
+	if !ok || payment.status != StatusAuthorized {
+		return nil, fmt.Errorf("%w: the payment is not authorized", ErrInvalidOperation)
+	}
+
+	if amount.Currency != payment.authorized.Currency || amount.IsZero() || amount.IsNegative() || amount.Cmp(payment.authorized) > 0 {
+		return nil, fmt.Errorf("%w: the amount must be positive and at most the authorized amount", ErrInvalidOperation)
+	}
+
+	payment.status = StatusCaptured
+	payment.captured = amount
+
+	result := Result{PaymentID: payment.id, Status: StatusCaptured, Amount: amount}
+	if payment.async {
+		result.Status = StatusPending
+		f.schedule(Event{PaymentID: payment.id, Status: StatusCaptured, Amount: amount})
+	}
+
+	return f.remember(idempotencyKey, result, nil)
+}
+
+// Void releases the amount held by an authorized payment.
+func (f *FakeGateway) Void(ctx context.Context, paymentID string, idempotencyKey string) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if outcome, ok := f.outcomes[idempotencyKey]; ok {
+		return outcome.copy()
+	}
+
+	payment, ok := f.payments[paymentID]
+	if !ok || payment.status != StatusAuthorized {
+		return nil, fmt.Errorf("%w: the payment is not authorized", ErrInvalidOperation)
+	}
+
+	payment.status = StatusVoided
+
+	return f.remember(idempotencyKey, Result{PaymentID: payment.id, Status: StatusVoided, Amount: payment.authorized}, nil)
+}
+
+// Refund gives back some or all of the captured amount of a payment. The result has
+// StatusRefunded and the amount refunded by this call.
+func (f *FakeGateway) Refund(ctx context.Context, paymentID string, amount money.Money, idempotencyKey string) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if outcome, ok := f.outcomes[idempotencyKey]; ok {
+		return outcome.copy()
+	}
+
+	payment, ok := f.payments[paymentID]
+	if !ok || (payment.status != StatusCaptured && payment.status != StatusRefunded) {
+		return nil, fmt.Errorf("%w: the payment is not captured", ErrInvalidOperation)
+	}
+
+	left := payment.captured.Sub(payment.refunded)
+	if amount.Currency != left.Currency || amount.IsZero() || amount.IsNegative() || amount.Cmp(left) > 0 {
+		return nil, fmt.Errorf("%w: the amount must be positive and at most the amount left to refund", ErrInvalidOperation)
+	}
+
+	payment.refunded = payment.refunded.Add(amount)
+	if payment.refunded.Cmp(payment.captured) == 0 {
+		payment.status = StatusRefunded
+	}
+
+	return f.remember(idempotencyKey, Result{PaymentID: payment.id, Status: StatusRefunded, Amount: amount}, nil)
+}
+
+// ParseWebhook checks that a payload was signed with the secret of the gateway and decodes it.
+func (f *FakeGateway) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, f.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	return &event, nil
+}
+
+// Sign returns the signature the gateway sends with a webhook payload.
+func (f *FakeGateway) Sign(payload []byte) string {
+	return hex.EncodeToString(f.sign(payload))
+}
+
+func (f *FakeGateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// schedule delivers an event after the configured delay. It must be called with the mutex held.
+func (f *FakeGateway) schedule(event Event) {
+	if f.deliver == nil {
+		return
+	}
+
+	event.ID = "evt_" + fakeID(event.PaymentID+":"+string(event.Status))
+	event.CreatedAt = time.Now()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	deliver, signature := f.deliver, f.Sign(payload)
+	time.AfterFunc(f.delay, func() { deliver(payload, signature) })
+}
+
+// remember stores the outcome of an operation, so a retry with the same idempotency key gets
+// it back. It must be called with the mutex held.
+func (f *FakeGateway) remember(idempotencyKey string, result Result, err error) (*Result, error) {
+	outcome := fakeOutcome{result: result, err: err}
+	if idempotencyKey != "" {
+		f.outcomes[idempotencyKey] = outcome
+	}
+
+	return outcome.copy()
+}
+
+func (o fakeOutcome) copy() (*Result, error) {
+	result := o.result
+	return &result, o.err
+}
+
+// fakeID derives a stable identifier from a seed.
+func fakeID(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:12])
+}
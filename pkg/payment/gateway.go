@@ -0,0 +1,109 @@
+// Package payment collects money from riders through a payment provider: cards on file,
+// pre-authorizations, captures, voids and refunds, and the asynchronous results the provider
+// reports through webhooks.
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// SignatureHeader is the request header carrying the signature of a webhook payload.
+const SignatureHeader = "Payment-Signature"
+
+// Status is the state of a payment at the provider.
+type Status string
+
+const (
+	// StatusPending means the provider has not decided yet; the result arrives by webhook.
+	StatusPending    Status = "pending"
+	StatusAuthorized Status = "authorized"
+	StatusCaptured   Status = "captured"
+	StatusVoided     Status = "voided"
+	StatusRefunded   Status = "refunded"
+	StatusFailed     Status = "failed"
+)
+
+var (
+	// ErrDeclined is returned when the provider declines an operation. The result carries the
+	// failure code and message.
+	ErrDeclined = errors.New("payment was declined")
+	// ErrInvalidCard is returned when a card token is unknown to the provider.
+	ErrInvalidCard = errors.New("invalid card token")
+	// ErrInvalidOperation is returned when an operation does not apply to the payment, such as
+	// capturing more than was authorized.
+	ErrInvalidOperation = errors.New("invalid payment operation")
+	// ErrInvalidSignature is returned when a webhook payload is not signed by the provider.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Card is a card on file. Token is the reference of the card at the provider; the card number
+// never reaches the service.
+type Card struct {
+	Token    string `json:"token"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
+
+// AuthorizeRequest asks the provider to hold an amount on a card.
+type AuthorizeRequest struct {
+	CardToken string
+	Amount    money.Money
+	// Reference identifies what is paid for, such as a rental, in the provider dashboard.
+	Reference string
+	// IdempotencyKey makes the request safe to retry: the provider answers a retry with the
+	// result of the first request.
+	IdempotencyKey string
+}
+
+// Result is the outcome of an operation on a payment.
+type Result struct {
+	// PaymentID is the reference of the payment at the provider.
+	PaymentID string      `json:"payment_id"`
+	Status    Status      `json:"status"`
+	Amount    money.Money `json:"amount"`
+	// FailureCode is a machine-readable reason for a declined operation.
+	FailureCode    string `json:"failure_code,omitempty"`
+	FailureMessage string `json:"failure_message,omitempty"`
+}
+
+// Event is an asynchronous result reported by the provider through a webhook.
+type Event struct {
+	ID             string      `json:"id"`
+	PaymentID      string      `json:"payment_id"`
+	Status         Status      `json:"status"`
+	Amount         money.Money `json:"amount"`
+	FailureCode    string      `json:"failure_code,omitempty"`
+	FailureMessage string      `json:"failure_message,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// PaymentGateway is a payment provider.
+//
+// Operations that move money take an idempotency key, so a request interrupted by a timeout can
+// be retried without charging twice. An operation declined by the provider returns a result with
+// the failure and an error wrapping ErrDeclined. An operation the provider has not decided yet
+// returns a result with StatusPending, and the final result arrives as an Event.
+type PaymentGateway interface {
+	// AttachCard stores the card behind a token created by the provider's client SDK and
+	// returns its details.
+	AttachCard(ctx context.Context, token string) (*Card, error)
+	// DetachCard removes a card from the provider.
+	DetachCard(ctx context.Context, token string) error
+	// Authorize holds an amount on a card.
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error)
+	// Capture collects up to the authorized amount of a payment and releases the rest.
+	Capture(ctx context.Context, paymentID string, amount money.Money, idempotencyKey string) (*Result, error)
+	// Void releases the amount held by a payment that was not captured.
+	Void(ctx context.Context, paymentID string, idempotencyKey string) (*Result, error)
+	// Refund gives back some or all of the captured amount of a payment.
+	Refund(ctx context.Context, paymentID string, amount money.Money, idempotencyKey string) (*Result, error)
+	// ParseWebhook checks the signature of a webhook payload and decodes its event. It returns
+	// ErrInvalidSignature when the payload was not sent by the provider.
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
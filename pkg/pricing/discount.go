@@ -0,0 +1,58 @@
+package pricing
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// DiscountType tells how a discount reduces the price of a ride.
+type DiscountType string
+
+const (
+	// DiscountPercentage takes a percentage off the price of the ride.
+	DiscountPercentage DiscountType = "percentage"
+	// DiscountFixedAmount takes a fixed amount off the price of the ride.
+	DiscountFixedAmount DiscountType = "fixed_amount"
+	// DiscountFreeMinutes waives the time charge of some minutes after the free minutes of the plan.
+	DiscountFreeMinutes DiscountType = "free_minutes"
+)
+
+// Discount reduces the price of a ride, such as a promo code. It never takes the price below zero.
+type Discount struct {
+	Name        string
+	Type        DiscountType
+	Percent     int
+	Amount      money.Money
+	FreeMinutes int
+}
+
+// amount returns how much the discount takes off a ride price. The fixed amount of a discount in
+// another currency than the plan is not applied.
+func (d Discount) amount(p Plan, billed time.Duration, total money.Money) money.Money {
+	discount := money.New(0, total.Currency)
+
+	switch d.Type {
+	case DiscountPercentage:
+		discount = total.Mul(big.NewRat(int64(d.Percent), 100), p.MoneyRounding)
+	case DiscountFixedAmount:
+		if d.Amount.Currency == total.Currency {
+			discount = d.Amount
+		}
+	case DiscountFreeMinutes:
+		free := min(billed, time.Duration(p.FreeMinutes)*time.Minute)
+		waived := min(billed, free+time.Duration(d.FreeMinutes)*time.Minute) - free
+		discount = p.round(p.timeCharge(waived))
+	}
+
+	if discount.IsNegative() || total.IsNegative() {
+		return money.New(0, total.Currency)
+	}
+
+	if discount.Cmp(total) > 0 {
+		return total
+	}
+
+	return discount
+}
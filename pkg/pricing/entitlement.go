@@ -0,0 +1,43 @@
+package pricing
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// Entitlement is what a pass of the rider covers on a ride. The covered minutes follow the free
+// minutes of the plan, and the member discount is a percentage off the rest of the time charge.
+type Entitlement struct {
+	Name            string
+	Minutes         int
+	WaiveUnlockFee  bool
+	DiscountPercent int
+}
+
+// covered returns the ride time covered by the entitlement.
+func (e Entitlement) covered(p Plan, billed time.Duration) time.Duration {
+	free := min(billed, time.Duration(p.FreeMinutes)*time.Minute)
+	return min(billed, free+time.Duration(e.Minutes)*time.Minute) - free
+}
+
+// minutesCharge returns the time charge of the covered minutes, which never exceeds the time
+// charge left after the daily cap.
+func (e Entitlement) minutesCharge(p Plan, billed time.Duration, timeCharge money.Money) money.Money {
+	charge := p.round(p.timeCharge(e.covered(p, billed)))
+	if charge.Cmp(timeCharge) > 0 {
+		return timeCharge
+	}
+
+	return charge
+}
+
+// discount returns the member discount on a time charge.
+func (e Entitlement) discount(p Plan, timeCharge money.Money) money.Money {
+	if e.DiscountPercent <= 0 || timeCharge.IsNegative() {
+		return money.New(0, timeCharge.Currency)
+	}
+
+	return timeCharge.Mul(big.NewRat(int64(min(e.DiscountPercent, 100)), 100), p.MoneyRounding)
+}
@@ -0,0 +1,258 @@
+package pricing
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// Rounding tells how a ride duration is rounded to the billing increment of a plan.
+type Rounding string
+
+const (
+	RoundUp      Rounding = "up"
+	RoundDown    Rounding = "down"
+	RoundNearest Rounding = "nearest"
+)
+
+// Line item codes of a breakdown.
+const (
+	LineUnlockFee     = "unlock_fee"
+	LineTime          = "time"
+	LinePausedTime    = "paused_time"
+	LineFreeMinutes   = "free_minutes"
+	LineDailyCap      = "daily_cap"
+	LineRule          = "rule"
+	LineMinimumCharge = "minimum_charge"
+	LineZoneFee       = "zone_fee"
+	LineDiscount      = "discount"
+	LinePass          = "pass"
+	LineMemberPrice   = "member_price"
+)
+
+const day = 24 * time.Hour
+
+// Plan describes how a ride is charged. Every amount is in the plan currency.
+type Plan struct {
+	Name      string
+	Currency  string
+	UnlockFee money.Money
+	// Rate is charged for every RatePeriod of ride time, pro rata.
+	Rate       money.Money
+	RatePeriod time.Duration
+	// PausedRate is charged for every RatePeriod the ride is paused, pro rata.
+	PausedRate       money.Money
+	BillingIncrement time.Duration
+	Rounding         Rounding
+	FreeMinutes      int
+	DailyCap         money.Money
+	MinimumCharge    money.Money
+	Rules            []Rule
+	// Entitlement is what a pass of the rider covers, before any money is charged.
+	Entitlement *Entitlement
+	// Discount is taken off the price of the ride, after the minimum charge.
+	Discount *Discount
+	// MoneyRounding tells how the amount of each line is rounded to minor units.
+	MoneyRounding money.RoundingMode
+}
+
+// LineItem is one component of a price. Discounts have negative amounts.
+type LineItem struct {
+	Code        string      `json:"code"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+}
+
+// AppliedRule is a rule that adjusted the price of a ride, with the multiplier it had then.
+type AppliedRule struct {
+	Name       string  `json:"name"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Rule returns a rule that always applies with the recorded multiplier, so a ride can be priced
+// again as it was, however the rule was edited since.
+func (a AppliedRule) Rule() Rule {
+	return Rule{Name: a.Name, Type: RuleSchedule, Multiplier: a.Multiplier}
+}
+
+// Breakdown itemizes the price of a ride. Total is the sum of the line amounts. Rules names the
+// rules that applied, and AppliedRules records them with their multipliers.
+type Breakdown struct {
+	Plan          string        `json:"plan"`
+	BilledMinutes float64       `json:"billed_minutes"`
+	PausedMinutes float64       `json:"paused_minutes,omitempty"`
+	PassMinutes   float64       `json:"pass_minutes,omitempty"`
+	Lines         []LineItem    `json:"lines"`
+	Rules         []string      `json:"rules,omitempty"`
+	AppliedRules  []AppliedRule `json:"applied_rules,omitempty"`
+	Total         money.Money   `json:"total"`
+}
+
+// Add appends a line to the breakdown and updates its total. Zero amounts are left out.
+func (b *Breakdown) Add(code string, description string, amount money.Money) {
+	if amount.IsZero() {
+		return
+	}
+
+	b.Lines = append(b.Lines, LineItem{Code: code, Description: description, Amount: amount})
+	b.Total = b.Total.Add(amount)
+}
+
+// Price computes the price of a ride of the given duration, never paused. See PriceRide.
+//
+// Parameters:
+// - duration: how long the ride lasted.
+// - conditions: the circumstances of the ride the rules are evaluated against.
+//
+// Returns:
+// - Breakdown: the itemized price.
+func (p Plan) Price(duration time.Duration, conditions Conditions) Breakdown {
+	return p.PriceRide(duration, 0, conditions)
+}
+
+// PriceRide computes the price of a ride that was ridden for some time and paused for some other.
+//
+// The riding time is first rounded to the billing increment. The free minutes are then deducted
+// from the start of the ride, and the time charge of each 24 hour period of the ride is capped
+// at the daily cap. A pass entitlement then covers the minutes that follow, and its member
+// discount is taken off the rest of the time charge. Every rule whose condition is met then
+// adjusts the resulting time charge. The paused time is charged at the paused rate, apart from
+// the free minutes, daily cap, pass and rules. The unlock fee is added on top, unless the pass
+// waives it, and the total is raised to the minimum charge when it falls below it, except for
+// pass holders. A discount is finally taken off, down to zero. Each line is computed exactly
+// and rounded once, with the plan money rounding.
+//
+// Parameters:
+// - riding: how long the bike was ridden.
+// - paused: how long the ride was paused.
+// - conditions: the circumstances of the ride the rules are evaluated against.
+//
+// Returns:
+// - Breakdown: the itemized price.
+func (p Plan) PriceRide(riding time.Duration, paused time.Duration, conditions Conditions) Breakdown {
+	billed := p.billedDuration(riding)
+	breakdown := Breakdown{Plan: p.Name, BilledMinutes: billed.Minutes(), Total: money.New(0, p.Currency)}
+
+	breakdown.Add(LineUnlockFee, "Unlock fee", p.UnlockFee)
+	breakdown.Add(LineTime, "Ride time", p.round(p.timeCharge(billed)))
+
+	free := min(billed, time.Duration(p.FreeMinutes)*time.Minute)
+	breakdown.Add(LineFreeMinutes, "Free minutes", p.round(p.timeCharge(free)).Neg())
+
+	if !p.DailyCap.IsZero() {
+		uncapped, capped := new(big.Rat), new(big.Rat)
+		dailyCap := p.DailyCap.Minor()
+
+		for start := time.Duration(0); start < billed; start += day {
+			charge := p.timeCharge(min(start+day, billed) - max(start, free))
+			if charge.Sign() <= 0 {
+				continue
+			}
+
+			uncapped.Add(uncapped, charge)
+			if charge.Cmp(dailyCap) > 0 {
+				charge = dailyCap
+			}
+			capped.Add(capped, charge)
+		}
+
+		breakdown.Add(LineDailyCap, "Daily cap", p.round(capped.Sub(capped, uncapped)))
+	}
+
+	if p.Entitlement != nil {
+		covered := p.Entitlement.minutesCharge(p, billed, breakdown.Total.Sub(p.UnlockFee))
+		breakdown.PassMinutes = p.Entitlement.covered(p, billed).Minutes()
+		breakdown.Add(LinePass, p.Entitlement.Name+" minutes", covered.Neg())
+		breakdown.Add(LineMemberPrice, "Member price", p.Entitlement.discount(p, breakdown.Total.Sub(p.UnlockFee)).Neg())
+	}
+
+	timeCharge := breakdown.Total.Sub(p.UnlockFee)
+	for _, rule := range p.Rules {
+		if !rule.Applies(conditions) {
+			continue
+		}
+
+		breakdown.Rules = append(breakdown.Rules, rule.Name)
+		breakdown.AppliedRules = append(breakdown.AppliedRules, AppliedRule{Name: rule.Name, Multiplier: rule.Multiplier})
+		breakdown.Add(LineRule, rule.Name, timeCharge.Mul(rule.adjustmentFactor(), p.MoneyRounding))
+	}
+
+	if paused > 0 {
+		breakdown.PausedMinutes = paused.Minutes()
+		breakdown.Add(LinePausedTime, "Paused time", p.round(p.pausedCharge(paused)))
+	}
+
+	if p.Entitlement != nil && p.Entitlement.WaiveUnlockFee {
+		breakdown.Add(LinePass, p.Entitlement.Name+" unlock", p.UnlockFee.Neg())
+	}
+
+	if p.Entitlement == nil && breakdown.Total.Cmp(p.MinimumCharge) < 0 {
+		breakdown.Add(LineMinimumCharge, "Minimum charge", p.MinimumCharge.Sub(breakdown.Total))
+	}
+
+	if p.Discount != nil {
+		breakdown.Add(LineDiscount, p.Discount.Name, p.Discount.amount(p, billed, breakdown.Total).Neg())
+	}
+
+	return breakdown
+}
+
+// timeCharge returns the exact charge, in minor units, of some ride time.
+func (p Plan) timeCharge(duration time.Duration) *big.Rat {
+	if duration <= 0 || p.RatePeriod <= 0 {
+		return new(big.Rat)
+	}
+
+	charge := new(big.Rat).SetFrac(big.NewInt(int64(duration)), big.NewInt(int64(p.RatePeriod)))
+	return charge.Mul(charge, p.Rate.Minor())
+}
+
+// pausedCharge returns the exact charge, in minor units, of some paused time.
+func (p Plan) pausedCharge(duration time.Duration) *big.Rat {
+	if duration <= 0 || p.RatePeriod <= 0 {
+		return new(big.Rat)
+	}
+
+	charge := new(big.Rat).SetFrac(big.NewInt(int64(duration)), big.NewInt(int64(p.RatePeriod)))
+	return charge.Mul(charge, p.PausedRate.Minor())
+}
+
+// round rounds an exact amount of minor units with the plan money rounding.
+func (p Plan) round(amount *big.Rat) money.Money {
+	return money.FromMinor(amount, p.Currency, p.MoneyRounding)
+}
+
+// billedDuration rounds a duration to the billing increment. Without an increment the exact
+// duration is billed.
+func (p Plan) billedDuration(duration time.Duration) time.Duration {
+	if duration < 0 {
+		duration = 0
+	}
+
+	if p.BillingIncrement <= 0 {
+		return duration
+	}
+
+	increments := float64(duration) / float64(p.BillingIncrement)
+
+	switch p.Rounding {
+	case RoundDown:
+		increments = math.Floor(increments)
+	case RoundNearest:
+		increments = math.Round(increments)
+	default:
+		increments = math.Ceil(increments)
+	}
+
+	return time.Duration(increments) * p.BillingIncrement
+}
+
+// decimalRat converts a decimal setting, such as a multiplier, into an exact number. It is
+// read from its shortest decimal form so that 0.8 is exactly 8/10.
+func decimalRat(value float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
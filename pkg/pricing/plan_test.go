@@ -0,0 +1,372 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// testPlan returns a plan charging a 1.00 BRL unlock fee and 0.50 BRL per minute, billed by the
+// started minute.
+func testPlan() Plan {
+	return Plan{
+		Name:             "Test",
+		Currency:         "BRL",
+		UnlockFee:        money.New(100, "BRL"),
+		Rate:             money.New(50, "BRL"),
+		RatePeriod:       time.Minute,
+		BillingIncrement: time.Minute,
+		Rounding:         RoundUp,
+		MoneyRounding:    money.RoundHalfEven,
+	}
+}
+
+func TestPriceRide(t *testing.T) {
+	one, five := 1, 5
+
+	tests := []struct {
+		name       string
+		plan       func(p *Plan)
+		riding     time.Duration
+		paused     time.Duration
+		conditions Conditions
+		wantBilled float64
+		wantTotal  int64
+	}{
+		{
+			name:       "whole minutes",
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  600,
+		},
+		{
+			name:       "started increment rounded up",
+			riding:     10*time.Minute + time.Second,
+			wantBilled: 11,
+			wantTotal:  650,
+		},
+		{
+			name:       "increment rounded down",
+			plan:       func(p *Plan) { p.Rounding = RoundDown },
+			riding:     10*time.Minute + 59*time.Second,
+			wantBilled: 10,
+			wantTotal:  600,
+		},
+		{
+			name:       "increment rounded to the nearest below half",
+			plan:       func(p *Plan) { p.Rounding = RoundNearest },
+			riding:     10*time.Minute + 29*time.Second,
+			wantBilled: 10,
+			wantTotal:  600,
+		},
+		{
+			name:       "increment rounded to the nearest at half",
+			plan:       func(p *Plan) { p.Rounding = RoundNearest },
+			riding:     10*time.Minute + 30*time.Second,
+			wantBilled: 11,
+			wantTotal:  650,
+		},
+		{
+			name:       "five minute increment",
+			plan:       func(p *Plan) { p.BillingIncrement = 5 * time.Minute },
+			riding:     11 * time.Minute,
+			wantBilled: 15,
+			wantTotal:  850,
+		},
+		{
+			name:       "no increment bills the exact duration",
+			plan:       func(p *Plan) { p.BillingIncrement = 0 },
+			riding:     90 * time.Second,
+			wantBilled: 1.5,
+			wantTotal:  175,
+		},
+		{
+			name:       "half a cent rounded half to even",
+			plan:       func(p *Plan) { p.BillingIncrement = 0; p.Rate = money.New(25, "BRL") },
+			riding:     30 * time.Second,
+			wantBilled: 0.5,
+			wantTotal:  112,
+		},
+		{
+			name: "half a cent rounded half up",
+			plan: func(p *Plan) {
+				p.BillingIncrement = 0
+				p.Rate = money.New(25, "BRL")
+				p.MoneyRounding = money.RoundHalfUp
+			},
+			riding:     30 * time.Second,
+			wantBilled: 0.5,
+			wantTotal:  113,
+		},
+		{
+			name:       "free minutes",
+			plan:       func(p *Plan) { p.FreeMinutes = 5 },
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  350,
+		},
+		{
+			name:       "free minutes longer than the ride",
+			plan:       func(p *Plan) { p.FreeMinutes = 15 },
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  100,
+		},
+		{
+			name:       "daily cap",
+			plan:       func(p *Plan) { p.DailyCap = money.New(5000, "BRL") },
+			riding:     3 * time.Hour,
+			wantBilled: 180,
+			wantTotal:  5100,
+		},
+		{
+			name:       "daily cap for each 24 hours",
+			plan:       func(p *Plan) { p.DailyCap = money.New(5000, "BRL") },
+			riding:     26 * time.Hour,
+			wantBilled: 1560,
+			wantTotal:  10100,
+		},
+		{
+			name:       "daily cap after the free minutes",
+			plan:       func(p *Plan) { p.DailyCap = money.New(5000, "BRL"); p.FreeMinutes = 10 },
+			riding:     3 * time.Hour,
+			wantBilled: 180,
+			wantTotal:  5100,
+		},
+		{
+			name:       "daily cap not reached",
+			plan:       func(p *Plan) { p.DailyCap = money.New(5000, "BRL") },
+			riding:     time.Hour,
+			wantBilled: 60,
+			wantTotal:  3100,
+		},
+		{
+			name:       "minimum charge",
+			plan:       func(p *Plan) { p.MinimumCharge = money.New(1000, "BRL") },
+			riding:     2 * time.Minute,
+			wantBilled: 2,
+			wantTotal:  1000,
+		},
+		{
+			name:       "minimum charge exceeded",
+			plan:       func(p *Plan) { p.MinimumCharge = money.New(300, "BRL") },
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  600,
+		},
+		{
+			name: "pass minutes after the free minutes",
+			plan: func(p *Plan) {
+				p.FreeMinutes = 5
+				p.Entitlement = &Entitlement{Name: "Monthly", Minutes: 30}
+			},
+			riding:     45 * time.Minute,
+			wantBilled: 45,
+			wantTotal:  600,
+		},
+		{
+			name: "pass waiving the unlock fee",
+			plan: func(p *Plan) {
+				p.FreeMinutes = 5
+				p.Entitlement = &Entitlement{Name: "Monthly", Minutes: 30, WaiveUnlockFee: true}
+			},
+			riding:     45 * time.Minute,
+			wantBilled: 45,
+			wantTotal:  500,
+		},
+		{
+			name: "pass holders skip the minimum charge",
+			plan: func(p *Plan) {
+				p.MinimumCharge = money.New(1000, "BRL")
+				p.Entitlement = &Entitlement{Name: "Monthly", Minutes: 30}
+			},
+			riding:     2 * time.Minute,
+			wantBilled: 2,
+			wantTotal:  100,
+		},
+		{
+			name:       "pass member discount",
+			plan:       func(p *Plan) { p.Entitlement = &Entitlement{Name: "Monthly", DiscountPercent: 20} },
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  500,
+		},
+		{
+			name: "pass minutes never exceed the capped time charge",
+			plan: func(p *Plan) {
+				p.DailyCap = money.New(5000, "BRL")
+				p.Entitlement = &Entitlement{Name: "Monthly", Minutes: 200}
+			},
+			riding:     3 * time.Hour,
+			wantBilled: 180,
+			wantTotal:  100,
+		},
+		{
+			name: "surge rule",
+			plan: func(p *Plan) {
+				p.Rules = []Rule{{Name: "Surge", Type: RuleSurge, Multiplier: 1.5, MaxAvailableBikes: 2}}
+			},
+			riding:     10 * time.Minute,
+			conditions: Conditions{StartStationAvailableBikes: &one},
+			wantBilled: 10,
+			wantTotal:  850,
+		},
+		{
+			name: "surge rule not met",
+			plan: func(p *Plan) {
+				p.Rules = []Rule{{Name: "Surge", Type: RuleSurge, Multiplier: 1.5, MaxAvailableBikes: 2}}
+			},
+			riding:     10 * time.Minute,
+			conditions: Conditions{StartStationAvailableBikes: &five},
+			wantBilled: 10,
+			wantTotal:  600,
+		},
+		{
+			name: "depleted return discount",
+			plan: func(p *Plan) {
+				p.Rules = []Rule{{Name: "Return", Type: RuleDepletedReturn, Multiplier: 0.8, MaxAvailableBikes: 2}}
+			},
+			riding:     10 * time.Minute,
+			conditions: Conditions{ReturnStationAvailableBikes: &one},
+			wantBilled: 10,
+			wantTotal:  500,
+		},
+		{
+			name: "rules adjust the time charge left after the pass",
+			plan: func(p *Plan) {
+				p.Entitlement = &Entitlement{Name: "Monthly", Minutes: 5}
+				p.Rules = []Rule{{Name: "Holiday", Type: RuleHoliday, Multiplier: 1.5}}
+			},
+			riding:     10 * time.Minute,
+			conditions: Conditions{Holiday: true},
+			wantBilled: 10,
+			wantTotal:  475,
+		},
+		{
+			name:       "paused time at the paused rate",
+			plan:       func(p *Plan) { p.PausedRate = money.New(20, "BRL") },
+			riding:     10 * time.Minute,
+			paused:     5 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  700,
+		},
+		{
+			name:       "paused time apart from the free minutes",
+			plan:       func(p *Plan) { p.PausedRate = money.New(20, "BRL"); p.FreeMinutes = 10 },
+			riding:     10 * time.Minute,
+			paused:     5 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  200,
+		},
+		{
+			name: "discount after the minimum charge",
+			plan: func(p *Plan) {
+				p.MinimumCharge = money.New(1000, "BRL")
+				p.Discount = &Discount{Name: "HALF", Type: DiscountPercentage, Percent: 50}
+			},
+			riding:     2 * time.Minute,
+			wantBilled: 2,
+			wantTotal:  500,
+		},
+		{
+			name: "fixed discount never below zero",
+			plan: func(p *Plan) {
+				p.Discount = &Discount{Name: "GIFT", Type: DiscountFixedAmount, Amount: money.New(5000, "BRL")}
+			},
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  0,
+		},
+		{
+			name: "fixed discount in another currency",
+			plan: func(p *Plan) {
+				p.Discount = &Discount{Name: "GIFT", Type: DiscountFixedAmount, Amount: money.New(100, "USD")}
+			},
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  600,
+		},
+		{
+			name:       "free minutes discount",
+			plan:       func(p *Plan) { p.Discount = &Discount{Name: "FREE5", Type: DiscountFreeMinutes, FreeMinutes: 5} },
+			riding:     10 * time.Minute,
+			wantBilled: 10,
+			wantTotal:  350,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := testPlan()
+			if tt.plan != nil {
+				tt.plan(&plan)
+			}
+
+			breakdown := plan.PriceRide(tt.riding, tt.paused, tt.conditions)
+
+			if breakdown.BilledMinutes != tt.wantBilled {
+				t.Errorf("billed minutes = %v, want %v", breakdown.BilledMinutes, tt.wantBilled)
+			}
+
+			if want := money.New(tt.wantTotal, "BRL"); breakdown.Total != want {
+				t.Errorf("total = %s, want %s (lines %+v)", breakdown.Total, want, breakdown.Lines)
+			}
+
+			sum := money.New(0, "BRL")
+			for _, line := range breakdown.Lines {
+				sum = sum.Add(line.Amount)
+			}
+
+			if sum != breakdown.Total {
+				t.Errorf("lines add up to %s, total is %s", sum, breakdown.Total)
+			}
+		})
+	}
+}
+
+func TestPriceLines(t *testing.T) {
+	plan := testPlan()
+	plan.FreeMinutes = 5
+	plan.MinimumCharge = money.New(1000, "BRL")
+
+	breakdown := plan.Price(10*time.Minute, Conditions{})
+
+	want := []LineItem{
+		{Code: LineUnlockFee, Description: "Unlock fee", Amount: money.New(100, "BRL")},
+		{Code: LineTime, Description: "Ride time", Amount: money.New(500, "BRL")},
+		{Code: LineFreeMinutes, Description: "Free minutes", Amount: money.New(-250, "BRL")},
+		{Code: LineMinimumCharge, Description: "Minimum charge", Amount: money.New(650, "BRL")},
+	}
+
+	if len(breakdown.Lines) != len(want) {
+		t.Fatalf("lines = %+v, want %+v", breakdown.Lines, want)
+	}
+
+	for i := range want {
+		if breakdown.Lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, breakdown.Lines[i], want[i])
+		}
+	}
+}
+
+func TestPriceRecordsAppliedRules(t *testing.T) {
+	plan := testPlan()
+	plan.Rules = []Rule{
+		{Name: "Holiday", Type: RuleHoliday, Multiplier: 1.5},
+		{Name: "Surge", Type: RuleSurge, Multiplier: 2},
+	}
+
+	breakdown := plan.Price(10*time.Minute, Conditions{Holiday: true})
+
+	want := []AppliedRule{{Name: "Holiday", Multiplier: 1.5}}
+	if len(breakdown.AppliedRules) != len(want) || breakdown.AppliedRules[0] != want[0] {
+		t.Fatalf("applied rules = %+v, want %+v", breakdown.AppliedRules, want)
+	}
+
+	// Priced again from the recorded rule, the ride costs the same once the rule is edited.
+	plan.Rules = []Rule{breakdown.AppliedRules[0].Rule()}
+	if again := plan.Price(10*time.Minute, Conditions{}); again.Total != breakdown.Total {
+		t.Errorf("total priced again = %s, want %s", again.Total, breakdown.Total)
+	}
+}
@@ -0,0 +1,83 @@
+package pricing
+
+import (
+	"math/big"
+	"slices"
+	"time"
+)
+
+// RuleType tells which condition triggers a pricing rule.
+type RuleType string
+
+const (
+	// RuleSchedule applies to rides started on the given weekdays and hours.
+	RuleSchedule RuleType = "schedule"
+	// RuleHoliday applies to rides started on a holiday.
+	RuleHoliday RuleType = "holiday"
+	// RuleSurge applies to rides started at a station with few bikes left.
+	RuleSurge RuleType = "surge"
+	// RuleDepletedReturn applies to rides ended at a station with few bikes left.
+	RuleDepletedReturn RuleType = "depleted_return"
+)
+
+// Rule adjusts the ride time charge of a ride by a multiplier when its condition is met.
+// Multipliers above 1 raise the price and multipliers below 1 discount it.
+type Rule struct {
+	Name       string
+	Type       RuleType
+	Multiplier float64
+	// Weekdays restricts schedule rules to some days of the week. Empty means every day.
+	Weekdays []time.Weekday
+	// StartHour and EndHour restrict schedule rules to the hours [StartHour, EndHour) of the day.
+	// The window wraps around midnight when StartHour is after EndHour, and covers the whole day
+	// when both are equal.
+	StartHour int
+	EndHour   int
+	// MaxAvailableBikes is the availability at or below which surge and depleted return rules apply.
+	MaxAvailableBikes int
+}
+
+// Conditions describes the ride a rule is evaluated against.
+type Conditions struct {
+	// StartTime is the start of the ride in the local time of the operator.
+	StartTime time.Time
+	Holiday   bool
+	// StartStationAvailableBikes and ReturnStationAvailableBikes are the bikes left at the
+	// station the ride started or ended at, or nil when the ride did not start or end at one.
+	StartStationAvailableBikes  *int
+	ReturnStationAvailableBikes *int
+}
+
+// Applies reports whether the rule condition is met.
+func (r Rule) Applies(conditions Conditions) bool {
+	switch r.Type {
+	case RuleSchedule:
+		if len(r.Weekdays) > 0 && !slices.Contains(r.Weekdays, conditions.StartTime.Weekday()) {
+			return false
+		}
+
+		hour := conditions.StartTime.Hour()
+		switch {
+		case r.StartHour == r.EndHour:
+			return true
+		case r.StartHour < r.EndHour:
+			return hour >= r.StartHour && hour < r.EndHour
+		default:
+			return hour >= r.StartHour || hour < r.EndHour
+		}
+	case RuleHoliday:
+		return conditions.Holiday
+	case RuleSurge:
+		return conditions.StartStationAvailableBikes != nil && *conditions.StartStationAvailableBikes <= r.MaxAvailableBikes
+	case RuleDepletedReturn:
+		return conditions.ReturnStationAvailableBikes != nil && *conditions.ReturnStationAvailableBikes <= r.MaxAvailableBikes
+	default:
+		return false
+	}
+}
+
+// adjustmentFactor returns the share of a ride time charge the rule adds to it.
+func (r Rule) adjustmentFactor() *big.Rat {
+	factor := decimalRat(r.Multiplier)
+	return factor.Sub(factor, big.NewRat(1, 1))
+}
@@ -9,6 +9,12 @@ import (
 	"github.com/resend/resend-go/v2"
 )
 
+// EmailAttachment is a file attached to an email.
+type EmailAttachment struct {
+	Filename string
+	Content  []byte
+}
+
 // SendEmail sends an email using the Resend API.
 //
 // Parameters:
@@ -19,6 +25,20 @@ import (
 // Returns:
 // - error: an error if there was a problem sending the email.
 func SendEmail(toEmail []string, subject string, body string) error {
+	return SendEmailWithAttachments(toEmail, subject, body, nil)
+}
+
+// SendEmailWithAttachments sends an email with attached files using the Resend API.
+//
+// Parameters:
+// - toEmail: a slice of email addresses to send the email to.
+// - subject: the subject of the email.
+// - body: the body of the email.
+// - attachments: the files attached to the email.
+//
+// Returns:
+// - error: an error if there was a problem sending the email.
+func SendEmailWithAttachments(toEmail []string, subject string, body string, attachments []EmailAttachment) error {
 	const emailFrom = "Ebike Rental <ebike@vinniciusgomes.com>"
 	const replyTo = "reply@vinniciusgomes.com"
 
@@ -37,6 +57,13 @@ func SendEmail(toEmail []string, subject string, body string) error {
 		ReplyTo: replyTo,
 	}
 
+	for _, attachment := range attachments {
+		params.Attachments = append(params.Attachments, &resend.Attachment{
+			Filename: attachment.Filename,
+			Content:  attachment.Content,
+		})
+	}
+
 	sent, err := client.Emails.Send(params)
 	if err != nil {
 		fmt.Println(err.Error())
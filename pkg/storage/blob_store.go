@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when the requested object does not exist in the store.
+var ErrNotFound = errors.New("object not found")
+
+// BlobStore stores binary objects (images, documents) under string keys.
+type BlobStore interface {
+	// Put stores the content of r under key, replacing any existing object.
+	Put(ctx context.Context, key string, contentType string, r io.Reader, size int64) error
+	// Get returns the content of the object stored under key and its content type.
+	// The caller must close the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that grants read access to the object for the given duration.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
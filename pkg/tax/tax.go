@@ -0,0 +1,109 @@
+package tax
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/vinniciusgomes/ebike-rental-service/pkg/money"
+)
+
+// Rate is a tax charged on a price, such as a VAT or a city tax. An inclusive rate is part of
+// the price; an exclusive rate is added on top of it.
+type Rate struct {
+	Name      string
+	Percent   float64
+	Inclusive bool
+}
+
+// Line is a tax charged on a price. Base is the price without any tax the amount is computed
+// on.
+type Line struct {
+	Name      string      `json:"name"`
+	Percent   float64     `json:"percent"`
+	Inclusive bool        `json:"inclusive"`
+	Base      money.Money `json:"base"`
+	Amount    money.Money `json:"amount"`
+}
+
+// Assessment is the tax charged on a price. Net is the price without taxes and Total is what
+// the customer pays, that is the price plus the exclusive taxes.
+type Assessment struct {
+	Net   money.Money `json:"net"`
+	Lines []Line      `json:"lines"`
+	Total money.Money `json:"total"`
+}
+
+// TaxTotal returns the sum of the taxes of the assessment, inclusive or not.
+func (a Assessment) TaxTotal() money.Money {
+	total := money.New(0, a.Total.Currency)
+	for _, line := range a.Lines {
+		total = total.Add(line.Amount)
+	}
+
+	return total
+}
+
+// Assess computes the taxes charged on a price.
+//
+// Every rate is computed on the same base, the price without taxes. The inclusive rates are
+// first taken out of the price: the exact base is the price divided by one plus their sum, and
+// each inclusive tax is its rate of that base. The exclusive rates are then added on top of the
+// price. Each tax is computed exactly and rounded once, and the
+// base is the price less the rounded inclusive taxes, so the lines always add up to the total.
+// There is one line per rate, in the order of the rates. Prices of zero or below are not taxed
+// and have no lines.
+//
+// Parameters:
+// - price: the price, which includes the inclusive taxes.
+// - rates: the rates in force.
+// - mode: how each tax is rounded to minor units.
+//
+// Returns:
+// - Assessment: the taxes and the total.
+func Assess(price money.Money, rates []Rate, mode money.RoundingMode) Assessment {
+	assessment := Assessment{Net: price, Lines: []Line{}, Total: price}
+	if price.IsZero() || price.IsNegative() {
+		return assessment
+	}
+
+	included := new(big.Rat)
+	for _, rate := range rates {
+		if rate.Inclusive {
+			included.Add(included, percentRat(rate.Percent))
+		}
+	}
+
+	base := new(big.Rat).Quo(price.Minor(), included.Add(included, big.NewRat(1, 1)))
+
+	amounts := make([]money.Money, len(rates))
+	for i, rate := range rates {
+		if rate.Inclusive {
+			amounts[i] = money.FromMinor(new(big.Rat).Mul(base, percentRat(rate.Percent)), price.Currency, mode)
+			assessment.Net = assessment.Net.Sub(amounts[i])
+		}
+	}
+
+	for i, rate := range rates {
+		if !rate.Inclusive {
+			amounts[i] = assessment.Net.Mul(percentRat(rate.Percent), mode)
+			assessment.Total = assessment.Total.Add(amounts[i])
+		}
+
+		assessment.Lines = append(assessment.Lines, Line{Name: rate.Name, Percent: rate.Percent, Inclusive: rate.Inclusive, Base: assessment.Net, Amount: amounts[i]})
+	}
+
+	return assessment
+}
+
+// FormatPercent writes a rate percentage in its shortest decimal form, such as "21%" or
+// "5.5%".
+func FormatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64) + "%"
+}
+
+// percentRat converts a percentage into an exact fraction. It is read from its shortest decimal
+// form so that 5.5 is exactly 55/1000.
+func percentRat(percent float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(percent, 'f', -1, 64))
+	return r.Quo(r, big.NewRat(100, 1))
+}